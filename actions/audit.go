@@ -1,15 +1,21 @@
 package actions
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
-	gomail "gopkg.in/gomail.v2"
+	"golang.org/x/term"
 	"restic-kit/restic"
 	"restic-kit/shared"
 )
@@ -18,6 +24,31 @@ import (
 type AuditConfig struct {
 	GrowThreshold   float64
 	ShrinkThreshold float64
+	PathThresholds  []string
+	Thresholds      map[string]SizeThresholds
+	MinSnapshots    int
+	MaxAge          time.Duration
+	MinInterval     time.Duration
+	ChurnThreshold  float64
+	Output          string
+	Color           string
+	Baseline        string
+	GroupBy         string
+	EmailOnSuccess  bool
+	IncludeCheck    bool
+	EmailGroupBy    string
+	RepoName        string
+	Since           string
+	Until           string
+	TimeRange       shared.TimeRange
+	Repo            string
+	PasswordFile    string
+	Timezone        string
+	Location        *time.Location
+	IncludePaths    []string
+	ExcludePaths    []string
+	ResultFile      string
+	Stdin           bool
 	*shared.NotifyEmailConfig
 }
 
@@ -29,76 +60,584 @@ func ValidateAuditConfig(cfg *AuditConfig) error {
 	if cfg.ShrinkThreshold < 0 {
 		return fmt.Errorf("shrink-threshold must be non-negative")
 	}
+	thresholds, err := parsePathThresholds(cfg.PathThresholds, SizeThresholds{Grow: cfg.GrowThreshold, Shrink: cfg.ShrinkThreshold})
+	if err != nil {
+		return err
+	}
+	cfg.Thresholds = thresholds
+	if cfg.MinSnapshots < 0 {
+		return fmt.Errorf("min-snapshots must be non-negative")
+	}
+	if cfg.MaxAge < 0 {
+		return fmt.Errorf("max-age must be non-negative")
+	}
+	if cfg.MinInterval < 0 {
+		return fmt.Errorf("min-interval must be non-negative")
+	}
+	if cfg.ChurnThreshold < 0 || cfg.ChurnThreshold > 100 {
+		return fmt.Errorf("churn-threshold must be between 0 and 100")
+	}
+	if cfg.Output == "" {
+		cfg.Output = "text"
+	}
+	if cfg.Output != "text" && cfg.Output != "json" {
+		return fmt.Errorf("output must be either text or json")
+	}
+	if cfg.Color == "" {
+		cfg.Color = "auto"
+	}
+	if cfg.Color != "auto" && cfg.Color != "always" && cfg.Color != "never" {
+		return fmt.Errorf("color must be one of auto, always or never")
+	}
+	if cfg.GroupBy == "" {
+		cfg.GroupBy = "paths"
+	}
+	if cfg.GroupBy != "paths" && cfg.GroupBy != "tags" {
+		return fmt.Errorf("group-by must be either paths or tags")
+	}
+	if cfg.EmailGroupBy == "" {
+		cfg.EmailGroupBy = "check"
+	}
+	if cfg.EmailGroupBy != "check" && cfg.EmailGroupBy != "path" {
+		return fmt.Errorf("email-group-by must be either check or path")
+	}
+	timeRange, err := shared.ParseTimeRange(cfg.Since, cfg.Until, time.Now())
+	if err != nil {
+		return err
+	}
+	cfg.TimeRange = timeRange
+	for _, pattern := range append(append([]string{}, cfg.IncludePaths...), cfg.ExcludePaths...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+		}
+		cfg.Location = loc
+	}
+	if cfg.PasswordFile != "" && cfg.Repo == "" {
+		return fmt.Errorf("password-file requires repo")
+	}
 	if cfg.NotifyEmailConfig != nil {
 		return shared.ValidateNotifyEmailConfig(cfg.NotifyEmailConfig)
 	}
 	return nil
 }
 
+// SizeThresholds are the maximum growth and shrink percentages of a path
+type SizeThresholds struct {
+	Grow   float64 `json:"grow"`
+	Shrink float64 `json:"shrink"`
+}
+
+// parsePathThresholds parses per-path overrides like
+// "/var/lib/pg:grow=60,shrink=10". Thresholds that are not overridden are
+// taken from the defaults.
+func parsePathThresholds(overrides []string, defaults SizeThresholds) (map[string]SizeThresholds, error) {
+	thresholds := make(map[string]SizeThresholds)
+	for _, override := range overrides {
+		sep := strings.LastIndex(override, ":")
+		if sep <= 0 || sep == len(override)-1 {
+			return nil, fmt.Errorf("invalid path-threshold %q: expected PATH:grow=N,shrink=N", override)
+		}
+
+		path := override[:sep]
+		pathThresholds := defaults
+		for _, setting := range strings.Split(override[sep+1:], ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(setting), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid path-threshold %q: expected key=value, got %q", override, setting)
+			}
+			percent, err := strconv.ParseFloat(value, 64)
+			if err != nil || percent < 0 {
+				return nil, fmt.Errorf("invalid path-threshold %q: %s must be a non-negative number", override, key)
+			}
+			switch key {
+			case "grow":
+				pathThresholds.Grow = percent
+			case "shrink":
+				pathThresholds.Shrink = percent
+			default:
+				return nil, fmt.Errorf("invalid path-threshold %q: unknown key %q, expected grow or shrink", override, key)
+			}
+		}
+		thresholds[path] = pathThresholds
+	}
+	return thresholds, nil
+}
+
 // AuditCheckResult represents a failed audit check
 type AuditCheckResult struct {
-	CheckType string
-	Path      string
-	Message   string
-	Details   map[string]string
+	CheckType string            `json:"check_type"`
+	Path      string            `json:"path"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// AuditReport is the machine-readable audit summary printed with --output json
+type AuditReport struct {
+	Repository string             `json:"repository,omitempty"`
+	Passed     bool               `json:"passed"`
+	Thresholds AuditThresholds    `json:"thresholds"`
+	Checks     []AuditCheckResult `json:"checks"`
+}
+
+// AuditThresholds are the audit settings a report was generated with
+type AuditThresholds struct {
+	GrowThreshold   float64                   `json:"grow_threshold"`
+	ShrinkThreshold float64                   `json:"shrink_threshold"`
+	MinSnapshots    int                       `json:"min_snapshots"`
+	MaxAge          string                    `json:"max_age"`
+	MinInterval     string                    `json:"min_interval"`
+	ChurnThreshold  float64                   `json:"churn_threshold"`
+	PathThresholds  map[string]SizeThresholds `json:"path_thresholds,omitempty"`
 }
 
 // AuditAction performs audit checks on snapshots
 type AuditAction struct {
 	*BaseAction
 	config *AuditConfig
+	// stdin is read instead of log directories with --stdin
+	stdin io.Reader
 }
 
 func NewAuditAction(cfg *AuditConfig) *AuditAction {
 	return &AuditAction{
 		BaseAction: NewBaseAction("audit"),
 		config:     cfg,
+		stdin:      os.Stdin,
 	}
 }
 
 func (a *AuditAction) Execute(args []string, dryRun bool) error {
-	if len(args) != 1 {
-		return fmt.Errorf("audit requires exactly one argument: the path to the log directory")
+	if len(args) == 0 && a.config.Repo == "" && !a.config.Stdin {
+		return WithExitCode(ExitConfigError, fmt.Errorf("audit requires at least one log directory, --repo or --stdin"))
 	}
 
-	logDir := args[0]
-
-	// Read snapshots from snapshots.out
-	snapshots, err := a.readSnapshots(logDir)
-	if err != nil {
-		return fmt.Errorf("failed to read snapshots: %w", err)
+	// Keep stdout valid JSON for piping, everything else goes to stderr
+	if a.config.Output == "json" {
+		defer shared.SetLogOutput(shared.SetLogOutput(os.Stderr))
 	}
 
 	// Perform audit checks
 	var failedChecks []AuditCheckResult
 
-	// Check size changes
-	sizeViolations := a.checkSizeChanges(snapshots)
-	failedChecks = append(failedChecks, sizeViolations...)
+	// A failed or missing snapshots command is itself a violation. The
+	// snapshots of all directories are merged, the remaining checks need
+	// them and are skipped if none are available.
+	var snapshots []restic.Snapshot
+	available := false
+	seen := make(map[string]bool)
+	addSnapshots := func(found []restic.Snapshot) {
+		for _, snap := range found {
+			if snap.ID != "" && seen[snap.ID] {
+				continue
+			}
+			seen[snap.ID] = true
+			snapshots = append(snapshots, snap)
+		}
+	}
+
+	// The output of restic snapshots --json can be piped in instead
+	if a.config.Stdin {
+		content, err := io.ReadAll(a.stdin)
+		if err != nil {
+			return WithExitCode(ExitParseError, fmt.Errorf("failed to read snapshots from stdin: %w", err))
+		}
+		stdinSnapshots, err := restic.ParseSnapshotsOutput(string(content))
+		if err != nil {
+			return WithExitCode(ExitParseError, fmt.Errorf("failed to parse snapshots from stdin: %w", err))
+		}
+		available = true
+		addSnapshots(stdinSnapshots)
+	}
+
+	// Without log directories, or if one of them has no snapshots log,
+	// the snapshots are read from the repository directly. Logs written
+	// outside of --since and --until are treated as missing, but the
+	// snapshot list itself is never filtered, the retention and size checks
+	// need the earlier snapshots.
+	fetchLive := len(args) == 0 && !a.config.Stdin
+	for _, logDir := range args {
+		if a.config.Repo != "" && (!hasSnapshotsLog(logDir) || !a.logInTimeRange(logDir, "snapshots")) {
+			fetchLive = true
+			continue
+		}
+		if !a.logInTimeRange(logDir, "snapshots") {
+			failedChecks = append(failedChecks, AuditCheckResult{
+				CheckType: "snapshots_unavailable",
+				Message:   "snapshots command did not run within the time range",
+				Details:   map[string]string{"log_dir": logDir},
+			})
+			continue
+		}
+		if unavailable := a.checkSnapshotsAvailable(logDir); unavailable != nil {
+			failedChecks = append(failedChecks, *unavailable)
+			continue
+		}
+		available = true
+
+		// Read snapshots from snapshots.out
+		dirSnapshots, err := a.readSnapshots(logDir)
+		if err != nil {
+			return WithExitCode(ExitParseError, fmt.Errorf("failed to read snapshots: %w", err))
+		}
+		addSnapshots(dirSnapshots)
+	}
+
+	if fetchLive {
+		liveSnapshots, err := a.fetchSnapshots(dryRun)
+		if err != nil {
+			failedChecks = append(failedChecks, AuditCheckResult{
+				CheckType: "snapshots_unavailable",
+				Message:   "restic snapshots failed",
+				Details:   map[string]string{"repo": a.config.Repo, "error": err.Error()},
+			})
+		} else if !dryRun {
+			available = true
+			addSnapshots(liveSnapshots)
+		}
+	}
 
-	// Send email if there are failures and email config is provided
-	if len(failedChecks) > 0 && a.config.NotifyEmailConfig != nil {
-		if err := a.sendAuditEmail(failedChecks, dryRun); err != nil {
-			return fmt.Errorf("failed to send audit email: %w", err)
+	if available {
+		snapshots = a.filterPaths(snapshots)
+
+		// Check size changes
+		sizeViolations := a.checkSizeChanges(snapshots)
+		failedChecks = append(failedChecks, sizeViolations...)
+
+		// Check snapshot counts
+		countViolations := a.checkMinimumSnapshots(snapshots)
+		failedChecks = append(failedChecks, countViolations...)
+
+		// Check snapshot age
+		staleViolations := a.checkStaleSnapshots(snapshots)
+		failedChecks = append(failedChecks, staleViolations...)
+
+		// Check for snapshots taken too close together
+		duplicateViolations := a.checkDuplicateSnapshots(snapshots)
+		failedChecks = append(failedChecks, duplicateViolations...)
+
+		// Check for snapshots that changed most of their files
+		churnViolations := a.checkChurn(snapshots)
+		failedChecks = append(failedChecks, churnViolations...)
+	}
+
+	// Check repository integrity
+	if a.config.IncludeCheck {
+		for _, logDir := range args {
+			if violation := a.checkRepositoryIntegrity(logDir); violation != nil {
+				failedChecks = append(failedChecks, *violation)
+			}
 		}
 	}
 
+	// Send email if there are failures (or always with --email-on-success)
+	// and email config is provided. The result file is written even if
+	// sending fails.
+	var sendErr error
+	if (len(failedChecks) > 0 || a.config.EmailOnSuccess) && a.config.NotifyEmailConfig != nil {
+		if err := a.sendAuditEmail(failedChecks, len(snapshots), dryRun); err != nil {
+			sendErr = WithExitCode(ExitNotifyError, fmt.Errorf("failed to send audit email: %w", err))
+		}
+	}
+	result := &ResultFile{
+		Command:    a.GetName(),
+		Repository: a.config.RepoName,
+		Success:    len(failedChecks) == 0,
+		Violations: failedChecks,
+	}
+	if err := writeResultFile(a.config.ResultFile, result, sendErr, dryRun); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
 	// Report results
+	if a.config.Output == "json" {
+		if err := a.printJSONReport(failedChecks); err != nil {
+			return err
+		}
+		if len(failedChecks) > 0 {
+			return WithExitCode(ExitAuditFailed, fmt.Errorf("audit checks failed"))
+		}
+		return nil
+	}
+
 	if len(failedChecks) > 0 {
-		fmt.Printf("Audit FAILED: %d checks failed\n", len(failedChecks))
-		for _, check := range failedChecks {
-			fmt.Printf("- %s: %s\n", check.CheckType, check.Message)
+		fmt.Print(formatAuditTable(failedChecks, a.useColor()))
+		return WithExitCode(ExitAuditFailed, fmt.Errorf("audit checks failed"))
+	}
+
+	shared.Infof("Audit PASSED: All checks successful\n")
+	return nil
+}
+
+// useColor reports whether the console output should be colored
+func (a *AuditAction) useColor() bool {
+	switch a.config.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether the file is a terminal. Other character
+// devices such as /dev/null are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+const (
+	ansiRed   = "\033[31m"
+	ansiBold  = "\033[1m"
+	ansiReset = "\033[0m"
+)
+
+// formatAuditTable formats the failed checks as an aligned table with one
+// row per check, optionally with failures highlighted in red
+func formatAuditTable(failedChecks []AuditCheckResult, color bool) string {
+	paint := func(s, code string) string {
+		if !color {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	rows := [][3]string{{"PATH", "CHECK", "DETAIL"}}
+	for _, check := range failedChecks {
+		path := check.Path
+		if path == "" {
+			path = "-"
+		}
+		rows = append(rows, [3]string{path, check.CheckType, check.Message})
+	}
+
+	pathWidth, typeWidth := 0, 0
+	for _, row := range rows {
+		pathWidth = max(pathWidth, len(row[0]))
+		typeWidth = max(typeWidth, len(row[1]))
+	}
+
+	var out strings.Builder
+	out.WriteString(paint(fmt.Sprintf("Audit FAILED: %d checks failed", len(failedChecks)), ansiBold+ansiRed) + "\n")
+	for i, row := range rows {
+		checkType := fmt.Sprintf("%-*s", typeWidth, row[1])
+		if i > 0 {
+			checkType = paint(checkType, ansiRed)
+		}
+		out.WriteString(strings.TrimRight(fmt.Sprintf("%-*s  %s  %s", pathWidth, row[0], checkType, row[2]), " ") + "\n")
+	}
+	return out.String()
+}
+
+// printJSONReport prints the audit results as a JSON object
+func (a *AuditAction) printJSONReport(failedChecks []AuditCheckResult) error {
+	report := AuditReport{
+		Repository: a.config.RepoName,
+		Passed:     len(failedChecks) == 0,
+		Thresholds: AuditThresholds{
+			GrowThreshold:   a.config.GrowThreshold,
+			ShrinkThreshold: a.config.ShrinkThreshold,
+			MinSnapshots:    a.config.MinSnapshots,
+			MaxAge:          a.config.MaxAge.String(),
+			MinInterval:     a.config.MinInterval.String(),
+			ChurnThreshold:  a.config.ChurnThreshold,
+			PathThresholds:  a.config.Thresholds,
+		},
+		Checks: []AuditCheckResult{},
+	}
+	report.Checks = append(report.Checks, failedChecks...)
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit report: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// filterPaths drops the snapshots excluded by --include-path and
+// --exclude-path. A snapshot is audited if one of its paths matches an
+// include pattern, or no include patterns are given, and none of its paths
+// matches an exclude pattern. Exclude wins over include.
+func (a *AuditAction) filterPaths(snapshots []restic.Snapshot) []restic.Snapshot {
+	if len(a.config.IncludePaths) == 0 && len(a.config.ExcludePaths) == 0 {
+		return snapshots
+	}
+
+	var filtered []restic.Snapshot
+	for _, snap := range snapshots {
+		if len(a.config.IncludePaths) > 0 && !matchesAnyPath(snap.Paths, a.config.IncludePaths) {
+			continue
+		}
+		if matchesAnyPath(snap.Paths, a.config.ExcludePaths) {
+			shared.Verbosef("Not auditing snapshot %s of %s\n", snapshotShortID(snap), strings.Join(snap.Paths, ", "))
+			continue
+		}
+		filtered = append(filtered, snap)
+	}
+	return filtered
+}
+
+// matchesAnyPath reports whether one of the paths matches one of the glob
+// patterns
+func matchesAnyPath(paths, patterns []string) bool {
+	for _, path := range paths {
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, path); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkSnapshotsAvailable reports a violation if the snapshots command
+// failed or did not produce any output
+func (a *AuditAction) checkSnapshotsAvailable(logDir string) *AuditCheckResult {
+	exitcodeFile := restic.ResolveLogFile(filepath.Join(logDir, "snapshots.exitcode"))
+	if _, err := os.Stat(exitcodeFile); err == nil {
+		exitCode, err := restic.ReadExitCode(exitcodeFile)
+		if err != nil || exitCode != 0 {
+			details := map[string]string{"exitcode_file": exitcodeFile}
+			message := "snapshots command failed"
+			if err != nil {
+				message = "snapshots exit code could not be read"
+				details["error"] = err.Error()
+			} else {
+				details["exit_code"] = fmt.Sprintf("%d", exitCode)
+			}
+			return &AuditCheckResult{
+				CheckType: "snapshots_unavailable",
+				Message:   message,
+				Details:   details,
+			}
+		}
+	}
+
+	snapshotsFile := restic.ResolveLogFile(filepath.Join(logDir, "snapshots.out"))
+	if _, err := os.Stat(snapshotsFile); os.IsNotExist(err) {
+		return &AuditCheckResult{
+			CheckType: "snapshots_unavailable",
+			Message:   "snapshots.out is missing",
+			Details:   map[string]string{"snapshots_file": snapshotsFile},
 		}
-		return fmt.Errorf("audit checks failed")
 	}
 
-	fmt.Println("Audit PASSED: All checks successful")
 	return nil
 }
 
+// checkRepositoryIntegrity reports a violation if the check command in the
+// log directory failed, found errors or did not run at all
+func (a *AuditAction) checkRepositoryIntegrity(logDir string) *AuditCheckResult {
+	if !a.logInTimeRange(logDir, "check") {
+		return &AuditCheckResult{
+			CheckType: "repository_integrity",
+			Message:   "check did not run within the time range",
+			Details:   map[string]string{"log_dir": logDir},
+		}
+	}
+
+	exitcodeFile := restic.ResolveLogFile(filepath.Join(logDir, "check.exitcode"))
+	exitCode, err := restic.ReadExitCode(exitcodeFile)
+	if err != nil {
+		return &AuditCheckResult{
+			CheckType: "repository_integrity",
+			Message:   "check result is not available",
+			Details:   map[string]string{"exitcode_file": exitcodeFile, "error": err.Error()},
+		}
+	}
+
+	numErrors := 0
+	outFile := restic.ResolveLogFile(filepath.Join(logDir, "check.out"))
+	if content, err := restic.ReadLogFile(outFile); err == nil {
+		if result, err := restic.ParseCheckOutput(string(content), exitCode == 0); err == nil {
+			numErrors = result.NumErrors
+		}
+	}
+
+	if exitCode == 0 && numErrors == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("check found %d errors", numErrors)
+	if numErrors == 0 {
+		message = fmt.Sprintf("check failed with exit code %d", exitCode)
+	}
+	return &AuditCheckResult{
+		CheckType: "repository_integrity",
+		Message:   message,
+		Details: map[string]string{
+			"exit_code":  fmt.Sprintf("%d", exitCode),
+			"num_errors": fmt.Sprintf("%d", numErrors),
+		},
+	}
+}
+
+// logInTimeRange reports whether the log of the named command was written
+// within --since and --until, judged like notify-email by the mtime of its
+// exitcode file, or of its .out file if there is no exitcode file. Missing
+// logs count as in range, so they are reported as missing.
+func (a *AuditAction) logInTimeRange(logDir, name string) bool {
+	for _, file := range []string{name + ".exitcode", name + ".out"} {
+		info, err := os.Stat(restic.ResolveLogFile(filepath.Join(logDir, file)))
+		if err == nil {
+			if !a.config.TimeRange.Contains(info.ModTime()) {
+				shared.Verbosef("Skipping %s log in %s outside of the time range\n", name, logDir)
+				return false
+			}
+			return true
+		}
+	}
+	return true
+}
+
+// hasSnapshotsLog reports whether the log directory contains the output of
+// a snapshots command
+func hasSnapshotsLog(logDir string) bool {
+	_, err := os.Stat(restic.ResolveLogFile(filepath.Join(logDir, "snapshots.out")))
+	return err == nil
+}
+
+// fetchSnapshots runs restic snapshots --json against the configured
+// repository and parses its output. The snapshots are grouped by paths like
+// in backup.sh, which is the format ParseSnapshotsOutput expects.
+func (a *AuditAction) fetchSnapshots(dryRun bool) ([]restic.Snapshot, error) {
+	args := []string{"snapshots", "--json", "--group-by=paths", "--repo", a.config.Repo}
+	if a.config.PasswordFile != "" {
+		args = append(args, "--password-file", a.config.PasswordFile)
+	}
+
+	if dryRun {
+		fmt.Fprintf(shared.LogOutput(), "DRY RUN: Would run restic %s\n", strings.Join(args, " "))
+		return nil, nil
+	}
+
+	shared.Verbosef("Running restic %s\n", strings.Join(args, " "))
+	var stderr bytes.Buffer
+	cmd := exec.Command("restic", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return restic.ParseSnapshotsOutput(string(output))
+}
+
 func (a *AuditAction) readSnapshots(logDir string) ([]restic.Snapshot, error) {
-	snapshotsFile := filepath.Join(logDir, "snapshots.out")
-	content, err := os.ReadFile(snapshotsFile)
+	snapshotsFile := restic.ResolveLogFile(filepath.Join(logDir, "snapshots.out"))
+	content, err := restic.ReadLogFile(snapshotsFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read snapshots file: %w", err)
 	}
@@ -109,10 +648,10 @@ func (a *AuditAction) readSnapshots(logDir string) ([]restic.Snapshot, error) {
 func (a *AuditAction) checkSizeChanges(snapshots []restic.Snapshot) []AuditCheckResult {
 	var violations []AuditCheckResult
 
-	// Group snapshots by path
+	// Group snapshots by path or tag
 	groupedByPath := make(map[string][]restic.Snapshot)
 	for _, snap := range snapshots {
-		key := strings.Join(snap.Paths, ", ")
+		key := a.groupKey(snap)
 		groupedByPath[key] = append(groupedByPath[key], snap)
 	}
 
@@ -121,16 +660,21 @@ func (a *AuditAction) checkSizeChanges(snapshots []restic.Snapshot) []AuditCheck
 			continue // Need at least 2 snapshots to compare
 		}
 
-		// Sort by time
-		sort.Slice(snaps, func(i, j int) bool {
-			t1, _ := time.Parse(time.RFC3339Nano, snaps[i].Time)
-			t2, _ := time.Parse(time.RFC3339Nano, snaps[j].Time)
-			return t1.Before(t2)
-		})
+		sortSnapshotsByTime(snaps)
 
-		// Compare only the two most recent snapshots
-		prev := snaps[len(snaps)-2] // Second most recent
-		curr := snaps[len(snaps)-1] // Most recent
+		// Compare the most recent snapshot against the baseline, which is
+		// the second most recent one unless --baseline is set
+		curr := snaps[len(snaps)-1]
+		prev, ok := a.selectBaseline(snaps)
+		if !ok {
+			continue
+		}
+
+		// Corrupt summaries would produce meaningless percentages
+		if anomaly := a.dataAnomaly(path, prev, curr); anomaly != nil {
+			violations = append(violations, *anomaly)
+			continue
+		}
 
 		if prev.Summary.TotalBytesProcessed == 0 {
 			continue // Skip if previous size is 0
@@ -138,19 +682,25 @@ func (a *AuditAction) checkSizeChanges(snapshots []restic.Snapshot) []AuditCheck
 
 		changePercent := float64(curr.Summary.TotalBytesProcessed-prev.Summary.TotalBytesProcessed) / float64(prev.Summary.TotalBytesProcessed) * 100
 
+		// Per-path overrides take precedence over the global thresholds
+		thresholds, ok := a.config.Thresholds[path]
+		if !ok {
+			thresholds = SizeThresholds{Grow: a.config.GrowThreshold, Shrink: a.config.ShrinkThreshold}
+		}
+
 		var threshold float64
 		var checkType string
 		if changePercent > 0 {
-			threshold = a.config.GrowThreshold
+			threshold = thresholds.Grow
 			checkType = "size_growth"
 		} else {
-			threshold = a.config.ShrinkThreshold
+			threshold = thresholds.Shrink
 			checkType = "size_shrink"
 			changePercent = -changePercent // Make positive for comparison
 		}
 
 		if changePercent >= threshold {
-			violations = append(violations, AuditCheckResult{
+			violation := AuditCheckResult{
 				CheckType: checkType,
 				Path:      path,
 				Message:   fmt.Sprintf("%.1f%% change exceeds %.1f%% threshold", changePercent, threshold),
@@ -159,8 +709,220 @@ func (a *AuditAction) checkSizeChanges(snapshots []restic.Snapshot) []AuditCheck
 					"current_size":   shared.FormatBytes(curr.Summary.TotalBytesProcessed),
 					"change_percent": fmt.Sprintf("%.1f", changePercent),
 					"threshold":      fmt.Sprintf("%.1f", threshold),
-					"previous_time":  prev.Time,
-					"current_time":   curr.Time,
+					"previous_time":  a.displayTime(prev.Time),
+					"current_time":   a.displayTime(curr.Time),
+				},
+			}
+			if a.config.Baseline != "" {
+				violation.Details["baseline_id"] = prev.ID
+				violation.Details["baseline_time"] = a.displayTime(prev.Time)
+			}
+			violations = append(violations, violation)
+		}
+	}
+
+	return violations
+}
+
+// dataAnomaly reports a snapshot with an impossible summary, e.g. negative
+// sizes, among the compared snapshots
+func (a *AuditAction) dataAnomaly(path string, snaps ...restic.Snapshot) *AuditCheckResult {
+	for _, snap := range snaps {
+		if err := snap.Summary.Validate(); err != nil {
+			return &AuditCheckResult{
+				CheckType: "data_anomaly",
+				Path:      path,
+				Message:   fmt.Sprintf("snapshot %s has an invalid summary: %v", snapshotShortID(snap), err),
+				Details: map[string]string{
+					"snapshot_id":   snap.ID,
+					"snapshot_time": a.displayTime(snap.Time),
+					"error":         err.Error(),
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// displayTime converts a snapshot time to the --timezone location. Without a
+// timezone, or if the time cannot be parsed, it is returned as recorded.
+func (a *AuditAction) displayTime(t string) string {
+	if a.config.Location == nil {
+		return t
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return t
+	}
+	return parsed.In(a.config.Location).Format(time.RFC3339)
+}
+
+// selectBaseline picks the snapshot the most recent one is compared against
+// from a list sorted by time. It returns false if there is no usable baseline.
+func (a *AuditAction) selectBaseline(snaps []restic.Snapshot) (restic.Snapshot, bool) {
+	curr := snaps[len(snaps)-1]
+
+	switch a.config.Baseline {
+	case "":
+		return snaps[len(snaps)-2], true
+	case "oldest":
+		return snaps[0], true
+	case "first-of-week":
+		// Weeks start on Monday, as in ISO 8601
+		currTime, err := time.Parse(time.RFC3339Nano, curr.Time)
+		if err != nil {
+			return restic.Snapshot{}, false
+		}
+		currYear, currWeek := currTime.ISOWeek()
+		for _, snap := range snaps[:len(snaps)-1] {
+			t, err := time.Parse(time.RFC3339Nano, snap.Time)
+			if err != nil {
+				continue
+			}
+			if year, week := t.ISOWeek(); year == currYear && week == currWeek {
+				return snap, true
+			}
+		}
+		return restic.Snapshot{}, false
+	default:
+		// Match a full or abbreviated snapshot ID
+		for _, snap := range snaps[:len(snaps)-1] {
+			if snap.ID != "" && strings.HasPrefix(snap.ID, a.config.Baseline) {
+				return snap, true
+			}
+		}
+		return restic.Snapshot{}, false
+	}
+}
+
+// groupKey returns the key snapshots are grouped by for the audit checks
+func (a *AuditAction) groupKey(snap restic.Snapshot) string {
+	if a.config.GroupBy == "tags" {
+		if len(snap.Tags) == 0 {
+			return "(untagged)"
+		}
+		tags := append([]string(nil), snap.Tags...)
+		sort.Strings(tags)
+		return strings.Join(tags, ", ")
+	}
+	return strings.Join(snap.Paths, ", ")
+}
+
+// checkMinimumSnapshots flags paths that have fewer snapshots than the
+// configured minimum, which usually means backups silently stopped
+func (a *AuditAction) checkMinimumSnapshots(snapshots []restic.Snapshot) []AuditCheckResult {
+	var violations []AuditCheckResult
+
+	if a.config.MinSnapshots <= 0 {
+		return violations
+	}
+
+	// Count snapshots by path or tag
+	countByPath := make(map[string]int)
+	for _, snap := range snapshots {
+		key := a.groupKey(snap)
+		countByPath[key]++
+	}
+
+	for path, count := range countByPath {
+		if count < a.config.MinSnapshots {
+			violations = append(violations, AuditCheckResult{
+				CheckType: "min_snapshots",
+				Path:      path,
+				Message:   fmt.Sprintf("%d snapshots found, expected at least %d", count, a.config.MinSnapshots),
+				Details: map[string]string{
+					"path":             path,
+					"snapshot_count":   fmt.Sprintf("%d", count),
+					"expected_minimum": fmt.Sprintf("%d", a.config.MinSnapshots),
+				},
+			})
+		}
+	}
+
+	return violations
+}
+
+// checkStaleSnapshots flags paths whose newest snapshot is older than the
+// configured maximum age
+func (a *AuditAction) checkStaleSnapshots(snapshots []restic.Snapshot) []AuditCheckResult {
+	var violations []AuditCheckResult
+
+	if a.config.MaxAge <= 0 {
+		return violations
+	}
+
+	// Find the newest snapshot per path or tag
+	newestByPath := make(map[string]time.Time)
+	newestTimeStr := make(map[string]string)
+	for _, snap := range snapshots {
+		key := a.groupKey(snap)
+		t, err := time.Parse(time.RFC3339Nano, snap.Time)
+		if err != nil {
+			shared.Infof("Note: skipping snapshot %s of %s with unparseable time %q\n", snap.ShortID, key, snap.Time)
+			continue
+		}
+		if newest, ok := newestByPath[key]; !ok || t.After(newest) {
+			newestByPath[key] = t
+			newestTimeStr[key] = snap.Time
+		}
+	}
+
+	now := time.Now()
+	for path, newest := range newestByPath {
+		age := now.Sub(newest)
+		if age > a.config.MaxAge {
+			violations = append(violations, AuditCheckResult{
+				CheckType: "stale_snapshot",
+				Path:      path,
+				Message:   fmt.Sprintf("newest snapshot is %s old, exceeds %s maximum age", age.Round(time.Minute), a.config.MaxAge),
+				Details: map[string]string{
+					"age":         age.Round(time.Minute).String(),
+					"max_age":     a.config.MaxAge.String(),
+					"newest_time": a.displayTime(newestTimeStr[path]),
+				},
+			})
+		}
+	}
+
+	return violations
+}
+
+// checkDuplicateSnapshots flags consecutive snapshots of a path that were
+// taken less than the minimum interval apart, e.g. by a duplicated cron job
+func (a *AuditAction) checkDuplicateSnapshots(snapshots []restic.Snapshot) []AuditCheckResult {
+	var violations []AuditCheckResult
+
+	if a.config.MinInterval <= 0 {
+		return violations
+	}
+
+	groupedByPath := make(map[string][]restic.Snapshot)
+	for _, snap := range snapshots {
+		key := a.groupKey(snap)
+		groupedByPath[key] = append(groupedByPath[key], snap)
+	}
+
+	for path, snaps := range groupedByPath {
+		sortSnapshotsByTime(snaps)
+		for i := 1; i < len(snaps); i++ {
+			prevTime, err1 := time.Parse(time.RFC3339Nano, snaps[i-1].Time)
+			currTime, err2 := time.Parse(time.RFC3339Nano, snaps[i].Time)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			delta := currTime.Sub(prevTime)
+			if delta >= a.config.MinInterval {
+				continue
+			}
+			violations = append(violations, AuditCheckResult{
+				CheckType: "duplicate_snapshot",
+				Path:      path,
+				Message:   fmt.Sprintf("snapshots taken %s apart, less than the %s minimum interval", delta, a.config.MinInterval),
+				Details: map[string]string{
+					"previous_time": a.displayTime(snaps[i-1].Time),
+					"current_time":  a.displayTime(snaps[i].Time),
+					"delta":         delta.String(),
+					"min_interval":  a.config.MinInterval.String(),
 				},
 			})
 		}
@@ -169,49 +931,153 @@ func (a *AuditAction) checkSizeChanges(snapshots []restic.Snapshot) []AuditCheck
 	return violations
 }
 
-func (a *AuditAction) sendAuditEmail(failedChecks []AuditCheckResult, dryRun bool) error {
-	subject := "Audit Report: FAILURES DETECTED"
+// checkChurn flags paths whose newest snapshot changed more than the
+// configured percentage of its files, e.g. when ransomware rewrote them
+// without changing the total size
+func (a *AuditAction) checkChurn(snapshots []restic.Snapshot) []AuditCheckResult {
+	var violations []AuditCheckResult
+
+	if a.config.ChurnThreshold <= 0 {
+		return violations
+	}
+
+	groupedByPath := make(map[string][]restic.Snapshot)
+	for _, snap := range snapshots {
+		key := a.groupKey(snap)
+		groupedByPath[key] = append(groupedByPath[key], snap)
+	}
+
+	for path, snaps := range groupedByPath {
+		sortSnapshotsByTime(snaps)
+		newest := snaps[len(snaps)-1]
+		if newest.Summary.TotalFilesProcessed == 0 {
+			continue
+		}
+
+		ratio := float64(newest.Summary.FilesChanged) / float64(newest.Summary.TotalFilesProcessed) * 100
+		if ratio <= a.config.ChurnThreshold {
+			continue
+		}
+		violations = append(violations, AuditCheckResult{
+			CheckType: "high_churn",
+			Path:      path,
+			Message:   fmt.Sprintf("%.1f%% of files changed, exceeds %.1f%% threshold", ratio, a.config.ChurnThreshold),
+			Details: map[string]string{
+				"snapshot_time":         a.displayTime(newest.Time),
+				"files_changed":         fmt.Sprintf("%d", newest.Summary.FilesChanged),
+				"total_files_processed": fmt.Sprintf("%d", newest.Summary.TotalFilesProcessed),
+				"churn_percent":         fmt.Sprintf("%.2f", ratio),
+				"threshold":             fmt.Sprintf("%.2f", a.config.ChurnThreshold),
+			},
+		})
+	}
+
+	return violations
+}
+
+// sortSnapshotsByTime sorts snapshots from oldest to newest
+func sortSnapshotsByTime(snaps []restic.Snapshot) {
+	sort.Slice(snaps, func(i, j int) bool {
+		t1, _ := time.Parse(time.RFC3339Nano, snaps[i].Time)
+		t2, _ := time.Parse(time.RFC3339Nano, snaps[j].Time)
+		return t1.Before(t2)
+	})
+}
+
+// sendAuditEmail sends the audit report. Subject templates get the number of
+// audited snapshots as Total and the number of failed checks as Failed.
+func (a *AuditAction) sendAuditEmail(failedChecks []AuditCheckResult, numSnapshots int, dryRun bool) error {
+	status := "FAILURES DETECTED"
+	if len(failedChecks) == 0 {
+		status = "PASSED"
+	}
+	subject, err := shared.BuildSubject(a.config.NotifyEmailConfig, a.auditTitle(status), shared.SubjectData{
+		Success:  len(failedChecks) == 0,
+		Status:   status,
+		Total:    numSnapshots,
+		Failed:   len(failedChecks),
+		RepoName: a.config.RepoName,
+	})
+	if err != nil {
+		return err
+	}
 	body := a.generateAuditEmailBody(failedChecks)
 
 	if dryRun {
-		fmt.Println("DRY RUN: Would send audit email with subject:", subject)
-		fmt.Println("DRY RUN: Email body preview:")
-		fmt.Println(body)
+		fmt.Fprintln(shared.LogOutput(), "DRY RUN: Would send audit email with subject:", subject)
+		fmt.Fprintln(shared.LogOutput(), "DRY RUN: Email body preview:")
+		fmt.Fprintln(shared.LogOutput(), body)
 		return nil
 	}
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", a.config.From)
-	m.SetHeader("To", a.config.To)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/plain", body)
-
-	d := gomail.NewDialer(a.config.SMTPHost, a.config.SMTPPort, a.config.SMTPUsername, a.config.SMTPPassword)
-
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	if err := shared.SendEmail(a.config.NotifyEmailConfig, subject, body, "", nil, false); err != nil {
+		return err
 	}
 
-	fmt.Println("Audit email sent successfully")
+	shared.Infof("Audit email sent successfully\n")
 	return nil
 }
 
+// auditTitle returns the audit report title, labeled with the repository name
+// if one is set
+func (a *AuditAction) auditTitle(status string) string {
+	if a.config.RepoName != "" {
+		return fmt.Sprintf("Audit Report (%s): %s", a.config.RepoName, status)
+	}
+	return "Audit Report: " + status
+}
+
 func (a *AuditAction) generateAuditEmailBody(failedChecks []AuditCheckResult) string {
 	var body strings.Builder
 
-	body.WriteString("Audit Report: FAILURES DETECTED\n\n")
+	if len(failedChecks) == 0 {
+		body.WriteString(a.auditTitle("PASSED") + "\n\n")
+		body.WriteString("All checks successful\n")
+		return body.String()
+	}
+
+	body.WriteString(a.auditTitle("FAILURES DETECTED") + "\n\n")
 	body.WriteString(fmt.Sprintf("Total failed checks: %d\n\n", len(failedChecks)))
 
-	// Group by check type
-	checksByType := make(map[string][]AuditCheckResult)
+	// Group by check type, or by path to see everything wrong with a path at once
+	byPath := a.config.EmailGroupBy == "path"
+	grouped := make(map[string][]AuditCheckResult)
 	for _, check := range failedChecks {
-		checksByType[check.CheckType] = append(checksByType[check.CheckType], check)
+		key := check.CheckType
+		if byPath {
+			key = check.Path
+		}
+		grouped[key] = append(grouped[key], check)
 	}
 
-	for checkType, checks := range checksByType {
-		body.WriteString(fmt.Sprintf("=== %s ===\n", strings.ToUpper(checkType)))
+	// Sections are sorted so the email reads the same on every run, checks
+	// without a path such as a failed snapshots command come last
+	keys := make([]string, 0, len(grouped))
+	for key := range grouped {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "" || keys[j] == "" {
+			return keys[j] == ""
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		checks := grouped[key]
+		if byPath && key == "" {
+			body.WriteString("=== General ===\n")
+		} else if byPath {
+			body.WriteString(fmt.Sprintf("=== %s ===\n", key))
+		} else {
+			body.WriteString(fmt.Sprintf("=== %s ===\n", strings.ToUpper(key)))
+		}
 		for _, check := range checks {
-			body.WriteString(fmt.Sprintf("Path: %s\n", check.Path))
+			if byPath {
+				body.WriteString(fmt.Sprintf("Check: %s\n", check.CheckType))
+			} else {
+				body.WriteString(fmt.Sprintf("Path: %s\n", check.Path))
+			}
 			body.WriteString(fmt.Sprintf("Issue: %s\n", check.Message))
 			if len(check.Details) > 0 {
 				body.WriteString("Details:\n")
@@ -229,55 +1095,159 @@ func (a *AuditAction) generateAuditEmailBody(failedChecks []AuditCheckResult) st
 
 func NewAuditCmd() *cobra.Command {
 	var growThreshold, shrinkThreshold float64
-	var smtpHost, smtpUsername, smtpPassword, from, to string
-	var smtpPort int
+	var minSnapshots int
+	var maxAge, minInterval time.Duration
+	var churnThreshold float64
+	var pathThresholds, includePaths, excludePaths []string
+	var output, color, baseline, groupBy, emailGroupBy, logDirGlob, since, until, repo, passwordFile, timezone, resultFile string
+	var smtpHost, smtpUsername, smtpPassword, smtpPasswordFile, smtpEncryption, from, subjectPrefix, subjectTemplate string
+	var smtpAuth, smtpToken, smtpTokenFile, fromName, replyTo string
+	var to, cc, bcc []string
+	var smtpPort, smtpRetries int
+	var smtpInsecure, hostnameInSubject, emailOnSuccess, includeCheck, stdin bool
+	var smtpRetryDelay, smtpTimeout time.Duration
 
 	cmd := &cobra.Command{
-		Use:   "audit [log-directory]",
+		Use:   "audit [log-directory...]",
 		Short: "Audit snapshots for size anomalies",
 		Long: `Audit restic snapshots for size anomalies.
-Checks for unusual size changes between snapshots. Sends email notifications for any failures.`,
-		Args: cobra.ExactArgs(1),
+Checks for unusual size changes between snapshots and, optionally, for paths with too few or stale snapshots.
+Sends email notifications for any failures.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
+			// With --repo alone the snapshots are read from the repository
+			var logDirs []string
+			if len(args) > 0 || logDirGlob != "" || (repo == "" && !stdin) {
+				var err error
+				logDirs, err = resolveLogDirs(args, logDirGlob)
+				if err != nil {
+					return err
+				}
+			}
+
 			var emailConfig *shared.NotifyEmailConfig
-			if smtpHost != "" || smtpUsername != "" || smtpPassword != "" || from != "" || to != "" {
+			if smtpHost != "" || smtpUsername != "" || smtpPassword != "" || smtpPasswordFile != "" || smtpToken != "" || smtpTokenFile != "" || from != "" || len(to) > 0 {
+				password, err := shared.ResolveSMTPPassword(smtpPassword, smtpPasswordFile)
+				if err != nil {
+					return WithExitCode(ExitConfigError, fmt.Errorf("invalid audit config: %w", err))
+				}
+				token, err := shared.ResolveSMTPToken(smtpToken, smtpTokenFile)
+				if err != nil {
+					return WithExitCode(ExitConfigError, fmt.Errorf("invalid audit config: %w", err))
+				}
 				emailConfig = &shared.NotifyEmailConfig{
-					SMTPHost:     smtpHost,
-					SMTPPort:     smtpPort,
-					SMTPUsername: smtpUsername,
-					SMTPPassword: smtpPassword,
-					From:         from,
-					To:           to,
+					SMTPHost:               smtpHost,
+					SMTPPort:               smtpPort,
+					SMTPUsername:           smtpUsername,
+					SMTPPassword:           password,
+					SMTPAuthMethod:         smtpAuth,
+					SMTPToken:              token,
+					SMTPEncryption:         smtpEncryption,
+					SMTPInsecureSkipVerify: smtpInsecure,
+					SMTPRetries:            smtpRetries,
+					SMTPRetryDelay:         smtpRetryDelay,
+					SMTPTimeout:            smtpTimeout,
+					From:                   from,
+					FromName:               fromName,
+					ReplyTo:                replyTo,
+					To:                     strings.Join(to, ","),
+					Cc:                     strings.Join(cc, ","),
+					Bcc:                    strings.Join(bcc, ","),
+					SubjectPrefix:          subjectPrefix,
+					SubjectTemplate:        subjectTemplate,
+					HostnameInSubject:      hostnameInSubject,
 				}
 			}
 
 			auditConfig := &AuditConfig{
 				GrowThreshold:     growThreshold,
 				ShrinkThreshold:   shrinkThreshold,
+				PathThresholds:    pathThresholds,
+				MinSnapshots:      minSnapshots,
+				MaxAge:            maxAge,
+				MinInterval:       minInterval,
+				ChurnThreshold:    churnThreshold,
+				Output:            output,
+				Color:             color,
+				Baseline:          baseline,
+				GroupBy:           groupBy,
+				EmailOnSuccess:    emailOnSuccess,
+				IncludeCheck:      includeCheck,
+				EmailGroupBy:      emailGroupBy,
+				RepoName:          repoName,
+				Since:             since,
+				Until:             until,
+				Repo:              repo,
+				PasswordFile:      passwordFile,
+				Timezone:          timezone,
+				IncludePaths:      includePaths,
+				ExcludePaths:      excludePaths,
+				ResultFile:        resultFile,
+				Stdin:             stdin,
 				NotifyEmailConfig: emailConfig,
 			}
 
 			if err := ValidateAuditConfig(auditConfig); err != nil {
-				return fmt.Errorf("invalid audit config: %w", err)
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid audit config: %w", err))
 			}
 
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 
 			action := NewAuditAction(auditConfig)
-			return action.Execute(args, dryRun)
+			return action.Execute(logDirs, dryRun)
 		},
 	}
 
 	cmd.Flags().Float64Var(&growThreshold, "grow-threshold", 20.0, "Maximum allowed growth percentage between snapshots")
 	cmd.Flags().Float64Var(&shrinkThreshold, "shrink-threshold", 5.0, "Maximum allowed shrink percentage between snapshots")
+	cmd.Flags().StringArrayVar(&pathThresholds, "path-threshold", nil, "Per-path size thresholds overriding the global ones, e.g. \"/var/lib/pg:grow=60,shrink=10\" (repeatable)")
+	cmd.Flags().IntVar(&minSnapshots, "min-snapshots", 0, "Minimum number of snapshots expected per path (0 disables the check)")
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "Maximum age of the newest snapshot per path, e.g. 36h (0 disables the check)")
+	cmd.Flags().DurationVar(&minInterval, "min-interval", 0, "Minimum time between consecutive snapshots of a path, e.g. 1h (0 disables the check)")
+	cmd.Flags().Float64Var(&churnThreshold, "churn-threshold", 0, "Maximum percentage of files changed in the newest snapshot of a path (0 disables the check)")
+	cmd.Flags().StringVar(&output, "output", "text", "Console output format: text or json")
+	cmd.Flags().StringArrayVar(&includePaths, "include-path", nil, "Only audit snapshots with a path matching this glob pattern (repeatable)")
+	cmd.Flags().StringArrayVar(&excludePaths, "exclude-path", nil, "Do not audit snapshots with a path matching this glob pattern, takes precedence over --include-path (repeatable)")
+	cmd.Flags().StringVar(&timezone, "timezone", "", "Show snapshot times in this IANA timezone, e.g. UTC or America/New_York (default: as recorded)")
+	cmd.Flags().StringVar(&resultFile, "result-file", "", "Write the overall result and violations as JSON to this file, even if the email fails")
+	cmd.Flags().StringVar(&color, "color", "auto", "Color the console output: auto (when stdout is a terminal), always or never")
+	cmd.Flags().BoolVar(&includeCheck, "include-check", false, "Fail if the check command in the log directory failed or found errors")
+	cmd.Flags().StringVar(&groupBy, "group-by", "paths", "Group snapshots for the checks by paths or tags")
+	cmd.Flags().StringVar(&logDirGlob, "log-dir-glob", "", "Glob pattern of additional log directories to audit")
+	cmd.Flags().StringVar(&since, "since", "", "Only use logs written after this time, RFC3339 or relative like 24h")
+	cmd.Flags().StringVar(&until, "until", "", "Only use logs written before this time, RFC3339 or relative like 1h")
+	cmd.Flags().StringVar(&repo, "repo", "", "Repository to read snapshots from with restic snapshots --json if a log directory has no snapshots log")
+	cmd.Flags().StringVar(&passwordFile, "password-file", "", "Password file for --repo (default: restic's own environment, e.g. RESTIC_PASSWORD)")
+	cmd.Flags().BoolVar(&stdin, "stdin", false, "Read the output of restic snapshots --json from stdin")
+	cmd.Flags().StringVar(&baseline, "baseline", "", "Snapshot to compare sizes against: oldest, first-of-week or a snapshot ID (default: the previous snapshot)")
 
 	// Email flags (optional)
 	cmd.Flags().StringVar(&smtpHost, "smtp-host", "", "SMTP server hostname")
 	cmd.Flags().IntVar(&smtpPort, "smtp-port", 587, "SMTP server port")
 	cmd.Flags().StringVar(&smtpUsername, "smtp-username", "", "SMTP username")
-	cmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "SMTP password")
+	cmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "SMTP password (falls back to --smtp-password-file or "+shared.SMTPPasswordEnvVar+")")
+	cmd.Flags().StringVar(&smtpPasswordFile, "smtp-password-file", "", "File containing the SMTP password")
+	cmd.Flags().StringVar(&smtpAuth, "smtp-auth", "", "SMTP auth method: plain, login or xoauth2 (default: negotiated with the server)")
+	cmd.Flags().StringVar(&smtpToken, "smtp-token", "", "OAuth2 access token for --smtp-auth xoauth2")
+	cmd.Flags().StringVar(&smtpTokenFile, "smtp-token-file", "", "File containing the OAuth2 access token")
+	cmd.Flags().StringVar(&smtpEncryption, "smtp-encryption", "", "SMTP encryption: none, starttls or tls (default: tls on port 465, starttls otherwise)")
+	cmd.Flags().BoolVar(&smtpInsecure, "smtp-insecure", false, "Skip TLS certificate verification for the SMTP server")
+	cmd.Flags().IntVar(&smtpRetries, "smtp-retries", 0, "Number of retries for transient SMTP failures")
+	cmd.Flags().DurationVar(&smtpRetryDelay, "smtp-retry-delay", 2*time.Second, "Initial delay between SMTP retries")
+	cmd.Flags().DurationVar(&smtpTimeout, "smtp-timeout", 30*time.Second, "Timeout for each SMTP send attempt")
 	cmd.Flags().StringVar(&from, "from", "", "From email address")
-	cmd.Flags().StringVar(&to, "to", "", "To email address")
+	cmd.Flags().StringVar(&fromName, "from-name", "", "Display name for the From address, e.g. \"Backup Bot\"")
+	cmd.Flags().StringVar(&replyTo, "reply-to", "", "Reply-To address, e.g. a distribution list for replies")
+	cmd.Flags().StringSliceVar(&to, "to", nil, "To email address (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&cc, "cc", nil, "Cc email address (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&bcc, "bcc", nil, "Bcc email address (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&subjectPrefix, "subject-prefix", "", "Prefix for the email subject, e.g. [backup]")
+	cmd.Flags().StringVar(&subjectTemplate, "subject-template", "", "Go template for the email subject, e.g. '[AUDIT {{.Status}}] {{.Failed}} checks failed'")
+	cmd.Flags().BoolVar(&hostnameInSubject, "hostname-in-subject", false, "Append the machine hostname to the email subject")
+	cmd.Flags().StringVar(&emailGroupBy, "email-group-by", "check", "Group the audit email by check or path")
+	cmd.Flags().BoolVar(&emailOnSuccess, "email-on-success", false, "Also send an email when all checks pass")
 
 	return cmd
 }
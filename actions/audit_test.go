@@ -1,6 +1,12 @@
 package actions
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +44,60 @@ func TestValidateAuditConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative min snapshots",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				MinSnapshots:    -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid output format",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				Output:          "yaml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "malformed path threshold",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				PathThresholds:  []string{"/data:grow"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid color",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				Color:           "sometimes",
+			},
+			wantErr: true,
+		},
+		{
+			name: "churn threshold above 100",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				ChurnThreshold:  150,
+			},
+			wantErr: true,
+		},
+		{
+			name: "password file without repo",
+			config: &AuditConfig{
+				GrowThreshold:   20.0,
+				ShrinkThreshold: 5.0,
+				PasswordFile:    "/etc/restic/password",
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid config with email",
 			config: &AuditConfig{
@@ -145,6 +205,51 @@ func TestAuditAction_checkSizeChanges(t *testing.T) {
 	}
 }
 
+func TestParsePathThresholds(t *testing.T) {
+	defaults := SizeThresholds{Grow: 20, Shrink: 5}
+
+	thresholds, err := parsePathThresholds([]string{"/var/lib/pg:grow=60,shrink=10", "/photos:grow=5", `C:\Users:shrink=1`}, defaults)
+	if err != nil {
+		t.Fatalf("parsePathThresholds failed: %v", err)
+	}
+	expected := map[string]SizeThresholds{
+		"/var/lib/pg": {Grow: 60, Shrink: 10},
+		"/photos":     {Grow: 5, Shrink: 5},
+		`C:\Users`:    {Grow: 20, Shrink: 1},
+	}
+	for path, want := range expected {
+		if got := thresholds[path]; got != want {
+			t.Errorf("Expected %+v for %s, got %+v", want, path, got)
+		}
+	}
+
+	for _, override := range []string{"/data", "/data:", ":grow=10", "/data:grow", "/data:grow=abc", "/data:grow=-1", "/data:size=10"} {
+		if _, err := parsePathThresholds([]string{override}, defaults); err == nil || !strings.Contains(err.Error(), "invalid path-threshold") {
+			t.Errorf("Expected error for %q, got %v", override, err)
+		}
+	}
+}
+
+func TestAuditAction_checkSizeChanges_PathThresholds(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{Time: "2025-10-29T02:00:00Z", Paths: []string{"/var/lib/pg"}, Summary: restic.BackupSummary{TotalBytesProcessed: 1000}},
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/var/lib/pg"}, Summary: restic.BackupSummary{TotalBytesProcessed: 1500}},
+		{Time: "2025-10-29T02:00:00Z", Paths: []string{"/photos"}, Summary: restic.BackupSummary{TotalBytesProcessed: 1000}},
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/photos"}, Summary: restic.BackupSummary{TotalBytesProcessed: 1100}},
+	}
+
+	cfg := &AuditConfig{GrowThreshold: 20, ShrinkThreshold: 5, PathThresholds: []string{"/var/lib/pg:grow=60", "/photos:grow=5"}}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The database may grow by 50%, the photos not even by 10%
+	violations := NewAuditAction(cfg).checkSizeChanges(snapshots)
+	if len(violations) != 1 || violations[0].Path != "/photos" || violations[0].Details["threshold"] != "5.0" {
+		t.Errorf("Unexpected violations: %+v", violations)
+	}
+}
+
 func TestAuditAction_checkSizeChanges_LatestOnly(t *testing.T) {
 	action := &AuditAction{
 		config: &AuditConfig{
@@ -207,6 +312,57 @@ func TestAuditAction_checkSizeChanges_LatestOnly(t *testing.T) {
 	}
 }
 
+func TestAuditAction_checkSizeChanges_Baseline(t *testing.T) {
+	// 2025-01-06 is a Monday, each snapshot grows 5% over its predecessor
+	baseTime := time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC)
+	sizes := []int64{1000, 1050, 1102, 1157, 1215}
+	var snapshots []restic.Snapshot
+	for i, size := range sizes {
+		snapshots = append(snapshots, restic.Snapshot{
+			ID:      fmt.Sprintf("%02d00000000000000", i),
+			Time:    baseTime.Add(time.Duration(i) * 24 * time.Hour).Format(time.RFC3339Nano),
+			Paths:   []string{"/path1"},
+			Summary: restic.BackupSummary{TotalBytesProcessed: size},
+		})
+	}
+
+	tests := []struct {
+		baseline   string
+		wantID     string
+		wantChange string
+	}{
+		{baseline: "", wantID: "", wantChange: ""},
+		{baseline: "oldest", wantID: "0000000000000000", wantChange: "21.5"},
+		{baseline: "first-of-week", wantID: "0200000000000000", wantChange: "10.3"},
+		{baseline: "0100", wantID: "0100000000000000", wantChange: "15.7"},
+		{baseline: "unknown", wantID: "", wantChange: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseline, func(t *testing.T) {
+			action := &AuditAction{config: &AuditConfig{GrowThreshold: 10.0, ShrinkThreshold: 5.0, Baseline: tt.baseline}}
+			violations := action.checkSizeChanges(snapshots)
+
+			if tt.wantID == "" {
+				if len(violations) != 0 {
+					t.Errorf("Expected no violations, got %+v", violations)
+				}
+				return
+			}
+			if len(violations) != 1 {
+				t.Fatalf("Expected 1 violation, got %d", len(violations))
+			}
+			v := violations[0]
+			if v.Details["baseline_id"] != tt.wantID || v.Details["baseline_time"] == "" {
+				t.Errorf("Unexpected baseline details: %v", v.Details)
+			}
+			if v.Details["change_percent"] != tt.wantChange {
+				t.Errorf("Expected change percent %s, got %s", tt.wantChange, v.Details["change_percent"])
+			}
+		})
+	}
+}
+
 func TestAuditAction_checkSizeChanges_EdgeCases(t *testing.T) {
 	action := &AuditAction{
 		config: &AuditConfig{
@@ -255,4 +411,799 @@ func TestAuditAction_checkSizeChanges_EdgeCases(t *testing.T) {
 			t.Errorf("Expected no violations when previous size is 0, got %d", len(violations))
 		}
 	})
+
+	t.Run("negative size", func(t *testing.T) {
+		snapshots := []restic.Snapshot{
+			{
+				ID:    "1111111111111111",
+				Time:  time.Now().Format(time.RFC3339Nano),
+				Paths: []string{"/path1"},
+				Summary: restic.BackupSummary{
+					TotalBytesProcessed: -1000,
+				},
+			},
+			{
+				ID:    "2222222222222222",
+				Time:  time.Now().Add(time.Hour).Format(time.RFC3339Nano),
+				Paths: []string{"/path1"},
+				Summary: restic.BackupSummary{
+					TotalBytesProcessed: 1000,
+				},
+			},
+		}
+
+		violations := action.checkSizeChanges(snapshots)
+		if len(violations) != 1 || violations[0].CheckType != "data_anomaly" {
+			t.Fatalf("Expected a data_anomaly violation, got %+v", violations)
+		}
+		if violations[0].Details["snapshot_id"] != "1111111111111111" || violations[0].Details["error"] != "total_bytes_processed is negative: -1000" {
+			t.Errorf("Unexpected data_anomaly details: %v", violations[0].Details)
+		}
+		if _, ok := violations[0].Details["change_percent"]; ok {
+			t.Error("Expected no change percentage for an invalid summary")
+		}
+	})
+}
+
+func TestAuditAction_displayTime(t *testing.T) {
+	cfg := &AuditConfig{Timezone: "UTC"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	action := &AuditAction{config: cfg}
+	if got := action.displayTime("2025-10-30T23:34:19.35394226+01:00"); got != "2025-10-30T22:34:19Z" {
+		t.Errorf("displayTime() = %q, want UTC time", got)
+	}
+
+	action = &AuditAction{config: &AuditConfig{}}
+	if got := action.displayTime("2025-10-30T23:34:19+01:00"); got != "2025-10-30T23:34:19+01:00" {
+		t.Errorf("displayTime() = %q, want time as recorded", got)
+	}
+
+	if err := ValidateAuditConfig(&AuditConfig{Timezone: "Not/AZone"}); err == nil {
+		t.Error("Expected invalid timezone to be rejected")
+	}
+}
+
+func TestAuditAction_filterPaths(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{ID: "1", Paths: []string{"/home/alice"}},
+		{ID: "2", Paths: []string{"/home/bob"}},
+		{ID: "3", Paths: []string{"/var/cache"}},
+		{ID: "4", Paths: []string{"/etc", "/var/cache"}},
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{name: "no patterns", want: []string{"1", "2", "3", "4"}},
+		{name: "include", include: []string{"/home/*"}, want: []string{"1", "2"}},
+		{name: "exclude", exclude: []string{"/var/*"}, want: []string{"1", "2"}},
+		{name: "exclude wins over include", include: []string{"/home/*", "/etc"}, exclude: []string{"/home/bob", "/var/cache"}, want: []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &AuditAction{config: &AuditConfig{IncludePaths: tt.include, ExcludePaths: tt.exclude}}
+			var got []string
+			for _, snap := range action.filterPaths(snapshots) {
+				got = append(got, snap.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := ValidateAuditConfig(&AuditConfig{ExcludePaths: []string{"/data/["}}); err == nil {
+		t.Error("Expected malformed pattern to be rejected")
+	}
+}
+
+func TestAuditAction_checkMinimumSnapshots(t *testing.T) {
+	baseTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []restic.Snapshot{
+		{Time: baseTime.Format(time.RFC3339Nano), Paths: []string{"/path1"}},
+		{Time: baseTime.Add(time.Hour).Format(time.RFC3339Nano), Paths: []string{"/path1"}},
+		{Time: baseTime.Add(2 * time.Hour).Format(time.RFC3339Nano), Paths: []string{"/path1"}},
+		{Time: baseTime.Format(time.RFC3339Nano), Paths: []string{"/path2"}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{}}
+		if violations := action.checkMinimumSnapshots(snapshots); len(violations) != 0 {
+			t.Errorf("Expected no violations when disabled, got %d", len(violations))
+		}
+	})
+
+	t.Run("below minimum", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{MinSnapshots: 2}}
+		violations := action.checkMinimumSnapshots(snapshots)
+		if len(violations) != 1 {
+			t.Fatalf("Expected 1 violation, got %d", len(violations))
+		}
+		v := violations[0]
+		if v.CheckType != "min_snapshots" || v.Path != "/path2" {
+			t.Errorf("Unexpected violation: %+v", v)
+		}
+		if v.Details["snapshot_count"] != "1" || v.Details["expected_minimum"] != "2" {
+			t.Errorf("Unexpected details: %v", v.Details)
+		}
+	})
+}
+
+func TestAuditAction_checkStaleSnapshots(t *testing.T) {
+	now := time.Now()
+	snapshots := []restic.Snapshot{
+		{Time: now.Add(-72 * time.Hour).Format(time.RFC3339Nano), Paths: []string{"/fresh"}},
+		{Time: now.Add(-2 * time.Hour).Format(time.RFC3339Nano), Paths: []string{"/fresh"}},
+		{Time: now.Add(-48 * time.Hour).Format(time.RFC3339Nano), Paths: []string{"/stale"}},
+		{Time: "not-a-time", Paths: []string{"/stale"}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{}}
+		if violations := action.checkStaleSnapshots(snapshots); len(violations) != 0 {
+			t.Errorf("Expected no violations when disabled, got %d", len(violations))
+		}
+	})
+
+	t.Run("stale path", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{MaxAge: 24 * time.Hour}}
+		violations := action.checkStaleSnapshots(snapshots)
+		if len(violations) != 1 {
+			t.Fatalf("Expected 1 violation, got %d", len(violations))
+		}
+		v := violations[0]
+		if v.CheckType != "stale_snapshot" || v.Path != "/stale" {
+			t.Errorf("Unexpected violation: %+v", v)
+		}
+		if v.Details["newest_time"] != snapshots[2].Time {
+			t.Errorf("Expected newest_time %s, got %s", snapshots[2].Time, v.Details["newest_time"])
+		}
+	})
+}
+
+func TestAuditAction_checkDuplicateSnapshots(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/data"}},
+		{Time: "2025-10-29T02:00:00Z", Paths: []string{"/data"}},
+		{Time: "2025-10-30T02:05:00Z", Paths: []string{"/data"}},
+		{Time: "2025-10-30T02:01:00Z", Paths: []string{"/etc"}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{}}
+		if violations := action.checkDuplicateSnapshots(snapshots); len(violations) != 0 {
+			t.Errorf("Expected no violations when disabled, got %d", len(violations))
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{MinInterval: time.Hour}}
+		violations := action.checkDuplicateSnapshots(snapshots)
+		if len(violations) != 1 {
+			t.Fatalf("Expected 1 violation, got %d", len(violations))
+		}
+		v := violations[0]
+		if v.CheckType != "duplicate_snapshot" || v.Path != "/data" {
+			t.Errorf("Unexpected violation: %+v", v)
+		}
+		if v.Details["previous_time"] != "2025-10-30T02:00:00Z" || v.Details["current_time"] != "2025-10-30T02:05:00Z" || v.Details["delta"] != "5m0s" {
+			t.Errorf("Unexpected details: %+v", v.Details)
+		}
+	})
+}
+
+func TestAuditAction_checkChurn(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{Time: "2025-10-29T02:00:00Z", Paths: []string{"/data"}, Summary: restic.BackupSummary{FilesChanged: 900, TotalFilesProcessed: 1000}},
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/data"}, Summary: restic.BackupSummary{FilesChanged: 800, TotalFilesProcessed: 1000}},
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/etc"}, Summary: restic.BackupSummary{FilesChanged: 10, TotalFilesProcessed: 1000}},
+		{Time: "2025-10-30T02:00:00Z", Paths: []string{"/empty"}},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{}}
+		if violations := action.checkChurn(snapshots); len(violations) != 0 {
+			t.Errorf("Expected no violations when disabled, got %d", len(violations))
+		}
+	})
+
+	t.Run("high churn", func(t *testing.T) {
+		action := &AuditAction{config: &AuditConfig{ChurnThreshold: 50}}
+		violations := action.checkChurn(snapshots)
+		if len(violations) != 1 {
+			t.Fatalf("Expected 1 violation, got %d", len(violations))
+		}
+		v := violations[0]
+		if v.CheckType != "high_churn" || v.Path != "/data" {
+			t.Errorf("Unexpected violation: %+v", v)
+		}
+		if v.Details["churn_percent"] != "80.00" || v.Details["snapshot_time"] != "2025-10-30T02:00:00Z" {
+			t.Errorf("Unexpected details: %+v", v.Details)
+		}
+	})
+}
+
+func TestAuditAction_GroupByTags(t *testing.T) {
+	snapshots := []restic.Snapshot{
+		{Paths: []string{"/home"}, Tags: []string{"daily"}},
+		{Paths: []string{"/etc"}, Tags: []string{"daily"}},
+		{Paths: []string{"/srv"}, Tags: []string{"weekly"}},
+		{Paths: []string{"/srv"}},
+	}
+
+	cfg := &AuditConfig{MinSnapshots: 2, GroupBy: "tags"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	violations := (&AuditAction{config: cfg}).checkMinimumSnapshots(snapshots)
+	got := make(map[string]bool)
+	for _, v := range violations {
+		got[v.Path] = true
+	}
+	if len(violations) != 2 || !got["weekly"] || !got["(untagged)"] {
+		t.Errorf("Expected violations for weekly and (untagged), got %+v", violations)
+	}
+
+	if err := ValidateAuditConfig(&AuditConfig{GroupBy: "hosts"}); err == nil {
+		t.Error("Expected error for invalid group-by, got nil")
+	}
+}
+
+func TestAuditActionJSONOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	snapshotsOut := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`
+	if err := os.WriteFile(filepath.Join(tmpDir, "snapshots.out"), []byte(snapshotsOut), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, Output: "json"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capture stdout for validation
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewAuditAction(cfg).Execute([]string{tmpDir}, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+
+	var report AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if report.Passed {
+		t.Error("Expected report to be marked as failed")
+	}
+	if report.Thresholds.GrowThreshold != 20.0 || report.Thresholds.ShrinkThreshold != 5.0 {
+		t.Errorf("Unexpected thresholds: %+v", report.Thresholds)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].CheckType != "size_growth" || report.Checks[0].Path != "/data" {
+		t.Errorf("Unexpected checks: %+v", report.Checks)
+	}
+}
+
+func TestAuditActionStdin(t *testing.T) {
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, Output: "json", Stdin: true}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewAuditAction(cfg)
+	action.stdin = strings.NewReader(`[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := action.Execute(nil, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+	var report AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].CheckType != "size_growth" {
+		t.Errorf("Expected size growth of the piped snapshots, got %+v", report.Checks)
+	}
+}
+
+func TestAuditActionResultFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	snapshotsOut := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`
+	if err := os.WriteFile(filepath.Join(tmpDir, "snapshots.out"), []byte(snapshotsOut), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resultFile := filepath.Join(t.TempDir(), "result.json")
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, Output: "json", ResultFile: resultFile}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	err := NewAuditAction(cfg).Execute([]string{tmpDir}, false)
+	os.Stdout = oldStdout
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+
+	data, err := os.ReadFile(resultFile)
+	if err != nil {
+		t.Fatalf("Expected result file: %v", err)
+	}
+	var result ResultFile
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Expected JSON result file, got %q: %v", data, err)
+	}
+	if result.Command != "audit" || result.Success {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Violations) != 1 || result.Violations[0].CheckType != "size_growth" {
+		t.Errorf("Unexpected violations: %+v", result.Violations)
+	}
+}
+
+func TestAuditActionSnapshotsUnavailable(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(dir string)
+	}{
+		{
+			name:  "missing snapshots.out",
+			setup: func(dir string) {},
+		},
+		{
+			name: "failed snapshots command",
+			setup: func(dir string) {
+				os.WriteFile(filepath.Join(dir, "snapshots.exitcode"), []byte("1"), 0644)
+				os.WriteFile(filepath.Join(dir, "snapshots.out"), []byte(""), 0644)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			tt.setup(tmpDir)
+
+			action := NewAuditAction(&AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0})
+			check := action.checkSnapshotsAvailable(tmpDir)
+			if check == nil || check.CheckType != "snapshots_unavailable" {
+				t.Fatalf("Expected snapshots_unavailable violation, got %+v", check)
+			}
+
+			// The audit still runs and fails instead of aborting early
+			err := action.Execute([]string{tmpDir}, false)
+			if err == nil || err.Error() != "audit checks failed" {
+				t.Errorf("Expected audit checks failed error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAuditActionEmailOnSuccess(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	snapshotsOut := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1010}}]}]`
+	if err := os.WriteFile(filepath.Join(tmpDir, "snapshots.out"), []byte(snapshotsOut), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:     "smtp.example.com",
+		SMTPUsername: "user",
+		SMTPPassword: "pass",
+		From:         "from@example.com",
+		To:           "to@example.com",
+	}
+
+	for _, emailOnSuccess := range []bool{false, true} {
+		cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, EmailOnSuccess: emailOnSuccess, NotifyEmailConfig: emailConfig}
+		if err := ValidateAuditConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+
+		// Capture stdout for validation
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := NewAuditAction(cfg).Execute([]string{tmpDir}, true)
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+
+		if err != nil {
+			t.Fatalf("Expected audit to pass, got error: %v", err)
+		}
+		sent := strings.Contains(buf.String(), "Would send audit email with subject: Audit Report: PASSED")
+		if sent != emailOnSuccess {
+			t.Errorf("email-on-success=%v: unexpected output:\n%s", emailOnSuccess, buf.String())
+		}
+	}
+}
+
+func TestAuditActionJSONOutputDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	snapshotsOut := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`
+	if err := os.WriteFile(filepath.Join(tmpDir, "snapshots.out"), []byte(snapshotsOut), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &AuditConfig{
+		GrowThreshold:   20.0,
+		ShrinkThreshold: 5.0,
+		Output:          "json",
+		ResultFile:      filepath.Join(tmpDir, "result.json"),
+		NotifyEmailConfig: &shared.NotifyEmailConfig{
+			SMTPHost:     "smtp.example.com",
+			SMTPUsername: "user",
+			SMTPPassword: "pass",
+			From:         "from@example.com",
+			To:           "to@example.com",
+		},
+	}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capture stdout and stderr separately
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	err := NewAuditAction(cfg).Execute([]string{tmpDir}, true)
+
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	var stdout, stderr bytes.Buffer
+	stdout.ReadFrom(outR)
+	stderr.ReadFrom(errR)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+	var report AuditReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Expected only JSON on stdout, got %q: %v", stdout.String(), err)
+	}
+	if !strings.Contains(stderr.String(), "DRY RUN: Would send audit email") || !strings.Contains(stderr.String(), "DRY RUN: Would write result file") {
+		t.Errorf("Expected the dry-run preview on stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestAuditAction_checkRepositoryIntegrity(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitcode  string
+		out       string
+		age       time.Duration
+		wantCheck bool
+	}{
+		{name: "passing check", exitcode: "0", out: `{"message_type":"summary","num_errors":0}`, wantCheck: false},
+		{name: "check errors", exitcode: "0", out: `{"message_type":"summary","num_errors":2}`, wantCheck: true},
+		{name: "failed check", exitcode: "1", out: "", wantCheck: true},
+		{name: "missing check", wantCheck: true},
+		{name: "passing check outside the time range", exitcode: "0", out: `{"message_type":"summary","num_errors":0}`, age: 48 * time.Hour, wantCheck: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if tt.exitcode != "" {
+				os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte(tt.exitcode), 0644)
+				os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(tt.out), 0644)
+				mtime := time.Now().Add(-tt.age)
+				os.Chtimes(filepath.Join(tmpDir, "check.exitcode"), mtime, mtime)
+			}
+
+			timeRange := shared.TimeRange{Since: time.Now().Add(-24 * time.Hour)}
+			action := &AuditAction{config: &AuditConfig{IncludeCheck: true, TimeRange: timeRange}}
+			check := action.checkRepositoryIntegrity(tmpDir)
+			if (check != nil) != tt.wantCheck {
+				t.Fatalf("Expected violation %v, got %+v", tt.wantCheck, check)
+			}
+			if check != nil && check.CheckType != "repository_integrity" {
+				t.Errorf("Unexpected check type %s", check.CheckType)
+			}
+		})
+	}
+}
+
+func TestAuditAction_generateAuditEmailBodyByPath(t *testing.T) {
+	failedChecks := []AuditCheckResult{
+		{CheckType: "size_growth", Path: "/home", Message: "25.0% change exceeds 20.0% threshold"},
+		{CheckType: "stale_snapshot", Path: "/etc", Message: "newest snapshot is 48h0m0s old"},
+		{CheckType: "min_snapshots", Path: "/home", Message: "1 snapshots found, expected at least 3"},
+	}
+
+	action := &AuditAction{config: &AuditConfig{EmailGroupBy: "path"}}
+	body := action.generateAuditEmailBody(failedChecks)
+
+	expected := "=== /home ===\n" +
+		"Check: size_growth\nIssue: 25.0% change exceeds 20.0% threshold\n\n" +
+		"Check: min_snapshots\nIssue: 1 snapshots found, expected at least 3\n\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected /home section with both violations, got:\n%s", body)
+	}
+	if !strings.Contains(body, "=== /etc ===\nCheck: stale_snapshot\n") {
+		t.Errorf("Expected /etc section, got:\n%s", body)
+	}
+
+	// Sections are sorted, checks without a path come last under General
+	failedChecks = append(failedChecks, AuditCheckResult{CheckType: "snapshots_unavailable", Message: "snapshots.out is missing"})
+	for range 10 {
+		body = action.generateAuditEmailBody(failedChecks)
+		etc := strings.Index(body, "=== /etc ===")
+		home := strings.Index(body, "=== /home ===")
+		general := strings.Index(body, "=== General ===\nCheck: snapshots_unavailable\n")
+		if etc < 0 || home < etc || general < home {
+			t.Fatalf("Expected sections /etc, /home, General in order, got:\n%s", body)
+		}
+	}
+	if strings.Contains(body, "===  ===") {
+		t.Errorf("Expected no empty section header, got:\n%s", body)
+	}
+
+	// Check type grouping stays the default
+	action = &AuditAction{config: &AuditConfig{}}
+	body = action.generateAuditEmailBody(failedChecks)
+	if !strings.Contains(body, "=== SIZE_GROWTH ===\nPath: /home\n") {
+		t.Errorf("Expected check type sections by default, got:\n%s", body)
+	}
+
+	if err := ValidateAuditConfig(&AuditConfig{EmailGroupBy: "host"}); err == nil {
+		t.Error("Expected error for invalid email-group-by, got nil")
+	}
+}
+
+func TestAuditActionMultipleLogDirs(t *testing.T) {
+	firstDir := t.TempDir()
+	secondDir := t.TempDir()
+
+	// Each directory lists one snapshot, together they show a large growth
+	first := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"id":"aaaa","time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}}]}]`
+	second := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"id":"aaaa","time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"id":"bbbb","time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`
+	os.WriteFile(filepath.Join(firstDir, "snapshots.out"), []byte(first), 0644)
+	os.WriteFile(filepath.Join(secondDir, "snapshots.out"), []byte(second), 0644)
+
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, MinSnapshots: 3, Output: "json"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewAuditAction(cfg).Execute([]string{firstDir, secondDir}, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+
+	var report AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+
+	// The duplicate snapshot is only counted once
+	var types []string
+	for _, check := range report.Checks {
+		types = append(types, check.CheckType)
+		if check.CheckType == "min_snapshots" && check.Details["snapshot_count"] != "2" {
+			t.Errorf("Expected 2 merged snapshots, got %+v", check.Details)
+		}
+	}
+	if strings.Join(types, ",") != "size_growth,min_snapshots" {
+		t.Errorf("Unexpected checks: %v", types)
+	}
+}
+
+func TestAuditActionTimeRange(t *testing.T) {
+	freshDir := t.TempDir()
+	staleDir := t.TempDir()
+
+	// The snapshots are older than --since, only the logs are recent
+	snapshots := `[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"id":"aaaa","time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"id":"bbbb","time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]`
+	os.WriteFile(filepath.Join(freshDir, "snapshots.out"), []byte(snapshots), 0644)
+	os.WriteFile(filepath.Join(staleDir, "snapshots.out"), []byte(snapshots), 0644)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(staleDir, "snapshots.out"), old, old)
+
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, MinSnapshots: 3, Since: "24h", Output: "json"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewAuditAction(cfg).Execute([]string{staleDir, freshDir}, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+
+	var report AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+
+	// The stale log counts as missing, the checks still see all snapshots
+	var types []string
+	for _, check := range report.Checks {
+		types = append(types, check.CheckType)
+		if check.CheckType == "min_snapshots" && check.Details["snapshot_count"] != "2" {
+			t.Errorf("Expected 2 snapshots, got %+v", check.Details)
+		}
+	}
+	if strings.Join(types, ",") != "snapshots_unavailable,size_growth,min_snapshots" {
+		t.Errorf("Unexpected checks: %v", types)
+	}
+}
+
+func TestAuditActionLiveSnapshots(t *testing.T) {
+	// A fake restic binary records its arguments and prints two snapshots
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" > ` + argsFile + `
+echo '[{"group_key":{"hostname":"","paths":["/data"],"tags":null},"snapshots":[{"id":"aaaa","time":"2025-10-29T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":1000}},{"id":"bbbb","time":"2025-10-30T23:00:00Z","paths":["/data"],"summary":{"total_bytes_processed":2000}}]}]'
+`
+	if err := os.WriteFile(filepath.Join(binDir, "restic"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, Output: "json", Repo: "/srv/restic", PasswordFile: "/etc/restic/password"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	// The log directory has no snapshots.out, so the repository is queried
+	err := NewAuditAction(cfg).Execute([]string{t.TempDir()}, false)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err == nil {
+		t.Error("Expected error for failed audit, got nil")
+	}
+
+	var report AuditReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if len(report.Checks) != 1 || report.Checks[0].CheckType != "size_growth" {
+		t.Errorf("Unexpected checks: %+v", report.Checks)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "snapshots --json --group-by=paths --repo /srv/restic --password-file /etc/restic/password" {
+		t.Errorf("Unexpected restic arguments: %q", got)
+	}
+}
+
+func TestAuditActionLiveSnapshotsDryRun(t *testing.T) {
+	// restic must not be run in dry-run mode
+	t.Setenv("PATH", t.TempDir())
+
+	cfg := &AuditConfig{GrowThreshold: 20.0, ShrinkThreshold: 5.0, Repo: "/srv/restic"}
+	if err := ValidateAuditConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewAuditAction(cfg).Execute(nil, true)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+
+	if err != nil {
+		t.Errorf("Expected no error in dry-run mode, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "DRY RUN: Would run restic snapshots --json --group-by=paths --repo /srv/restic") {
+		t.Errorf("Expected dry-run command in output, got %q", buf.String())
+	}
+}
+
+func TestFormatAuditTable(t *testing.T) {
+	failedChecks := []AuditCheckResult{
+		{CheckType: "size_growth", Path: "/data", Message: "size grew by 100.0%"},
+		{CheckType: "snapshots_unavailable", Message: "snapshots.out is missing"},
+	}
+
+	expected := "Audit FAILED: 2 checks failed\n" +
+		"PATH   CHECK                  DETAIL\n" +
+		"/data  size_growth            size grew by 100.0%\n" +
+		"-      snapshots_unavailable  snapshots.out is missing\n"
+	if got := formatAuditTable(failedChecks, false); got != expected {
+		t.Errorf("Expected table:\n%s\ngot:\n%s", expected, got)
+	}
+
+	colored := formatAuditTable(failedChecks, true)
+	if !strings.Contains(colored, "\033[31msize_growth          \033[0m") {
+		t.Errorf("Expected colored check type, got %q", colored)
+	}
+	if strings.Contains(formatAuditTable(failedChecks, false), "\033[") {
+		t.Error("Expected no escape codes without color")
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	// /dev/null is a character device but not a terminal
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skipf("%s not available: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+	if isTerminal(devNull) {
+		t.Errorf("Expected %s not to be a terminal", os.DevNull)
+	}
 }
@@ -0,0 +1,130 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"restic-kit/shared"
+)
+
+// CheckDiskConfig holds configuration for the free disk space check
+type CheckDiskConfig struct {
+	MinFree        string
+	MinFreeBytes   int64
+	MinFreePercent float64
+}
+
+// ValidateCheckDiskConfig validates the check-disk config and parses the
+// threshold, which is either a size like 10G or a percentage like 15%
+func ValidateCheckDiskConfig(cfg *CheckDiskConfig) error {
+	if cfg.MinFree == "" {
+		return fmt.Errorf("min-free-space is required")
+	}
+	if strings.HasSuffix(cfg.MinFree, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(cfg.MinFree, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("invalid min-free-space %q: percentage must be between 0%% and 100%%", cfg.MinFree)
+		}
+		cfg.MinFreePercent = percent
+		return nil
+	}
+	bytes, err := parseSize(cfg.MinFree)
+	if err != nil {
+		return fmt.Errorf("invalid min-free-space %q: %w", cfg.MinFree, err)
+	}
+	cfg.MinFreeBytes = bytes
+	return nil
+}
+
+// parseSize parses a size in bytes with an optional K, M, G or T suffix
+// (base 1024, optionally followed by B or iB)
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	if n := len(s); n > 0 {
+		if i := strings.IndexByte("KMGT", s[n-1]); i >= 0 {
+			multiplier = int64(1) << (10 * (i + 1))
+			s = strings.TrimSpace(s[:n-1])
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("expected a size like 500M or 10G")
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+type CheckDiskAction struct {
+	*BaseAction
+	config *CheckDiskConfig
+}
+
+func NewCheckDiskAction(cfg *CheckDiskConfig) *CheckDiskAction {
+	return &CheckDiskAction{
+		BaseAction: NewBaseAction("check-disk"),
+		config:     cfg,
+	}
+}
+
+func (a *CheckDiskAction) Execute(args []string) error {
+	if len(args) != 1 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("check-disk requires exactly one argument: the path to check"))
+	}
+
+	path := args[0]
+	free, total, err := diskSpace(path)
+	if err != nil {
+		return fmt.Errorf("failed to read free space of %s: %w", path, err)
+	}
+
+	required := a.config.MinFreeBytes
+	if a.config.MinFreePercent > 0 {
+		required = int64(float64(total) * a.config.MinFreePercent / 100)
+	}
+
+	if free < required {
+		return fmt.Errorf("insufficient free space on %s: %s free, %s required",
+			path, shared.FormatBytes(free), shared.FormatBytes(required))
+	}
+
+	shared.Infof("Free space on %s: %s of %s, %s required\n",
+		path, shared.FormatBytes(free), shared.FormatBytes(total), shared.FormatBytes(required))
+	return nil
+}
+
+func NewCheckDiskCmd() *cobra.Command {
+	var minFree string
+
+	cmd := &cobra.Command{
+		Use:   "check-disk [path]",
+		Short: "Check that enough disk space is free",
+		Long: `Check the free space of the file system containing the given path and exit non-zero if it is below
+--min-free-space, so a hook chain can abort before the backup instead of failing halfway through.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checkDiskConfig := &CheckDiskConfig{
+				MinFree: minFree,
+			}
+
+			if err := ValidateCheckDiskConfig(checkDiskConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid check-disk config: %w", err))
+			}
+
+			// Too little free space is not a usage error
+			cmd.SilenceUsage = true
+
+			action := NewCheckDiskAction(checkDiskConfig)
+			return action.Execute(args)
+		},
+	}
+
+	cmd.Flags().StringVar(&minFree, "min-free-space", "", "Minimum free space, as a size like 10G or a percentage like 15% (required)")
+	cmd.MarkFlagRequired("min-free-space")
+
+	return cmd
+}
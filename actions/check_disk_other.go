@@ -0,0 +1,10 @@
+//go:build !unix
+
+package actions
+
+import "fmt"
+
+// diskSpace is not implemented on this platform
+func diskSpace(path string) (free, total int64, err error) {
+	return 0, 0, fmt.Errorf("checking free space is not supported on this platform")
+}
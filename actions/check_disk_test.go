@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCheckDiskConfig(t *testing.T) {
+	tests := []struct {
+		minFree     string
+		wantBytes   int64
+		wantPercent float64
+		wantErr     bool
+	}{
+		{minFree: "1048576", wantBytes: 1048576},
+		{minFree: "500M", wantBytes: 500 * 1024 * 1024},
+		{minFree: "10GB", wantBytes: 10 * 1024 * 1024 * 1024},
+		{minFree: "1.5GiB", wantBytes: 1536 * 1024 * 1024},
+		{minFree: "2t", wantBytes: 2 * 1024 * 1024 * 1024 * 1024},
+		{minFree: "15%", wantPercent: 15},
+		{minFree: "", wantErr: true},
+		{minFree: "ten", wantErr: true},
+		{minFree: "-5G", wantErr: true},
+		{minFree: "150%", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.minFree, func(t *testing.T) {
+			cfg := &CheckDiskConfig{MinFree: tt.minFree}
+			err := ValidateCheckDiskConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateCheckDiskConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if cfg.MinFreeBytes != tt.wantBytes || cfg.MinFreePercent != tt.wantPercent {
+				t.Errorf("Got %d bytes / %v%%, want %d bytes / %v%%", cfg.MinFreeBytes, cfg.MinFreePercent, tt.wantBytes, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestCheckDiskAction(t *testing.T) {
+	dir := t.TempDir()
+	if _, _, err := diskSpace(dir); err != nil {
+		t.Skipf("Free space not available: %v", err)
+	}
+
+	if err := NewCheckDiskAction(&CheckDiskConfig{MinFreeBytes: 1}).Execute([]string{dir}); err != nil {
+		t.Errorf("Expected enough free space, got %v", err)
+	}
+
+	// No file system has an exabyte free
+	err := NewCheckDiskAction(&CheckDiskConfig{MinFreeBytes: 1 << 60}).Execute([]string{dir})
+	if err == nil || !strings.Contains(err.Error(), "insufficient free space") || !strings.Contains(err.Error(), "1.0 EB required") {
+		t.Errorf("Expected insufficient free space error, got %v", err)
+	}
+
+	if err := NewCheckDiskAction(&CheckDiskConfig{MinFreeBytes: 1}).Execute([]string{dir + "/missing"}); err == nil {
+		t.Error("Expected error for a missing path")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package actions
+
+import "syscall"
+
+// diskSpace returns the bytes available to unprivileged users and the total
+// size of the file system containing path
+func diskSpace(path string) (free, total int64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), int64(stat.Blocks) * int64(stat.Bsize), nil
+}
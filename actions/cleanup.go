@@ -1,20 +1,49 @@
 package actions
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
+	"restic-kit/shared"
 )
 
 // CleanupConfig holds configuration for cleanup operations
 type CleanupConfig struct {
-	// No configuration needed for cleanup action
+	ArchiveDir string
+	MaxLogAge  time.Duration
 }
 
 // ValidateCleanupConfig validates the cleanup config
 func ValidateCleanupConfig(cfg *CleanupConfig) error {
-	// No validation needed for cleanup config
+	if cfg.MaxLogAge < 0 {
+		return fmt.Errorf("max-log-age must be non-negative")
+	}
+	if cfg.ArchiveDir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(cfg.ArchiveDir)
+	if err != nil {
+		return fmt.Errorf("archive directory %s is not accessible: %w", cfg.ArchiveDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("archive path %s is not a directory", cfg.ArchiveDir)
+	}
+
+	// Make sure the archive can be written before any logs are touched
+	probe, err := os.CreateTemp(cfg.ArchiveDir, ".restic-kit-probe-*")
+	if err != nil {
+		return fmt.Errorf("archive directory %s is not writable: %w", cfg.ArchiveDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
 	return nil
 }
 
@@ -30,9 +59,9 @@ func NewCleanupAction(cfg *CleanupConfig) *CleanupAction {
 	}
 }
 
-func (a *CleanupAction) Execute(args []string) error {
+func (a *CleanupAction) Execute(args []string, dryRun bool) error {
 	if len(args) != 1 {
-		return fmt.Errorf("cleanup requires exactly one argument: the path to the log directory")
+		return WithExitCode(ExitConfigError, fmt.Errorf("cleanup requires exactly one argument: the path to the log directory"))
 	}
 
 	logDir := args[0]
@@ -42,43 +71,211 @@ func (a *CleanupAction) Execute(args []string) error {
 		return fmt.Errorf("log directory does not exist: %s", logDir)
 	}
 
+	// Remove old log directories that were kept for debugging
+	if a.config.MaxLogAge > 0 {
+		if err := removeOldLogDirs(logDir, a.config.MaxLogAge, dryRun); err != nil {
+			return fmt.Errorf("failed to remove old log directories: %w", err)
+		}
+	}
+
 	// Analyze backup results to determine overall success
 	_, overallSuccess, err := analyzeBackupResults(logDir)
 	if err != nil {
 		return fmt.Errorf("failed to analyze backup results: %w", err)
 	}
 
+	if dryRun {
+		if overallSuccess {
+			if a.config.ArchiveDir != "" {
+				fmt.Printf("DRY RUN: would archive %s to %s\n", logDir, a.config.ArchiveDir)
+			}
+			fmt.Printf("DRY RUN: would remove %s\n", logDir)
+		} else {
+			fmt.Printf("DRY RUN: would keep %s (failures detected)\n", logDir)
+		}
+		return nil
+	}
+
 	if overallSuccess {
-		// All backups successful, remove the directory
+		// All backups successful, archive the logs if requested and remove the directory
+		if a.config.ArchiveDir != "" {
+			archivePath, err := archiveLogDir(logDir, a.config.ArchiveDir)
+			if err != nil {
+				return fmt.Errorf("failed to archive log directory %s: %w", logDir, err)
+			}
+			shared.Infof("Archived log directory %s to %s\n", logDir, archivePath)
+		}
 		if err := os.RemoveAll(logDir); err != nil {
 			return fmt.Errorf("failed to remove log directory %s: %w", logDir, err)
 		}
-		fmt.Printf("Cleanup completed: removed log directory %s\n", logDir)
+		shared.Infof("Cleanup completed: removed log directory %s\n", logDir)
 	} else {
 		// Some backups failed, keep directory for debugging
-		fmt.Printf("Cleanup skipped: keeping log directory %s for debugging (backup failures detected)\n", logDir)
+		shared.Infof("Cleanup skipped: keeping log directory %s for debugging (backup failures detected)\n", logDir)
 	}
 
 	return nil
 }
 
+// removeOldLogDirs removes sibling directories of logDir that are older than
+// maxAge. Only directories containing *.exitcode(.gz) files are considered, so
+// unrelated data next to the log directories is never touched.
+func removeOldLogDirs(logDir string, maxAge time.Duration, dryRun bool) error {
+	absLogDir, err := filepath.Abs(logDir)
+	if err != nil {
+		return err
+	}
+	parent := filepath.Dir(absLogDir)
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		dir := filepath.Join(parent, entry.Name())
+		if !entry.IsDir() || dir == absLogDir {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		exitcodeFiles, err := filepath.Glob(filepath.Join(dir, "*.exitcode"))
+		if err != nil {
+			continue
+		}
+		compressedFiles, err := filepath.Glob(filepath.Join(dir, "*.exitcode.gz"))
+		if err != nil || len(exitcodeFiles)+len(compressedFiles) == 0 {
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("DRY RUN: would remove %s\n", dir)
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove log directory %s: %w", dir, err)
+		}
+		shared.Infof("Removed old log directory %s\n", dir)
+	}
+
+	return nil
+}
+
+// maxArchiveSuffix bounds the numbered names tried for archives created
+// within the same second
+const maxArchiveSuffix = 100
+
+// archiveLogDir writes the contents of the log directory to a
+// logs-<timestamp>.tar.gz file in the archive directory
+func archiveLogDir(logDir, archiveDir string) (string, error) {
+	file, archivePath, err := createArchiveFile(archiveDir, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	gzw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gzw)
+
+	err = filepath.Walk(logDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(logDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gzw.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return "", err
+	}
+	return archivePath, nil
+}
+
+// createArchiveFile creates a new logs-<timestamp>.tar.gz file. If another
+// cleanup created one in the same second, a numeric suffix like
+// logs-<timestamp>-1.tar.gz is added instead of overwriting it.
+func createArchiveFile(archiveDir string, now time.Time) (*os.File, string, error) {
+	base := "logs-" + now.Format("20060102-150405")
+	for i := range maxArchiveSuffix {
+		name := base + ".tar.gz"
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d.tar.gz", base, i)
+		}
+		archivePath := filepath.Join(archiveDir, name)
+		file, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return file, archivePath, err
+	}
+	return nil, "", fmt.Errorf("too many archives named %s-*.tar.gz in %s", base, archiveDir)
+}
+
 func NewCleanupCmd() *cobra.Command {
+	var archiveDir string
+	var maxLogAge time.Duration
+
 	cmd := &cobra.Command{
 		Use:   "cleanup [log-directory]",
 		Short: "Clean up log directory after backup operations",
-		Long:  `Remove the log directory if all backup operations were successful. Keep it for debugging if any operations failed.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Remove the log directory if all backup operations were successful. Keep it for debugging if any operations failed.
+With --archive, the logs are first stored as logs-<timestamp>.tar.gz in the given directory.
+With --max-log-age, sibling log directories older than the given duration are removed as well.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cleanupConfig := &CleanupConfig{}
+			cleanupConfig := &CleanupConfig{
+				ArchiveDir: archiveDir,
+				MaxLogAge:  maxLogAge,
+			}
 
 			if err := ValidateCleanupConfig(cleanupConfig); err != nil {
-				return fmt.Errorf("invalid cleanup config: %w", err)
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid cleanup config: %w", err))
 			}
 
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
 			action := NewCleanupAction(cleanupConfig)
-			return action.Execute(args)
+			return action.Execute(args, dryRun)
 		},
 	}
 
+	cmd.Flags().StringVar(&archiveDir, "archive", "", "Directory to store a tar.gz archive of the logs in before removing them")
+	cmd.Flags().DurationVar(&maxLogAge, "max-log-age", 0, "Remove sibling log directories older than this, e.g. 720h (0 disables)")
+
 	return cmd
 }
@@ -1,10 +1,15 @@
 package actions
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCleanupAction(t *testing.T) {
@@ -33,7 +38,7 @@ func TestCleanupAction(t *testing.T) {
 		createOutFile(t, logDir, "check.out", `{"message_type":"status","num_errors":0}`)
 
 		action := NewCleanupAction(&CleanupConfig{})
-		err := action.Execute([]string{logDir})
+		err := action.Execute([]string{logDir}, false)
 
 		if err != nil {
 			t.Errorf("Expected successful cleanup, got error: %v", err)
@@ -63,7 +68,7 @@ func TestCleanupAction(t *testing.T) {
 		createOutFile(t, logDir, "check.out", `{"message_type":"status","num_errors":0}`)
 
 		action := NewCleanupAction(&CleanupConfig{})
-		err := action.Execute([]string{logDir})
+		err := action.Execute([]string{logDir}, false)
 
 		if err != nil {
 			t.Errorf("Expected cleanup to complete (even with failures), got error: %v", err)
@@ -75,28 +80,191 @@ func TestCleanupAction(t *testing.T) {
 		}
 	})
 
+	// Test archiving the logs before removal
+	t.Run("ArchiveCleanup", func(t *testing.T) {
+		logDir := filepath.Join(tempDir, "archive-logs")
+		archiveDir := filepath.Join(tempDir, "archive")
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			t.Fatalf("Failed to create log dir: %v", err)
+		}
+		if err := os.MkdirAll(archiveDir, 0755); err != nil {
+			t.Fatalf("Failed to create archive dir: %v", err)
+		}
+
+		createExitCodeFile(t, logDir, "backup.etc.exitcode", 0)
+		createOutFile(t, logDir, "backup.etc.out", `{"message_type":"summary","files_new":0,"files_changed":0,"files_unmodified":10}`)
+
+		cfg := &CleanupConfig{ArchiveDir: archiveDir}
+		if err := ValidateCleanupConfig(cfg); err != nil {
+			t.Fatalf("Expected valid config, got error: %v", err)
+		}
+
+		action := NewCleanupAction(cfg)
+		if err := action.Execute([]string{logDir}, false); err != nil {
+			t.Fatalf("Expected successful cleanup, got error: %v", err)
+		}
+
+		if _, err := os.Stat(logDir); !os.IsNotExist(err) {
+			t.Errorf("Expected log directory to be removed, but it still exists")
+		}
+
+		archives, _ := filepath.Glob(filepath.Join(archiveDir, "logs-*.tar.gz"))
+		if len(archives) != 1 {
+			t.Fatalf("Expected one archive, got %v", archives)
+		}
+
+		f, err := os.Open(archives[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gzr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gzr)
+
+		var names []string
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			names = append(names, header.Name)
+		}
+		sort.Strings(names)
+		if len(names) != 2 || names[0] != "backup.etc.exitcode" || names[1] != "backup.etc.out" {
+			t.Errorf("Unexpected archive contents: %v", names)
+		}
+	})
+
+	// Test that a missing archive directory is rejected
+	t.Run("InvalidArchiveDir", func(t *testing.T) {
+		cfg := &CleanupConfig{ArchiveDir: filepath.Join(tempDir, "does-not-exist")}
+		if err := ValidateCleanupConfig(cfg); err == nil {
+			t.Error("Expected error for missing archive directory, got nil")
+		}
+	})
+
+	// Test removing old sibling log directories
+	t.Run("MaxLogAge", func(t *testing.T) {
+		parent := filepath.Join(tempDir, "aged")
+		logDir := filepath.Join(parent, "current")
+		oldLogDir := filepath.Join(parent, "old")
+		recentLogDir := filepath.Join(parent, "recent")
+		unrelatedDir := filepath.Join(parent, "unrelated")
+		for _, dir := range []string{logDir, oldLogDir, recentLogDir, unrelatedDir} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("Failed to create dir: %v", err)
+			}
+		}
+
+		createExitCodeFile(t, logDir, "backup.etc.exitcode", 1)
+		createOutFile(t, logDir, "backup.etc.out", `{"message_type":"summary"}`)
+		createExitCodeFile(t, oldLogDir, "backup.etc.exitcode", 1)
+		createExitCodeFile(t, recentLogDir, "backup.etc.exitcode", 1)
+		createOutFile(t, unrelatedDir, "data.txt", "keep me")
+
+		old := time.Now().Add(-48 * time.Hour)
+		for _, dir := range []string{logDir, oldLogDir, unrelatedDir} {
+			if err := os.Chtimes(dir, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		action := NewCleanupAction(&CleanupConfig{MaxLogAge: 24 * time.Hour})
+		if err := action.Execute([]string{logDir}, false); err != nil {
+			t.Fatalf("Expected cleanup to complete, got error: %v", err)
+		}
+
+		if _, err := os.Stat(oldLogDir); !os.IsNotExist(err) {
+			t.Error("Expected old log directory to be removed")
+		}
+		for _, dir := range []string{logDir, recentLogDir, unrelatedDir} {
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("Expected %s to be kept: %v", dir, err)
+			}
+		}
+	})
+
+	// Test dry run leaves the filesystem untouched
+	t.Run("DryRun", func(t *testing.T) {
+		parent := filepath.Join(tempDir, "dryrun")
+		logDir := filepath.Join(parent, "current")
+		oldLogDir := filepath.Join(parent, "old")
+		archiveDir := filepath.Join(tempDir, "dryrun-archive")
+		for _, dir := range []string{logDir, oldLogDir, archiveDir} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("Failed to create dir: %v", err)
+			}
+		}
+
+		createExitCodeFile(t, logDir, "backup.etc.exitcode", 0)
+		createOutFile(t, logDir, "backup.etc.out", `{"message_type":"summary"}`)
+		createExitCodeFile(t, oldLogDir, "backup.etc.exitcode", 1)
+
+		old := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(oldLogDir, old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		action := NewCleanupAction(&CleanupConfig{ArchiveDir: archiveDir, MaxLogAge: 24 * time.Hour})
+		if err := action.Execute([]string{logDir}, true); err != nil {
+			t.Fatalf("Expected dry run to succeed, got error: %v", err)
+		}
+
+		for _, dir := range []string{logDir, oldLogDir} {
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("Expected %s to be kept in dry run: %v", dir, err)
+			}
+		}
+		if entries, _ := os.ReadDir(archiveDir); len(entries) != 0 {
+			t.Errorf("Expected no archive in dry run, got %d entries", len(entries))
+		}
+	})
+
 	// Test invalid arguments
 	t.Run("InvalidArgs", func(t *testing.T) {
 		action := NewCleanupAction(&CleanupConfig{})
 
 		// No arguments
-		err := action.Execute([]string{})
+		err := action.Execute([]string{}, false)
 		if err == nil {
 			t.Error("Expected error for no arguments, got nil")
 		}
 
 		// Too many arguments
-		err = action.Execute([]string{"dir1", "dir2"})
+		err = action.Execute([]string{"dir1", "dir2"}, false)
 		if err == nil {
 			t.Error("Expected error for too many arguments, got nil")
 		}
 
 		// Non-existent directory
-		err = action.Execute([]string{"/non/existent/directory"})
+		err = action.Execute([]string{"/non/existent/directory"}, false)
 		if err == nil {
 			t.Error("Expected error for non-existent directory, got nil")
 		}
 	})
+
+	t.Run("archives created in the same second", func(t *testing.T) {
+		archiveDir := t.TempDir()
+		now := time.Date(2025, 10, 30, 23, 0, 0, 0, time.UTC)
+
+		var names []string
+		for range 3 {
+			file, path, err := createArchiveFile(archiveDir, now)
+			if err != nil {
+				t.Fatalf("Expected a new archive file, got error: %v", err)
+			}
+			file.Close()
+			names = append(names, filepath.Base(path))
+		}
+
+		expected := "logs-20251030-230000.tar.gz,logs-20251030-230000-1.tar.gz,logs-20251030-230000-2.tar.gz"
+		if strings.Join(names, ",") != expected {
+			t.Errorf("Expected archives %s, got %v", expected, names)
+		}
+	})
 }
 
 func createExitCodeFile(t *testing.T, dir, filename string, code int) {
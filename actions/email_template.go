@@ -0,0 +1,74 @@
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+// EmailTemplateData is the data passed to a --body-template template. The
+// actions implement restic.ActionResult, their fields depend on the concrete
+// type returned by the actionType function, e.g. .Result.DataAdded for
+// *restic.BackupActionResult.
+type EmailTemplateData struct {
+	Actions []restic.ActionResult
+	Overall OverallStatus
+}
+
+// OverallStatus summarizes the reported actions for email templates
+type OverallStatus struct {
+	Success  bool
+	Status   string
+	RepoName string
+	Total    int
+	Failed   int
+}
+
+// bodyTemplateFuncs are the helper functions available in body templates
+var bodyTemplateFuncs = template.FuncMap{
+	"formatBytes": shared.FormatBytes,
+	"actionType":  actionTypeOf,
+	"join":        strings.Join,
+}
+
+// parseBodyTemplate reads and parses the body template file
+func parseBodyTemplate(path string) (*template.Template, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body template: %w", err)
+	}
+	tmpl, err := template.New(path).Funcs(bodyTemplateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse body template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderBodyTemplate executes the body template for the given actions
+func renderBodyTemplate(tmpl *template.Template, actions []restic.ActionResult, success bool, repoName string) (string, error) {
+	data := EmailTemplateData{
+		Actions: actions,
+		Overall: OverallStatus{
+			Success:  success,
+			Status:   map[bool]string{true: "SUCCESS", false: "FAILURE"}[success],
+			RepoName: repoName,
+			Total:    len(actions),
+		},
+	}
+	for _, action := range actions {
+		if !action.IsSuccess() {
+			data.Overall.Failed++
+		}
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to render body template: %w", err)
+	}
+	return body.String(), nil
+}
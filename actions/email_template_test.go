@@ -0,0 +1,93 @@
+package actions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+func TestRenderBodyTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.tmpl")
+	content := `{{.Overall.Status}} {{.Overall.Failed}}/{{.Overall.Total}}
+{{range .Actions}}{{actionType .}} {{.GetActionName}}{{if eq (actionType .) "backup"}} {{formatBytes .Result.DataAdded}}{{end}}
+{{end}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := parseBodyTemplate(path)
+	if err != nil {
+		t.Fatalf("parseBodyTemplate failed: %v", err)
+	}
+
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{Name: "home", Success: true, Result: &restic.BackupResult{DataAdded: 2048}},
+		&restic.CheckActionResult{Name: "check", Success: false, Result: &restic.CheckResult{}},
+	}
+	body, err := renderBodyTemplate(tmpl, actions, false, "nas")
+	if err != nil {
+		t.Fatalf("renderBodyTemplate failed: %v", err)
+	}
+
+	expected := "FAILURE 1/2\nbackup home 2.0 KB\ncheck check\n"
+	if body != expected {
+		t.Errorf("Expected body %q, got %q", expected, body)
+	}
+}
+
+func TestParseBodyTemplateInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.tmpl")
+	if err := os.WriteFile(path, []byte("{{range .Actions}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseBodyTemplate(path); err == nil || !strings.Contains(err.Error(), "failed to parse body template") {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+	if _, err := parseBodyTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Expected error for missing template file")
+	}
+}
+
+func TestNotifyEmailActionBodyTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	templatePath := filepath.Join(t.TempDir(), "body.tmpl")
+	os.WriteFile(templatePath, []byte("Custom report: {{.Overall.Status}}"), 0644)
+
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:     "localhost",
+		SMTPUsername: "test",
+		SMTPPassword: "test",
+		From:         "from@example.com",
+		To:           "to@example.com",
+		BodyTemplate: templatePath,
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewNotifyEmailAction(emailConfig).Execute([]string{tmpDir}, true)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Errorf("Expected no error in dry-run mode, got %v", err)
+	}
+	if !strings.Contains(output, "Custom report: SUCCESS") || strings.Contains(output, "Overall Status:") {
+		t.Errorf("Expected templated body, got:\n%s", output)
+	}
+}
@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes of restic-kit, so that wrappers such as cron jobs can tell
+// the failure categories apart. Commands wrapped by run exit with their own
+// exit code instead.
+const (
+	// ExitFailure is any other failure, e.g. a failed backup reported with
+	// --exit-on-failure or too little free space
+	ExitFailure = 1
+	// ExitConfigError is an invalid flag, argument or config file
+	ExitConfigError = 2
+	// ExitNotifyError is a notification that could not be delivered
+	ExitNotifyError = 3
+	// ExitAuditFailed is an audit that found violations
+	ExitAuditFailed = 4
+	// ExitParseError is a log or restic output that could not be read or
+	// parsed
+	ExitParseError = 5
+)
+
+// ExitCodeError carries the exit code restic-kit exits with. It is returned
+// when a wrapped command exits with a non-zero status, so the caller can
+// exit with the same code, and wraps the errors of the categories above.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("command exited with code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
+// WithExitCode attaches an exit code to an error, nil stays nil
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitCodeError{Code: code, Err: err}
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	var exitErr *ExitCodeError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
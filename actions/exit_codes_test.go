@@ -0,0 +1,52 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "plain error", err: errors.New("boom"), want: ExitFailure},
+		{name: "config error", err: WithExitCode(ExitConfigError, errors.New("bad flag")), want: ExitConfigError},
+		{name: "wrapped notify error", err: fmt.Errorf("notify-email: %w", WithExitCode(ExitNotifyError, errors.New("smtp down"))), want: ExitNotifyError},
+		{name: "joined errors", err: errors.Join(WithExitCode(ExitNotifyError, errors.New("smtp down")), errors.New("disk full")), want: ExitNotifyError},
+		{name: "wrapped command", err: &ExitCodeError{Code: 42}, want: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if WithExitCode(ExitConfigError, nil) != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+	err := WithExitCode(ExitParseError, errors.New("bad json"))
+	if err.Error() != "bad json" {
+		t.Errorf("Expected the message of the wrapped error, got %q", err.Error())
+	}
+}
+
+func TestExitCodeCategories(t *testing.T) {
+	if err := NewAuditAction(&AuditConfig{}).Execute(nil, false); ExitCode(err) != ExitConfigError {
+		t.Errorf("Expected config error without log directories, got %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte("{not json"), 0644)
+	if _, _, err := analyzeBackupResults(tmpDir); ExitCode(err) != ExitParseError {
+		t.Errorf("Expected parse error for malformed output, got %v", err)
+	}
+}
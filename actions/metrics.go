@@ -0,0 +1,249 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+// MetricsConfig holds configuration for the metrics action
+type MetricsConfig struct {
+	Output string
+}
+
+// ValidateMetricsConfig validates the metrics config
+func ValidateMetricsConfig(cfg *MetricsConfig) error {
+	if cfg.Output != "" && !strings.HasSuffix(cfg.Output, ".prom") {
+		return fmt.Errorf("output file must have a .prom extension")
+	}
+	return nil
+}
+
+// metricFamily is a single Prometheus metric with its samples
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+type MetricsAction struct {
+	*BaseAction
+	config *MetricsConfig
+}
+
+func NewMetricsAction(cfg *MetricsConfig) *MetricsAction {
+	return &MetricsAction{
+		BaseAction: NewBaseAction("metrics"),
+		config:     cfg,
+	}
+}
+
+func (a *MetricsAction) Execute(args []string, dryRun bool) error {
+	if len(args) != 1 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("metrics requires exactly one argument: the path to the log directory"))
+	}
+
+	logDir := args[0]
+
+	actions, _, err := analyzeBackupResults(logDir)
+	if err != nil {
+		return err
+	}
+
+	content := formatPrometheusMetrics(buildMetrics(actions))
+
+	if dryRun || a.config.Output == "" {
+		if dryRun {
+			fmt.Println("DRY RUN: Would write metrics to", a.config.Output)
+		}
+		fmt.Print(content)
+		return nil
+	}
+
+	// Write to a temp file and rename it so the textfile collector never
+	// reads a partially written file
+	tmpFile, err := os.CreateTemp(filepath.Dir(a.config.Output), ".restic-kit-metrics-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary metrics file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set permissions on metrics file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), a.config.Output); err != nil {
+		return fmt.Errorf("failed to write metrics file %s: %w", a.config.Output, err)
+	}
+
+	shared.Infof("Metrics written to %s\n", a.config.Output)
+	return nil
+}
+
+// buildMetrics derives the metric families from the parsed action results
+func buildMetrics(actions []restic.ActionResult) []*metricFamily {
+	backupSuccess := &metricFamily{name: "restic_backup_success", help: "Whether the last backup succeeded (1) or failed (0)."}
+	backupBytes := &metricFamily{name: "restic_backup_bytes_processed", help: "Total bytes processed by the last backup."}
+	backupFilesNew := &metricFamily{name: "restic_backup_files_new", help: "Number of new files in the last backup."}
+	backupDataAdded := &metricFamily{name: "restic_backup_data_added_bytes", help: "Bytes added to the repository by the last backup."}
+	backupDuration := &metricFamily{name: "restic_backup_duration_seconds", help: "Duration of the last backup in seconds."}
+	backupTimestamp := &metricFamily{name: "restic_backup_timestamp_seconds", help: "Unix timestamp of the last backup run."}
+	checkSuccess := &metricFamily{name: "restic_check_success", help: "Whether the last repository check succeeded (1) or failed (0)."}
+	checkErrors := &metricFamily{name: "restic_check_errors", help: "Number of errors found by the last repository check."}
+	snapshotsTotal := &metricFamily{name: "restic_snapshots_total", help: "Number of snapshots in the repository per path."}
+	snapshotLatest := &metricFamily{name: "restic_snapshot_latest_timestamp_seconds", help: "Unix timestamp of the newest snapshot per path."}
+	repositorySize := &metricFamily{name: "restic_repository_size_bytes", help: "Total size of the repository as reported by restic stats."}
+
+	for _, action := range actions {
+		switch actionResult := action.(type) {
+		case *restic.BackupActionResult:
+			labels := [][2]string{{"backup", actionResult.Name}}
+			backupSuccess.add(labels, boolToFloat(actionResult.Success))
+			if actionResult.Result != nil {
+				backupBytes.add(labels, float64(actionResult.Result.TotalBytesProcessed))
+				backupFilesNew.add(labels, float64(actionResult.Result.FilesNew))
+				backupDataAdded.add(labels, float64(actionResult.Result.DataAdded))
+				backupDuration.add(labels, actionResult.Result.TotalDuration)
+			}
+			if info, err := os.Stat(actionResult.OutFile); err == nil {
+				backupTimestamp.add(labels, float64(info.ModTime().Unix()))
+			}
+
+		case *restic.CheckActionResult:
+			checkSuccess.add(nil, boolToFloat(actionResult.Success))
+			if actionResult.Result != nil {
+				checkErrors.add(nil, float64(actionResult.Result.NumErrors))
+			}
+
+		case *restic.StatsActionResult:
+			if actionResult.Result != nil {
+				repositorySize.add(nil, float64(actionResult.Result.TotalSize))
+			}
+
+		case *restic.SnapshotsActionResult:
+			countByPath := make(map[string]int)
+			latestByPath := make(map[string]time.Time)
+			for _, snap := range actionResult.Snapshots {
+				key := strings.Join(snap.Paths, ", ")
+				countByPath[key]++
+				if t, err := time.Parse(time.RFC3339Nano, snap.Time); err == nil && t.After(latestByPath[key]) {
+					latestByPath[key] = t
+				}
+			}
+
+			var paths []string
+			for path := range countByPath {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			for _, path := range paths {
+				labels := [][2]string{{"path", path}}
+				snapshotsTotal.add(labels, float64(countByPath[path]))
+				if latest, ok := latestByPath[path]; ok {
+					snapshotLatest.add(labels, float64(latest.Unix()))
+				}
+			}
+		}
+	}
+
+	return []*metricFamily{
+		backupSuccess, backupBytes, backupFilesNew, backupDataAdded, backupDuration, backupTimestamp,
+		checkSuccess, checkErrors, snapshotsTotal, snapshotLatest, repositorySize,
+	}
+}
+
+func (m *metricFamily) add(labels [][2]string, value float64) {
+	m.samples = append(m.samples, metricSample{labels: labels, value: value})
+}
+
+// formatPrometheusMetrics renders the metric families in the Prometheus text
+// exposition format, skipping families without samples
+func formatPrometheusMetrics(families []*metricFamily) string {
+	var out strings.Builder
+
+	for _, family := range families {
+		if len(family.samples) == 0 {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("# HELP %s %s\n", family.name, family.help))
+		out.WriteString(fmt.Sprintf("# TYPE %s gauge\n", family.name))
+		for _, sample := range family.samples {
+			out.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				var labels []string
+				for _, label := range sample.labels {
+					labels = append(labels, fmt.Sprintf("%s=\"%s\"", label[0], escapeLabelValue(label[1])))
+				}
+				out.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+			out.WriteString(" " + strconv.FormatFloat(sample.value, 'f', -1, 64) + "\n")
+		}
+	}
+
+	return out.String()
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return value
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func NewMetricsCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "metrics [log-directory]",
+		Short: "Write Prometheus textfile metrics",
+		Long: `Parse the logs in the specified directory and write Prometheus metrics for the node_exporter textfile collector.
+Prints the metrics to stdout if no output file is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			metricsConfig := &MetricsConfig{
+				Output: output,
+			}
+
+			if err := ValidateMetricsConfig(metricsConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid metrics config: %w", err))
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			action := NewMetricsAction(metricsConfig)
+			return action.Execute(args, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&output, "output", "", "Path of the .prom file to write (default: stdout)")
+
+	return cmd
+}
@@ -0,0 +1,90 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetricsAction(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "metrics-test*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	createExitCodeFile(t, tmpDir, "backup.etc.exitcode", 0)
+	createExitCodeFile(t, tmpDir, "backup.docker-confs.exitcode", 1)
+	createExitCodeFile(t, tmpDir, "check.exitcode", 0)
+	createExitCodeFile(t, tmpDir, "snapshots.exitcode", 0)
+
+	createOutFile(t, tmpDir, "backup.etc.out", `{"message_type":"summary","files_new":3,"total_bytes_processed":2048,"data_added":100,"total_duration":1.5}`)
+	createOutFile(t, tmpDir, "backup.docker-confs.out", ``)
+	createOutFile(t, tmpDir, "check.out", `{"message_type":"summary","num_errors":0}`)
+	createOutFile(t, tmpDir, "snapshots.out", `[{"group_key":{"hostname":"","paths":["/etc"],"tags":null},"snapshots":[{"time":"2025-01-01T00:00:00Z","paths":["/etc"],"id":"a"},{"time":"2025-01-02T00:00:00Z","paths":["/etc"],"id":"b"}]},{"group_key":{"hostname":"","paths":["/data \"x\""],"tags":null},"snapshots":[{"time":"2025-01-01T00:00:00Z","paths":["/data \"x\""],"id":"c"}]}]`)
+
+	output := filepath.Join(tmpDir, "restic.prom")
+	metricsConfig := &MetricsConfig{Output: output}
+	if err := ValidateMetricsConfig(metricsConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewMetricsAction(metricsConfig)
+	if err := action.Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Expected metrics file to be written: %v", err)
+	}
+	metrics := string(content)
+
+	expectedStrings := []string{
+		"# HELP restic_backup_success ",
+		"# TYPE restic_backup_success gauge",
+		`restic_backup_success{backup="etc"} 1`,
+		`restic_backup_success{backup="docker-confs"} 0`,
+		`restic_backup_bytes_processed{backup="etc"} 2048`,
+		`restic_backup_files_new{backup="etc"} 3`,
+		`restic_backup_duration_seconds{backup="etc"} 1.5`,
+		`restic_backup_timestamp_seconds{backup="etc"} `,
+		"restic_check_success 1",
+		"restic_check_errors 0",
+		`restic_snapshots_total{path="/etc"} 2`,
+		`restic_snapshots_total{path="/data \"x\""} 1`,
+		`restic_snapshot_latest_timestamp_seconds{path="/etc"} 1735776000`,
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("Expected metrics to contain '%s', but it didn't.\nActual metrics:\n%s", expected, metrics)
+		}
+	}
+
+	// Wrong number of arguments
+	if err := action.Execute([]string{}, false); err == nil {
+		t.Error("Expected error for no arguments, got nil")
+	}
+}
+
+func TestValidateMetricsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *MetricsConfig
+		wantErr bool
+	}{
+		{name: "stdout", config: &MetricsConfig{}, wantErr: false},
+		{name: "prom file", config: &MetricsConfig{Output: "/var/lib/node_exporter/restic.prom"}, wantErr: false},
+		{name: "wrong extension", config: &MetricsConfig{Output: "/tmp/restic.txt"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetricsConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMetricsConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
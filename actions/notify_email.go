@@ -1,12 +1,17 @@
 package actions
 
 import (
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,73 +21,344 @@ import (
 
 type NotifyEmailAction struct {
 	*BaseAction
-	config *shared.NotifyEmailConfig
+	config       *shared.NotifyEmailConfig
+	bodyTemplate *template.Template
+	// stdin is read instead of log directories with --stdin
+	stdin io.Reader
 }
 
 func NewNotifyEmailAction(cfg *shared.NotifyEmailConfig) *NotifyEmailAction {
 	return &NotifyEmailAction{
 		BaseAction: NewBaseAction("notify-email"),
 		config:     cfg,
+		stdin:      os.Stdin,
 	}
 }
 
 func (a *NotifyEmailAction) Execute(args []string, dryRun bool) error {
-	if len(args) != 1 {
-		return fmt.Errorf("notify-email requires exactly one argument: the path to the log directory")
+	if len(args) == 0 && !a.config.Stdin {
+		return WithExitCode(ExitConfigError, fmt.Errorf("notify-email requires at least one log directory or --stdin"))
 	}
 
-	logDir := args[0]
+	// Parse the body template before doing any work, so a broken template
+	// fails fast instead of after the logs were analyzed
+	if a.config.BodyTemplate != "" && a.bodyTemplate == nil {
+		tmpl, err := parseBodyTemplate(a.config.BodyTemplate)
+		if err != nil {
+			return err
+		}
+		a.bodyTemplate = tmpl
+	}
+
+	columns, err := parseSnapshotColumns(a.config.Columns)
+	if err != nil {
+		return err
+	}
+
+	var actions []restic.ActionResult
+	var overallSuccess bool
+	if a.config.Stdin {
+		actions, overallSuccess, err = readStdinBackup(a.stdin)
+	} else {
+		actions, overallSuccess, err = analyzeBackupResultsInRange(a.config.TimeRange, args...)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := newResultFile(a.GetName(), a.config.RepoName, actions, overallSuccess)
+
+	if a.config.OnFailureOnly && overallSuccess {
+		shared.Infof("All actions succeeded, no notification sent\n")
+		return writeResultFile(a.config.ResultFile, result, nil, dryRun)
+	}
+
+	// Embed the tail of each failed action's error output in the body
+	var excerpts map[restic.ActionResult]string
+	if a.config.InlineErrors {
+		excerpts = make(map[restic.ActionResult]string)
+		for _, action := range actions {
+			if action.IsSuccess() || action.GetErrFile() == "" {
+				continue
+			}
+			excerpt, err := readErrorExcerpt(action.GetErrFile(), a.config.InlineErrorLines)
+			if err != nil {
+				continue
+			}
+			if excerpt != "" {
+				excerpts[action] = excerpt
+			}
+		}
+	}
+
+	// Attach log files of failed actions, or of all actions with --attach-all
+	var attachments []string
+	var toCompress []string
+	var skipped []skippedAttachment
+	for _, action := range actions {
+		if action.IsSuccess() && !a.config.AttachAll {
+			continue
+		}
 
-	actions, overallSuccess, err := analyzeBackupResults(logDir)
+		for _, file := range []string{action.GetOutFile(), action.GetErrFile()} {
+			if file == "" {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			if a.config.MaxAttachmentSize > 0 && info.Size() > a.config.MaxAttachmentSize {
+				skipped = append(skipped, skippedAttachment{path: file, size: info.Size()})
+				continue
+			}
+			if a.config.AttachCompress && info.Size() > a.config.AttachCompressMinSize && !strings.HasSuffix(file, ".gz") {
+				toCompress = append(toCompress, file)
+				continue
+			}
+			attachments = append(attachments, file)
+		}
+	}
+
+	subjectData := shared.SubjectData{
+		Success:  overallSuccess,
+		Status:   map[bool]string{true: "SUCCESS", false: "FAILURE"}[overallSuccess],
+		Total:    len(actions),
+		RepoName: a.config.RepoName,
+	}
+	for _, action := range actions {
+		if !action.IsSuccess() {
+			subjectData.Failed++
+		}
+	}
+	subject, err := shared.BuildSubject(a.config, reportTitle(a.config.RepoName, overallSuccess), subjectData)
 	if err != nil {
 		return err
 	}
+	body := generateBodyFromActions(actions, overallSuccess, excerpts, a.config.MaxSnapshotRows, a.config.Location, columns)
+	if a.bodyTemplate != nil {
+		body, err = renderBodyTemplate(a.bodyTemplate, actions, overallSuccess, a.config.RepoName)
+		if err != nil {
+			return err
+		}
+	}
+	body += skippedAttachmentsNote(skipped, a.config.MaxAttachmentSize)
 
-	subject := fmt.Sprintf("Backup Report: %s", map[bool]string{true: "SUCCESS", false: "FAILURE"}[overallSuccess])
-	body := generateBodyFromActions(actions, overallSuccess)
+	// The report is saved before sending, also in dry-run mode, so there is
+	// a local record even if the email never arrives
+	if a.config.AttachReport != "" {
+		if err := os.WriteFile(a.config.AttachReport, []byte(body), 0644); err != nil {
+			return fmt.Errorf("failed to write report %s: %w", a.config.AttachReport, err)
+		}
+		shared.Verbosef("Report written to %s\n", a.config.AttachReport)
+	}
+
+	var htmlBody string
+	if a.config.Format == "html" {
+		htmlBody = generateHTMLBodyFromActions(actions, overallSuccess, excerpts, a.config.MaxSnapshotRows, a.config.Location, columns)
+		if note := skippedAttachmentsHTMLNote(skipped, a.config.MaxAttachmentSize); note != "" {
+			htmlBody = strings.Replace(htmlBody, "</body>", note+"</body>", 1)
+		}
+	}
 
 	if dryRun {
 		fmt.Println("DRY RUN: Would send email with subject:", subject)
 		fmt.Println("DRY RUN: Email body preview:")
 		fmt.Println(body)
-		return nil
+		if htmlBody != "" {
+			fmt.Println("DRY RUN: HTML body preview:")
+			fmt.Println(htmlBody)
+		}
+		for _, file := range attachments {
+			fmt.Println("DRY RUN: Would attach:", file)
+		}
+		for _, file := range toCompress {
+			fmt.Println("DRY RUN: Would compress attachment:", file)
+		}
+		return writeResultFile(a.config.ResultFile, result, nil, dryRun)
 	}
 
-	// Attach log files from action results
-	var attachments []string
+	// Compressed copies are written to temp files that are removed after sending
+	for _, file := range toCompress {
+		gzFile, err := gzipToTempFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to compress attachment %s: %w", file, err)
+		}
+		defer os.Remove(gzFile)
+		attachments = append(attachments, gzFile)
+	}
+
+	// The result file is written even if sending fails, so the result is
+	// not lost behind an SMTP error
+	sendErr := shared.SendEmail(a.config, subject, body, htmlBody, attachments, dryRun)
+	if sendErr != nil {
+		sendErr = WithExitCode(ExitNotifyError, fmt.Errorf("failed to send email: %w", sendErr))
+	}
+	if err := writeResultFile(a.config.ResultFile, result, sendErr, dryRun); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	shared.Infof("Email sent successfully\n")
+
+	if a.config.ExitOnFailure && !overallSuccess {
+		return backupFailedError(actions)
+	}
+	return nil
+}
+
+// skippedAttachment is a log file that was not attached because it exceeds
+// --max-attachment-size
+type skippedAttachment struct {
+	path string
+	size int64
+}
+
+// skippedAttachmentsNote lists the skipped attachments for the text body
+func skippedAttachmentsNote(skipped []skippedAttachment, maxSize int64) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+
+	var note strings.Builder
+	note.WriteString(fmt.Sprintf("\nAttachments skipped (larger than %s):\n", shared.FormatBytes(maxSize)))
+	for _, file := range skipped {
+		note.WriteString(fmt.Sprintf("  %s (%s)\n", file.path, shared.FormatBytes(file.size)))
+	}
+	return note.String()
+}
+
+// skippedAttachmentsHTMLNote lists the skipped attachments for the HTML body
+func skippedAttachmentsHTMLNote(skipped []skippedAttachment, maxSize int64) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+
+	var note strings.Builder
+	note.WriteString(fmt.Sprintf("<p>Attachments skipped (larger than %s):</p>\n<ul>\n", shared.FormatBytes(maxSize)))
+	for _, file := range skipped {
+		note.WriteString(fmt.Sprintf("<li>%s (%s)</li>\n", html.EscapeString(file.path), shared.FormatBytes(file.size)))
+	}
+	note.WriteString("</ul>\n")
+	return note.String()
+}
+
+// backupFailedError summarizes the failed actions for --exit-on-failure
+func backupFailedError(actions []restic.ActionResult) error {
+	var failed []string
 	for _, action := range actions {
-		if action.IsSuccess() {
-			continue
+		if !action.IsSuccess() {
+			failed = append(failed, actionTypeOf(action)+" "+action.GetActionName())
 		}
+	}
+	return fmt.Errorf("backup failed: %d of %d actions failed (%s)", len(failed), len(actions), strings.Join(failed, ", "))
+}
 
-		outFile := action.GetOutFile()
-		errFile := action.GetErrFile()
+// gzipToTempFile writes a gzip-compressed copy of the file to a temp file
+// and returns its path
+func gzipToTempFile(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
 
-		if outFile != "" {
-			if _, err := os.Stat(outFile); err == nil {
-				attachments = append(attachments, outFile)
-			}
+	dst, err := os.CreateTemp("", filepath.Base(path)+".*.gz")
+	if err != nil {
+		return "", err
+	}
+
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// maxInlineErrorBytes caps how much of an error file is read for inline excerpts
+const maxInlineErrorBytes = 64 * 1024
+
+// readErrorExcerpt returns the last lines of an error file, reading at most
+// maxInlineErrorBytes from its end
+func readErrorExcerpt(path string, lines int) (string, error) {
+	var content []byte
+	var offset int64
+	if strings.HasSuffix(path, ".gz") {
+		// Compressed files cannot be seeked, so they are read completely
+		decompressed, err := restic.ReadLogFile(path)
+		if err != nil {
+			return "", err
+		}
+		offset = int64(len(decompressed)) - maxInlineErrorBytes
+		if offset > 0 {
+			decompressed = decompressed[offset:]
 		}
-		if errFile != "" {
-			if _, err := os.Stat(errFile); err == nil {
-				attachments = append(attachments, errFile)
+		content = decompressed
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return "", err
+		}
+
+		offset = info.Size() - maxInlineErrorBytes
+		if offset > 0 {
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				return "", err
 			}
 		}
+		content, err = io.ReadAll(file)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	if err := shared.SendEmail(a.config, subject, body, attachments, dryRun); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	tail := strings.Split(strings.TrimRight(string(content), "\r\n"), "\n")
+	if offset > 0 && len(tail) > 1 {
+		// Drop the partial first line
+		tail = tail[1:]
 	}
-
-	fmt.Println("Email sent successfully")
-	return nil
+	if len(tail) > lines {
+		tail = tail[len(tail)-lines:]
+	}
+	return strings.TrimSpace(strings.Join(tail, "\n")), nil
 }
 
-func generateBodyFromActions(actions []restic.ActionResult, success bool) string {
+// generateBodyFromActions renders the plain-text report. A positive
+// maxSnapshotRows limits the snapshot table of each path to the newest rows.
+// Without columns, the snapshot table shows the default columns.
+func generateBodyFromActions(actions []restic.ActionResult, success bool, excerpts map[restic.ActionResult]string, maxSnapshotRows int, loc *time.Location, columns []snapshotColumn) string {
 	var body strings.Builder
+	if columns == nil {
+		columns, _ = parseSnapshotColumns(nil)
+	}
 
 	body.WriteString(fmt.Sprintf("Overall Status: %s\n\n", map[bool]string{true: "SUCCESS", false: "FAILURE"}[success]))
 
+	if totals := sumBackupTotals(actions); totals.backups > 0 {
+		body.WriteString(fmt.Sprintf("Total data added: %s across %d backups\n", shared.FormatBytes(totals.dataAdded), totals.backups))
+		body.WriteString(fmt.Sprintf("Total bytes processed: %s\n", shared.FormatBytes(totals.bytesProcessed)))
+		body.WriteString(fmt.Sprintf("Total files: %d new, %d changed\n\n", totals.filesNew, totals.filesChanged))
+	}
+
+	writeExitSummary(&body, actions)
+
 	// Process actions in execution order
 	for _, action := range actions {
 		switch actionResult := action.(type) {
@@ -91,7 +367,7 @@ func generateBodyFromActions(actions []restic.ActionResult, success bool) string
 			if !actionResult.Success {
 				statusEmoji = "❌"
 			}
-			body.WriteString(fmt.Sprintf("%s backup %s\n", statusEmoji, actionResult.Name))
+			body.WriteString(fmt.Sprintf("%s backup %s%s%s\n", statusEmoji, actionResult.Name, degradedSuffix(actionResult), warningsSuffix(actionResult)))
 
 			info := actionResult.GetSummaryInfo()
 			body.WriteString(fmt.Sprintf("  Files: %s new, %s changed, %s unmodified\n",
@@ -105,6 +381,29 @@ func generateBodyFromActions(actions []restic.ActionResult, success bool) string
 			if duration, ok := info["duration"]; ok {
 				body.WriteString(fmt.Sprintf("  Duration: %s seconds\n", duration))
 			}
+			if throughput, ok := info["throughput"]; ok {
+				body.WriteString(fmt.Sprintf("  Throughput: %s\n", throughput))
+			}
+			if warningsSuffix(actionResult) != "" {
+				shown, more := limitWarnings(actionResult.Warnings)
+				body.WriteString("  Warnings:\n")
+				for _, warning := range shown {
+					body.WriteString(fmt.Sprintf("  - %s\n", warning))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("  ... and %d more warnings\n", more))
+				}
+			}
+			if actionResult.Result != nil && len(actionResult.Result.Errors) > 0 {
+				shown, more := limitCheckErrors(actionResult.Result.Errors)
+				body.WriteString("  Errors:\n")
+				for _, backupErr := range shown {
+					body.WriteString(fmt.Sprintf("  - %s\n", backupErr))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("  ... and %d more errors\n", more))
+				}
+			}
 			body.WriteString("\n")
 
 		case *restic.CheckActionResult:
@@ -114,7 +413,17 @@ func generateBodyFromActions(actions []restic.ActionResult, success bool) string
 			}
 			body.WriteString(fmt.Sprintf("%s check\n", statusEmoji))
 			info := actionResult.GetSummaryInfo()
-			body.WriteString(fmt.Sprintf("  %s\n\n", info["status"]))
+			body.WriteString(fmt.Sprintf("  %s\n", info["status"]))
+			if actionResult.Result != nil {
+				shown, more := limitCheckErrors(actionResult.Result.Errors)
+				for _, checkErr := range shown {
+					body.WriteString(fmt.Sprintf("  - %s\n", checkErr))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("  ... and %d more errors\n", more))
+				}
+			}
+			body.WriteString("\n")
 
 		case *restic.SnapshotsActionResult:
 			body.WriteString(fmt.Sprintf("%s snapshots\n", "✅"))
@@ -137,41 +446,25 @@ func generateBodyFromActions(actions []restic.ActionResult, success bool) string
 			for _, path := range paths {
 				snapshots := groupedByPath[path]
 				body.WriteString(fmt.Sprintf("\n  Path: %s\n", path))
+				if tags := snapshotTags(snapshots); len(tags) > 0 {
+					body.WriteString(fmt.Sprintf("  Tags: %s\n", strings.Join(tags, ", ")))
+				}
 				body.WriteString(fmt.Sprintf("  Snapshots: %d\n", len(snapshots)))
 
 				if len(snapshots) > 0 {
-					body.WriteString("  Date & Time          |      New | Modified |  Total Files |   Added Size |   Total Size\n")
-					body.WriteString("  -------------------- | -------- | -------- | ------------ | ------------ | ------------\n")
+					body.WriteString(textSnapshotHeader(columns))
 
 					// Sort snapshots by time (newest first)
 					sort.Slice(snapshots, func(i, j int) bool {
 						return snapshots[i].Time > snapshots[j].Time
 					})
 
-					for _, snap := range snapshots {
-						// Parse time for formatting (YYYY-MM-DD HH:MM)
-						timeStr := snap.Time
-						if len(timeStr) >= 16 {
-							timeStr = timeStr[:10] + " " + timeStr[11:16] // YYYY-MM-DD HH:MM
-						}
-
-						// Get summary data
-						newFiles := "0"
-						modifiedFiles := "0"
-						totalFiles := "0"
-						addedSize := "0 B"
-						totalSize := "0 B"
-
-						if snap.Summary.FilesNew > 0 || snap.Summary.FilesChanged > 0 || snap.Summary.FilesUnmodified > 0 {
-							newFiles = fmt.Sprintf("%d", snap.Summary.FilesNew)
-							modifiedFiles = fmt.Sprintf("%d", snap.Summary.FilesChanged)
-							totalFiles = fmt.Sprintf("%d", snap.Summary.TotalFilesProcessed)
-							addedSize = formatBytes(snap.Summary.DataAdded)
-							totalSize = formatBytes(snap.Summary.TotalBytesProcessed)
-						}
-
-						body.WriteString(fmt.Sprintf("  %-20s | %8s | %8s | %12s | %12s | %12s\n",
-							timeStr, newFiles, modifiedFiles, totalFiles, addedSize, totalSize))
+					shown, older := limitSnapshotRows(snapshots, maxSnapshotRows)
+					for _, snap := range shown {
+						body.WriteString(textSnapshotRow(columns, snap, loc))
+					}
+					if older > 0 {
+						body.WriteString(fmt.Sprintf("  ... and %d older snapshots\n", older))
 					}
 				}
 			}
@@ -184,74 +477,647 @@ func generateBodyFromActions(actions []restic.ActionResult, success bool) string
 			}
 			body.WriteString(fmt.Sprintf("%s forget\n", statusEmoji))
 			if actionResult.RemovedCount > 0 {
-				body.WriteString(fmt.Sprintf("  %d snapshots removed\n\n", actionResult.RemovedCount))
+				body.WriteString(fmt.Sprintf("  %d snapshots removed\n", actionResult.RemovedCount))
+				shown, more := limitRemovedSnapshots(actionResult.Removed)
+				for _, snap := range shown {
+					body.WriteString(fmt.Sprintf("    - %s\n", removedSnapshotText(snap, loc)))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("    ... and %d more\n", more))
+				}
 			} else {
-				body.WriteString("  no snapshots removed\n\n")
+				body.WriteString("  no snapshots removed\n")
+			}
+			rules, keptByRule := groupForgetReasons(actionResult.Reasons, loc)
+			if len(rules) > 0 {
+				body.WriteString("  Kept snapshots by rule:\n")
+				for _, rule := range rules {
+					body.WriteString(fmt.Sprintf("    %s: %s\n", rule, strings.Join(keptByRule[rule], ", ")))
+				}
+			}
+			body.WriteString("\n")
+
+		case *restic.PruneActionResult:
+			statusEmoji := "✅"
+			if !actionResult.Success {
+				statusEmoji = "❌"
+			}
+			body.WriteString(fmt.Sprintf("%s prune\n", statusEmoji))
+			info := actionResult.GetSummaryInfo()
+			body.WriteString(fmt.Sprintf("  %s freed\n", info["size_freed"]))
+			body.WriteString(fmt.Sprintf("  %s packs deleted, %s blobs removed, %s packs to repack\n\n",
+				info["packs_deleted"], info["blobs_removed"], info["packs_to_repack"]))
+
+		case *restic.StatsActionResult:
+			statusEmoji := "✅"
+			if !actionResult.Success {
+				statusEmoji = "❌"
+			}
+			body.WriteString(fmt.Sprintf("%s stats\n", statusEmoji))
+			info := actionResult.GetSummaryInfo()
+			body.WriteString(fmt.Sprintf("  Repository size: %s%s\n", info["total_size"], repositoryGrowthText(actions)))
+			body.WriteString(fmt.Sprintf("  %s snapshots, %s files, %s blobs\n\n",
+				info["snapshots_count"], info["total_file_count"], info["total_blob_count"]))
+
+		case *restic.DiffActionResult:
+			statusEmoji := "✅"
+			if !actionResult.Success {
+				statusEmoji = "❌"
+			}
+			body.WriteString(fmt.Sprintf("%s diff\n", statusEmoji))
+			body.WriteString(fmt.Sprintf("  %s\n\n", diffSummary(actionResult)))
+
+		case *restic.CopyActionResult:
+			statusEmoji := "✅"
+			if !actionResult.Success {
+				statusEmoji = "❌"
+			}
+			body.WriteString(fmt.Sprintf("%s copy\n", statusEmoji))
+			body.WriteString(fmt.Sprintf("  %s\n\n", copySummary(actionResult)))
+
+		case *restic.GenericActionResult:
+			statusEmoji := "✅"
+			if !actionResult.Success {
+				statusEmoji = "❌"
 			}
+			body.WriteString(fmt.Sprintf("%s %s\n", statusEmoji, actionResult.Name))
+			body.WriteString(fmt.Sprintf("  Exit code: %d\n", actionResult.ExitCode))
+			for _, line := range actionResult.OutputTail {
+				body.WriteString("    " + line + "\n")
+			}
+			body.WriteString("\n")
+
+		case *restic.MissingOutputActionResult:
+			body.WriteString(fmt.Sprintf("❌ %s %s (no output captured)\n", actionResult.Type, actionResult.Name))
+			body.WriteString(fmt.Sprintf("  Exit code: %d\n\n", actionResult.ExitCode))
+		}
+
+		if excerpt, ok := excerpts[action]; ok {
+			body.WriteString("  Error output:\n")
+			for _, line := range strings.Split(excerpt, "\n") {
+				body.WriteString("    " + line + "\n")
+			}
+			body.WriteString("\n")
 		}
 	}
 
 	return body.String()
 }
 
-// formatBytes formats bytes into human readable format
-func formatBytes(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+// maxCheckErrors is the number of check and backup error messages shown in
+// the email
+const maxCheckErrors = 5
+
+// limitCheckErrors returns the first maxCheckErrors errors and the number of
+// errors that were left out
+func limitCheckErrors(errors []string) ([]string, int) {
+	if len(errors) <= maxCheckErrors {
+		return errors, 0
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	return errors[:maxCheckErrors], len(errors) - maxCheckErrors
+}
+
+// maxRemovedSnapshots is the number of removed snapshots listed for forget
+const maxRemovedSnapshots = 10
+
+// limitRemovedSnapshots returns the first maxRemovedSnapshots removed
+// snapshots and the number of snapshots that were left out
+func limitRemovedSnapshots(snapshots []restic.Snapshot) ([]restic.Snapshot, int) {
+	if len(snapshots) <= maxRemovedSnapshots {
+		return snapshots, 0
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return snapshots[:maxRemovedSnapshots], len(snapshots) - maxRemovedSnapshots
 }
 
-// analyzeBackupResults analyzes the backup results from a log directory
-// Helper functions (these could be moved to restic package if needed elsewhere)
-func readExitCode(exitcodeFile string) (int, error) {
-	content, err := os.ReadFile(exitcodeFile)
-	if err != nil {
-		return -1, err
+// removedSnapshotText describes a removed snapshot by its short ID, time
+// and paths, e.g. "1a2b3c4d 2024-01-01 10:00 /home"
+func removedSnapshotText(snap restic.Snapshot, loc *time.Location) string {
+	timeStr := formatSnapshotTime(snap.Time, loc)
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", snapshotShortID(snap), timeStr, strings.Join(snap.Paths, ", ")))
+}
+
+// formatSnapshotTime shortens an RFC3339 snapshot time to YYYY-MM-DD HH:MM.
+// With a location the time is converted to it, otherwise the offset restic
+// wrote is kept.
+func formatSnapshotTime(t string, loc *time.Location) string {
+	if loc != nil {
+		if parsed, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return parsed.In(loc).Format("2006-01-02 15:04")
+		}
 	}
-	code, err := strconv.Atoi(strings.TrimSpace(string(content)))
-	if err != nil {
-		return -1, fmt.Errorf("invalid exit code in %s: %w", exitcodeFile, err)
+	if len(t) >= 16 {
+		return t[:10] + " " + t[11:16]
+	}
+	return t
+}
+
+// limitSnapshotRows returns the first maxRows snapshots of a list sorted
+// newest first and the number of older snapshots that were left out. A
+// maxRows of 0 shows all snapshots.
+func limitSnapshotRows(snapshots []restic.Snapshot, maxRows int) ([]restic.Snapshot, int) {
+	if maxRows <= 0 || len(snapshots) <= maxRows {
+		return snapshots, 0
 	}
-	return code, nil
+	return snapshots[:maxRows], len(snapshots) - maxRows
 }
 
-func determineActionType(exitcodeFile string) (string, string) {
-	base := filepath.Base(exitcodeFile)
-	base = strings.TrimSuffix(base, ".exitcode")
+// maxInlineWarnings is the number of warnings shown for a backup
+const maxInlineWarnings = 3
 
-	if strings.HasPrefix(base, "backup.") {
-		actionName := strings.TrimPrefix(base, "backup.")
-		return "backup", actionName
-	} else if base == "check" {
-		return "check", base
-	} else if base == "snapshots" {
-		return "snapshots", base
-	} else if base == "forget" {
-		return "forget", base
+// limitWarnings returns the first maxInlineWarnings warnings and the number
+// of warnings that were left out
+func limitWarnings(warnings []string) ([]string, int) {
+	if len(warnings) <= maxInlineWarnings {
+		return warnings, 0
 	}
-	return "unknown", base
+	return warnings[:maxInlineWarnings], len(warnings) - maxInlineWarnings
 }
 
-func determineOverallSuccessFromActions(actions []restic.ActionResult) bool {
+// warningsSuffix returns the warning count shown next to an otherwise
+// successful backup. Failed backups report their error output instead.
+func warningsSuffix(backup *restic.BackupActionResult) string {
+	if !backup.Success || len(backup.Warnings) == 0 {
+		return ""
+	}
+	if len(backup.Warnings) == 1 {
+		return " ⚠ 1 warning"
+	}
+	return fmt.Sprintf(" ⚠ %d warnings", len(backup.Warnings))
+}
+
+// degradedSuffix marks a failed backup that still completed with a summary,
+// e.g. because some files could not be read
+func degradedSuffix(backup *restic.BackupActionResult) string {
+	if backup.Success || backup.Result == nil || !backup.Result.Degraded {
+		return ""
+	}
+	return " (completed with errors)"
+}
+
+// backupTotals aggregates the statistics of all backup actions of a run
+type backupTotals struct {
+	backups        int
+	dataAdded      int64
+	bytesProcessed int64
+	filesNew       int
+	filesChanged   int
+}
+
+// sumBackupTotals sums the statistics of all backup actions
+func sumBackupTotals(actions []restic.ActionResult) backupTotals {
+	var totals backupTotals
 	for _, action := range actions {
-		if !action.IsSuccess() {
-			return false
+		backup, ok := action.(*restic.BackupActionResult)
+		if !ok {
+			continue
+		}
+		totals.backups++
+		if backup.Result != nil {
+			totals.dataAdded += backup.Result.DataAdded
+			totals.bytesProcessed += backup.Result.TotalBytesProcessed
+			totals.filesNew += backup.Result.FilesNew
+			totals.filesChanged += backup.Result.FilesChanged
 		}
 	}
-	return true
+	return totals
 }
 
-func analyzeBackupResults(logDir string) ([]restic.ActionResult, bool, error) {
-	exitcodeFiles, err := filepath.Glob(filepath.Join(logDir, "*.exitcode"))
-	if err != nil {
-		return nil, false, fmt.Errorf("failed to list exitcode files in %s: %w", logDir, err)
+// actionStatus returns the status label of an action for the exit summary
+func actionStatus(action restic.ActionResult) string {
+	if action.IsSuccess() {
+		return "OK"
+	}
+	return "FAILED"
+}
+
+// writeExitSummary writes a compact table with the status and exit code of
+// every action
+func writeExitSummary(body *strings.Builder, actions []restic.ActionResult) {
+	if len(actions) == 0 {
+		return
+	}
+
+	nameWidth := len("Action")
+	for _, action := range actions {
+		nameWidth = max(nameWidth, len(action.GetActionName()))
+	}
+
+	body.WriteString(fmt.Sprintf("%-*s | %-9s | %-6s | %4s\n", nameWidth, "Action", "Type", "Status", "Exit"))
+	body.WriteString(fmt.Sprintf("%s | --------- | ------ | ----\n", strings.Repeat("-", nameWidth)))
+	for _, action := range actions {
+		body.WriteString(fmt.Sprintf("%-*s | %-9s | %-6s | %4d\n",
+			nameWidth, action.GetActionName(), actionTypeOf(action), actionStatus(action), action.GetExitCode()))
+	}
+	body.WriteString("\n")
+}
+
+// writeHTMLExitSummary writes the HTML version of the exit summary table
+func writeHTMLExitSummary(body *strings.Builder, actions []restic.ActionResult) {
+	if len(actions) == 0 {
+		return
+	}
+
+	body.WriteString("<table style=\"border-collapse:collapse;\" border=\"1\" cellpadding=\"4\">\n")
+	body.WriteString("<tr><th>Action</th><th>Type</th><th>Status</th><th>Exit Code</th></tr>\n")
+	for _, action := range actions {
+		body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td align=\"right\">%d</td></tr>\n",
+			html.EscapeString(action.GetActionName()),
+			actionTypeOf(action),
+			htmlStatusBadge(action.IsSuccess()),
+			action.GetExitCode()))
+	}
+	body.WriteString("</table>\n")
+}
+
+// snapshotShortID returns the short ID of a snapshot, falling back to the
+// first 8 characters of its ID
+func snapshotShortID(snap restic.Snapshot) string {
+	if snap.ShortID != "" {
+		return snap.ShortID
+	}
+	if len(snap.ID) >= 8 {
+		return snap.ID[:8]
+	}
+	return snap.ID
+}
+
+// snapshotTags returns the sorted, distinct tags of the given snapshots
+func snapshotTags(snapshots []restic.Snapshot) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, snap := range snapshots {
+		for _, tag := range snap.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// groupForgetReasons groups the kept snapshots by the retention rule that
+// matched them, e.g. "daily snapshot". Rules are returned in the order restic
+// reported them and each snapshot is formatted as "<short id> (<time>)".
+func groupForgetReasons(reasons []restic.ForgetReason, loc *time.Location) ([]string, map[string][]string) {
+	var rules []string
+	keptByRule := make(map[string][]string)
+
+	for _, reason := range reasons {
+		shortID := snapshotShortID(reason.Snapshot)
+		timeStr := formatSnapshotTime(reason.Snapshot.Time, loc)
+		entry := shortID
+		if timeStr != "" {
+			entry += " (" + timeStr + ")"
+		}
+
+		for _, rule := range reason.Matches {
+			if _, ok := keptByRule[rule]; !ok {
+				rules = append(rules, rule)
+			}
+			keptByRule[rule] = append(keptByRule[rule], entry)
+		}
+	}
+
+	return rules, keptByRule
+}
+
+// repositoryGrowthText returns how much the backups of this run added to the
+// repository, e.g. " (+1.2 MB from this run)", or an empty string
+func repositoryGrowthText(actions []restic.ActionResult) string {
+	var added int64
+	for _, action := range actions {
+		if backup, ok := action.(*restic.BackupActionResult); ok && backup.Result != nil {
+			added += backup.Result.DataAddedPacked
+		}
+	}
+	if added == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (+%s from this run)", shared.FormatBytes(added))
+}
+
+// htmlStatusBadge returns a colored status badge for the HTML report
+func htmlStatusBadge(success bool) string {
+	if success {
+		return `<span style="background-color:#2e7d32;color:#ffffff;padding:2px 6px;border-radius:3px;font-weight:bold;">OK</span>`
+	}
+	return `<span style="background-color:#c62828;color:#ffffff;padding:2px 6px;border-radius:3px;font-weight:bold;">FAILED</span>`
+}
+
+func generateHTMLBodyFromActions(actions []restic.ActionResult, success bool, excerpts map[restic.ActionResult]string, maxSnapshotRows int, loc *time.Location, columns []snapshotColumn) string {
+	var body strings.Builder
+	if columns == nil {
+		columns, _ = parseSnapshotColumns(nil)
+	}
+
+	body.WriteString("<!DOCTYPE html>\n<html>\n<body style=\"font-family:Arial,Helvetica,sans-serif;font-size:14px;\">\n")
+	body.WriteString(fmt.Sprintf("<h2>Overall Status: %s %s</h2>\n",
+		htmlStatusBadge(success), map[bool]string{true: "SUCCESS", false: "FAILURE"}[success]))
+
+	if totals := sumBackupTotals(actions); totals.backups > 0 {
+		body.WriteString("<table style=\"border-collapse:collapse;\">\n")
+		writeHTMLRow(&body, "Total data added", fmt.Sprintf("%s across %d backups", shared.FormatBytes(totals.dataAdded), totals.backups))
+		writeHTMLRow(&body, "Total bytes processed", shared.FormatBytes(totals.bytesProcessed))
+		writeHTMLRow(&body, "Total files", fmt.Sprintf("%d new, %d changed", totals.filesNew, totals.filesChanged))
+		body.WriteString("</table>\n")
+	}
+
+	writeHTMLExitSummary(&body, actions)
+
+	// Process actions in execution order
+	for _, action := range actions {
+		switch actionResult := action.(type) {
+		case *restic.BackupActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s backup %s%s%s</h3>\n", htmlStatusBadge(actionResult.Success), html.EscapeString(actionResult.Name), degradedSuffix(actionResult), warningsSuffix(actionResult)))
+
+			info := actionResult.GetSummaryInfo()
+			body.WriteString("<table style=\"border-collapse:collapse;\">\n")
+			writeHTMLRow(&body, "Files", fmt.Sprintf("%s new, %s changed, %s unmodified",
+				info["files_new"], info["files_changed"], info["files_unmodified"]))
+			writeHTMLRow(&body, "Directories", fmt.Sprintf("%s new, %s changed, %s unmodified",
+				info["dirs_new"], info["dirs_changed"], info["dirs_unmodified"]))
+			writeHTMLRow(&body, "Data added", fmt.Sprintf("%s (%s packed)",
+				info["data_added"], info["data_added_packed"]))
+			writeHTMLRow(&body, "Total files processed", info["total_files_processed"])
+			writeHTMLRow(&body, "Total bytes processed", info["total_bytes_processed"])
+			if duration, ok := info["duration"]; ok {
+				writeHTMLRow(&body, "Duration", duration+" seconds")
+			}
+			if throughput, ok := info["throughput"]; ok {
+				writeHTMLRow(&body, "Throughput", throughput)
+			}
+			body.WriteString("</table>\n")
+			if warningsSuffix(actionResult) != "" {
+				shown, more := limitWarnings(actionResult.Warnings)
+				body.WriteString("<ul>\n")
+				for _, warning := range shown {
+					body.WriteString(fmt.Sprintf("<li>%s</li>\n", html.EscapeString(warning)))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("<li>... and %d more warnings</li>\n", more))
+				}
+				body.WriteString("</ul>\n")
+			}
+			if actionResult.Result != nil && len(actionResult.Result.Errors) > 0 {
+				shown, more := limitCheckErrors(actionResult.Result.Errors)
+				body.WriteString("<ul>\n")
+				for _, backupErr := range shown {
+					body.WriteString(fmt.Sprintf("<li>%s</li>\n", html.EscapeString(backupErr)))
+				}
+				if more > 0 {
+					body.WriteString(fmt.Sprintf("<li>... and %d more errors</li>\n", more))
+				}
+				body.WriteString("</ul>\n")
+			}
+
+		case *restic.CheckActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s check</h3>\n", htmlStatusBadge(actionResult.Success)))
+			info := actionResult.GetSummaryInfo()
+			body.WriteString(fmt.Sprintf("<p>%s</p>\n", html.EscapeString(info["status"])))
+			if actionResult.Result != nil {
+				shown, more := limitCheckErrors(actionResult.Result.Errors)
+				if len(shown) > 0 {
+					body.WriteString("<ul>\n")
+					for _, checkErr := range shown {
+						body.WriteString(fmt.Sprintf("<li>%s</li>\n", html.EscapeString(checkErr)))
+					}
+					if more > 0 {
+						body.WriteString(fmt.Sprintf("<li>... and %d more errors</li>\n", more))
+					}
+					body.WriteString("</ul>\n")
+				}
+			}
+
+		case *restic.SnapshotsActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s snapshots</h3>\n", htmlStatusBadge(true)))
+			body.WriteString(fmt.Sprintf("<p>Repository Snapshots: %d</p>\n", len(actionResult.Snapshots)))
+
+			// Group snapshots by paths
+			groupedByPath := make(map[string][]restic.Snapshot)
+			for _, snap := range actionResult.Snapshots {
+				key := strings.Join(snap.Paths, ", ")
+				groupedByPath[key] = append(groupedByPath[key], snap)
+			}
+
+			// Sort paths alphabetically
+			var paths []string
+			for path := range groupedByPath {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			for _, path := range paths {
+				snapshots := groupedByPath[path]
+				body.WriteString(fmt.Sprintf("<h4>Path: %s</h4>\n", html.EscapeString(path)))
+				if tags := snapshotTags(snapshots); len(tags) > 0 {
+					body.WriteString(fmt.Sprintf("<p>Tags: %s</p>\n", html.EscapeString(strings.Join(tags, ", "))))
+				}
+				body.WriteString(fmt.Sprintf("<p>Snapshots: %d</p>\n", len(snapshots)))
+
+				if len(snapshots) == 0 {
+					continue
+				}
+
+				body.WriteString("<table style=\"border-collapse:collapse;\" border=\"1\" cellpadding=\"4\">\n")
+				body.WriteString("<tr>")
+				for _, column := range columns {
+					body.WriteString(fmt.Sprintf("<th>%s</th>", html.EscapeString(column.header)))
+				}
+				body.WriteString("</tr>\n")
+
+				// Sort snapshots by time (newest first)
+				sort.Slice(snapshots, func(i, j int) bool {
+					return snapshots[i].Time > snapshots[j].Time
+				})
+
+				shown, older := limitSnapshotRows(snapshots, maxSnapshotRows)
+				for _, snap := range shown {
+					body.WriteString("<tr>")
+					for _, column := range columns {
+						align := ""
+						if !column.left {
+							align = " align=\"right\""
+						}
+						body.WriteString(fmt.Sprintf("<td%s>%s</td>", align, html.EscapeString(column.value(snap, loc))))
+					}
+					body.WriteString("</tr>\n")
+				}
+				body.WriteString("</table>\n")
+				if older > 0 {
+					body.WriteString(fmt.Sprintf("<p>... and %d older snapshots</p>\n", older))
+				}
+			}
+
+		case *restic.ForgetActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s forget</h3>\n", htmlStatusBadge(actionResult.Success)))
+			if actionResult.RemovedCount > 0 {
+				body.WriteString(fmt.Sprintf("<p>%d snapshots removed</p>\n", actionResult.RemovedCount))
+				shown, more := limitRemovedSnapshots(actionResult.Removed)
+				if len(shown) > 0 {
+					body.WriteString("<ul>\n")
+					for _, snap := range shown {
+						body.WriteString(fmt.Sprintf("<li>%s</li>\n", html.EscapeString(removedSnapshotText(snap, loc))))
+					}
+					if more > 0 {
+						body.WriteString(fmt.Sprintf("<li>... and %d more</li>\n", more))
+					}
+					body.WriteString("</ul>\n")
+				}
+			} else {
+				body.WriteString("<p>no snapshots removed</p>\n")
+			}
+			rules, keptByRule := groupForgetReasons(actionResult.Reasons, loc)
+			if len(rules) > 0 {
+				body.WriteString("<table style=\"border-collapse:collapse;\">\n")
+				for _, rule := range rules {
+					writeHTMLRow(&body, rule, strings.Join(keptByRule[rule], ", "))
+				}
+				body.WriteString("</table>\n")
+			}
+
+		case *restic.PruneActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s prune</h3>\n", htmlStatusBadge(actionResult.Success)))
+			info := actionResult.GetSummaryInfo()
+			body.WriteString(fmt.Sprintf("<p>%s freed (%s packs deleted, %s blobs removed, %s packs to repack)</p>\n",
+				html.EscapeString(info["size_freed"]), info["packs_deleted"], info["blobs_removed"], info["packs_to_repack"]))
+
+		case *restic.StatsActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s stats</h3>\n", htmlStatusBadge(actionResult.Success)))
+			info := actionResult.GetSummaryInfo()
+			body.WriteString("<table style=\"border-collapse:collapse;\">\n")
+			writeHTMLRow(&body, "Repository size", info["total_size"]+repositoryGrowthText(actions))
+			writeHTMLRow(&body, "Snapshots", info["snapshots_count"])
+			writeHTMLRow(&body, "Files", info["total_file_count"])
+			writeHTMLRow(&body, "Blobs", info["total_blob_count"])
+			body.WriteString("</table>\n")
+
+		case *restic.DiffActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s diff</h3>\n", htmlStatusBadge(actionResult.Success)))
+			body.WriteString(fmt.Sprintf("<p>%s</p>\n", html.EscapeString(diffSummary(actionResult))))
+
+		case *restic.CopyActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s copy</h3>\n", htmlStatusBadge(actionResult.Success)))
+			body.WriteString(fmt.Sprintf("<p>%s</p>\n", html.EscapeString(copySummary(actionResult))))
+
+		case *restic.GenericActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s %s</h3>\n", htmlStatusBadge(actionResult.Success), html.EscapeString(actionResult.Name)))
+			body.WriteString(fmt.Sprintf("<p>Exit code: %d</p>\n", actionResult.ExitCode))
+			if len(actionResult.OutputTail) > 0 {
+				body.WriteString(fmt.Sprintf("<pre style=\"background-color:#f5f5f5;padding:8px;\">%s</pre>\n",
+					html.EscapeString(strings.Join(actionResult.OutputTail, "\n"))))
+			}
+
+		case *restic.MissingOutputActionResult:
+			body.WriteString(fmt.Sprintf("<h3>%s %s %s (no output captured)</h3>\n",
+				htmlStatusBadge(false), actionResult.Type, html.EscapeString(actionResult.Name)))
+			body.WriteString(fmt.Sprintf("<p>Exit code: %d</p>\n", actionResult.ExitCode))
+		}
+
+		if excerpt, ok := excerpts[action]; ok {
+			body.WriteString(fmt.Sprintf("<p><b>Error output:</b></p>\n<pre style=\"background-color:#f5f5f5;padding:8px;\">%s</pre>\n",
+				html.EscapeString(excerpt)))
+		}
+	}
+
+	body.WriteString("</body>\n</html>\n")
+	return body.String()
+}
+
+// diffSummary returns a one-line summary of a diff between two snapshots
+func diffSummary(action *restic.DiffActionResult) string {
+	info := action.GetSummaryInfo()
+	summary := fmt.Sprintf("%s added, %s removed, %s changed files, %s",
+		info["files_added"], info["files_removed"], info["files_changed"], info["size_delta"])
+	if action.Result != nil && action.Result.SourceSnapshot != "" && action.Result.TargetSnapshot != "" {
+		summary = fmt.Sprintf("%s..%s: %s", shortSnapshotID(action.Result.SourceSnapshot),
+			shortSnapshotID(action.Result.TargetSnapshot), summary)
+	}
+	return summary
+}
+
+// copySummary returns a one-line summary of a copy to another repository
+func copySummary(action *restic.CopyActionResult) string {
+	info := action.GetSummaryInfo()
+	return fmt.Sprintf("%s snapshots copied (%s), %s already present",
+		info["snapshots_copied"], info["bytes_copied"], info["snapshots_skipped"])
+}
+
+// shortSnapshotID shortens a full snapshot ID to the usual 8 characters
+func shortSnapshotID(id string) string {
+	return snapshotShortID(restic.Snapshot{ID: id})
+}
+
+// writeHTMLRow writes a label/value row of an HTML summary table
+func writeHTMLRow(body *strings.Builder, label, value string) {
+	body.WriteString(fmt.Sprintf("<tr><td style=\"padding-right:12px;\"><b>%s</b></td><td>%s</td></tr>\n",
+		html.EscapeString(label), html.EscapeString(value)))
+}
+
+// reportTitle returns the "Backup Report: <status>" title used by all
+// notifications, labeled with the repository name if one is set
+func reportTitle(repoName string, success bool) string {
+	status := map[bool]string{true: "SUCCESS", false: "FAILURE"}[success]
+	if repoName != "" {
+		return fmt.Sprintf("Backup Report (%s): %s", repoName, status)
+	}
+	return "Backup Report: " + status
+}
+
+// resolveLogDirs combines the log directories given as arguments with the
+// directories matching the glob pattern, skipping duplicates
+func resolveLogDirs(args []string, pattern string) ([]string, error) {
+	logDirs := append([]string{}, args...)
+	if pattern != "" {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, WithExitCode(ExitConfigError, fmt.Errorf("invalid log-dir-glob %q: %w", pattern, err))
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				logDirs = append(logDirs, match)
+			}
+		}
+		if len(matches) == 0 && len(args) == 0 {
+			return nil, WithExitCode(ExitConfigError, fmt.Errorf("no log directories match %q", pattern))
+		}
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, dir := range logDirs {
+		key := filepath.Clean(dir)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, dir)
+	}
+	if len(unique) == 0 {
+		return nil, WithExitCode(ExitConfigError, fmt.Errorf("at least one log directory or --log-dir-glob is required"))
+	}
+	return unique, nil
+}
+
+// analyzeBackupResults parses the logs of all given directories. The actions
+// of all directories are merged in the order they were run.
+func analyzeBackupResults(logDirs ...string) ([]restic.ActionResult, bool, error) {
+	return analyzeBackupResultsInRange(shared.TimeRange{}, logDirs...)
+}
+
+// analyzeBackupResultsInRange is analyzeBackupResults limited to the actions
+// whose exitcode file was written within the time range
+func analyzeBackupResultsInRange(timeRange shared.TimeRange, logDirs ...string) ([]restic.ActionResult, bool, error) {
+	var exitcodeFiles []string
+	for _, logDir := range logDirs {
+		for _, pattern := range []string{"*.exitcode", "*.exitcode.gz"} {
+			matches, err := filepath.Glob(filepath.Join(logDir, pattern))
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to list exitcode files in %s: %w", logDir, err)
+			}
+			exitcodeFiles = append(exitcodeFiles, matches...)
+		}
 	}
 
 	// Sort exitcode files by modification time to preserve execution order
@@ -261,11 +1127,21 @@ func analyzeBackupResults(logDir string) ([]restic.ActionResult, bool, error) {
 	}
 	var filesWithTime []fileWithTime
 	for _, f := range exitcodeFiles {
+		// Compressed files are tracked by their uncompressed name and only
+		// used if the plain file is missing
+		path := strings.TrimSuffix(f, ".gz")
+		if path != f && restic.ResolveLogFile(path) != f {
+			continue
+		}
 		info, err := os.Stat(f)
 		if err != nil {
 			continue
 		}
-		filesWithTime = append(filesWithTime, fileWithTime{path: f, mtime: info.ModTime()})
+		if !timeRange.Contains(info.ModTime()) {
+			shared.Verbosef("Skipping %s outside of the time range\n", f)
+			continue
+		}
+		filesWithTime = append(filesWithTime, fileWithTime{path: path, mtime: info.ModTime()})
 	}
 	sort.Slice(filesWithTime, func(i, j int) bool {
 		return filesWithTime[i].mtime.Before(filesWithTime[j].mtime)
@@ -277,125 +1153,426 @@ func analyzeBackupResults(logDir string) ([]restic.ActionResult, bool, error) {
 		exitcodeFiles[i] = f.path
 	}
 
+	// Parse the logs concurrently, each result keeps the position of its
+	// exitcode file so the chronological order is preserved
+	results := make([]restic.ActionResult, len(exitcodeFiles))
+	errs := make([]error, len(exitcodeFiles))
+	sem := make(chan struct{}, maxParseWorkers)
+	var wg sync.WaitGroup
+	for i, exitcodeFile := range exitcodeFiles {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = parseActionLog(exitcodeFile)
+		}()
+	}
+	wg.Wait()
+
 	var actions []restic.ActionResult
+	for i, result := range results {
+		if errs[i] != nil {
+			return nil, false, WithExitCode(ExitParseError, errs[i])
+		}
+		if result != nil {
+			actions = append(actions, result)
+		}
+	}
+
+	overallSuccess := restic.DetermineOverallSuccess(actions)
+	return actions, overallSuccess, nil
+}
+
+// maxParseWorkers limits how many log files are parsed concurrently
+const maxParseWorkers = 8
+
+// stdinActionName is the name of the backup read with --stdin
+const stdinActionName = "stdin"
+
+// readStdinBackup builds the report of a single backup from the output of
+// restic backup --json piped into restic-kit
+func readStdinBackup(r io.Reader) ([]restic.ActionResult, bool, error) {
+	result, exitCode, err := restic.ParseBackupStream(r)
+	if err != nil {
+		return nil, false, WithExitCode(ExitParseError, fmt.Errorf("failed to parse backup output from stdin: %w", err))
+	}
+	action := &restic.BackupActionResult{
+		Name:     stdinActionName,
+		Success:  exitCode == 0,
+		Result:   result,
+		ExitCode: exitCode,
+	}
+	return []restic.ActionResult{action}, action.Success, nil
+}
+
+// genericOutputLines is the number of output lines kept for actions without
+// a dedicated parser
+const genericOutputLines = 5
+
+// parseActionLog reads and parses the logs belonging to an exitcode file.
+// Action types without a dedicated parser are reported with the last lines
+// of their output.
+func parseActionLog(exitcodeFile string) (restic.ActionResult, error) {
+	actionType, actionName := restic.DetermineActionType(exitcodeFile)
+
+	exitCode, err := restic.ReadExitCode(exitcodeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exit code from %s: %w", exitcodeFile, err)
+	}
+
+	success := exitCode == 0
 
-	for _, exitcodeFile := range exitcodeFiles {
-		actionType, actionName := determineActionType(exitcodeFile)
+	outFile := restic.ResolveLogFile(strings.TrimSuffix(exitcodeFile, ".exitcode") + ".out")
+	errFile := restic.ResolveLogFile(strings.TrimSuffix(exitcodeFile, ".exitcode") + ".err")
+	shared.Verbosef("Parsing %s output %s (exit code %d)\n", actionType, outFile, exitCode)
 
-		exitCode, err := readExitCode(exitcodeFile)
+	// Restic reports non-fatal problems such as unreadable files on stderr,
+	// even if the action succeeded
+	warnings := readWarnings(errFile)
+
+	// An action that crashed before writing any output is reported as
+	// failed instead of aborting the whole report
+	if _, err := os.Stat(outFile); os.IsNotExist(err) && actionType != "unknown" {
+		return &restic.MissingOutputActionResult{
+			Type:     actionType,
+			Name:     actionName,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+	}
+
+	// Verbose backup logs can be huge, so they are streamed instead of
+	// being read into memory
+	if actionType == "backup" {
+		out, err := restic.OpenLogFile(outFile)
 		if err != nil {
-			return nil, false, fmt.Errorf("failed to read exit code from %s: %w", exitcodeFile, err)
+			return nil, fmt.Errorf("failed to read output file %s: %w", outFile, err)
 		}
+		defer out.Close()
 
-		success := exitCode == 0
-
-		outFile := strings.TrimSuffix(exitcodeFile, ".exitcode") + ".out"
-		errFile := strings.TrimSuffix(exitcodeFile, ".exitcode") + ".err"
-		outContent, err := os.ReadFile(outFile)
+		result, err := restic.ParseBackupReader(out, success)
 		if err != nil {
-			return nil, false, fmt.Errorf("failed to read output file %s: %w", outFile, err)
+			return nil, fmt.Errorf("failed to parse backup output for %s: %w", actionName, err)
 		}
+		return &restic.BackupActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+	}
 
-		switch actionType {
-		case "backup":
-			result, err := restic.ParseBackupOutput(string(outContent), success)
+	if actionType == "unknown" {
+		var tail []string
+		if out, err := restic.OpenLogFile(outFile); err == nil {
+			tail, err = restic.ParseOutputTail(out, genericOutputLines)
+			out.Close()
 			if err != nil {
-				return nil, false, fmt.Errorf("failed to parse backup output for %s: %w", actionName, err)
-			}
-			actions = append(actions, &restic.BackupActionResult{
-				Name:    actionName,
-				Success: success,
-				Result:  result,
-				OutFile: outFile,
-				ErrFile: errFile,
-			})
-
-		case "check":
-			result, err := restic.ParseCheckOutput(string(outContent), success)
-			if err != nil {
-				return nil, false, fmt.Errorf("failed to parse check output: %w", err)
-			}
-			actions = append(actions, &restic.CheckActionResult{
-				Name:    actionName,
-				Success: success,
-				Result:  result,
-				OutFile: outFile,
-				ErrFile: errFile,
-			})
-
-		case "snapshots":
-			snapshots, err := restic.ParseSnapshotsOutput(string(outContent))
-			if err != nil {
-				return nil, false, fmt.Errorf("failed to parse snapshots output: %w", err)
-			}
-			actions = append(actions, &restic.SnapshotsActionResult{
-				Name:      actionName,
-				Success:   success,
-				Snapshots: snapshots,
-				OutFile:   outFile,
-				ErrFile:   errFile,
-			})
-
-		case "forget":
-			snapshots, removedCount, err := restic.ParseForgetOutput(string(outContent))
-			if err != nil {
-				return nil, false, fmt.Errorf("failed to parse forget output: %w", err)
+				return nil, fmt.Errorf("failed to read output file %s: %w", outFile, err)
 			}
-			actions = append(actions, &restic.ForgetActionResult{
-				Name:         actionName,
-				Success:      success,
-				Snapshots:    snapshots,
-				RemovedCount: removedCount,
-				OutFile:      outFile,
-				ErrFile:      errFile,
-			})
 		}
+		return &restic.GenericActionResult{
+			Name:       actionName,
+			Success:    success,
+			OutputTail: tail,
+			OutFile:    outFile,
+			ErrFile:    errFile,
+			ExitCode:   exitCode,
+			Warnings:   warnings,
+		}, nil
 	}
 
-	overallSuccess := determineOverallSuccessFromActions(actions)
-	return actions, overallSuccess, nil
+	outContent, err := restic.ReadLogFile(outFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file %s: %w", outFile, err)
+	}
+
+	switch actionType {
+	case "check":
+		result, err := restic.ParseCheckOutput(string(outContent), success)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse check output: %w", err)
+		}
+		return &restic.CheckActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+
+	case "snapshots":
+		snapshots, err := restic.ParseSnapshotsOutput(string(outContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse snapshots output: %w", err)
+		}
+		return &restic.SnapshotsActionResult{
+			Name:      actionName,
+			Success:   success,
+			Snapshots: snapshots,
+			OutFile:   outFile,
+			ErrFile:   errFile,
+			ExitCode:  exitCode,
+			Warnings:  warnings,
+		}, nil
+
+	case "forget":
+		result, err := restic.ParseForgetOutput(string(outContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse forget output: %w", err)
+		}
+		return &restic.ForgetActionResult{
+			Name:         actionName,
+			Success:      success,
+			Snapshots:    result.Kept,
+			Reasons:      result.Reasons,
+			Removed:      result.Removed,
+			RemovedCount: result.RemovedCount,
+			OutFile:      outFile,
+			ErrFile:      errFile,
+			ExitCode:     exitCode,
+			Warnings:     warnings,
+		}, nil
+
+	case "prune":
+		result, err := restic.ParsePruneOutput(string(outContent), success)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prune output: %w", err)
+		}
+		return &restic.PruneActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+
+	case "stats":
+		result, err := restic.ParseStatsOutput(string(outContent), success)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse stats output: %w", err)
+		}
+		return &restic.StatsActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+
+	case "copy":
+		result, err := restic.ParseCopyOutput(string(outContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse copy output: %w", err)
+		}
+		return &restic.CopyActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+
+	case "diff":
+		result, err := restic.ParseDiffOutput(string(outContent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse diff output: %w", err)
+		}
+		return &restic.DiffActionResult{
+			Name:     actionName,
+			Success:  success,
+			Result:   result,
+			OutFile:  outFile,
+			ErrFile:  errFile,
+			ExitCode: exitCode,
+			Warnings: warnings,
+		}, nil
+	}
+	return nil, nil
+}
+
+// readWarnings returns the warnings in an action's error output. A missing
+// error file has no warnings.
+func readWarnings(errFile string) []string {
+	file, err := restic.OpenLogFile(errFile)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	warnings, err := restic.ParseWarnings(file)
+	if err != nil {
+		shared.Verbosef("Ignoring warnings in %s: %v\n", errFile, err)
+		return nil
+	}
+	return warnings
 }
 
 func NewNotifyEmailCmd() *cobra.Command {
-	var smtpHost, smtpUsername, smtpPassword, from, to string
-	var smtpPort int
+	var smtpHost, smtpUsername, smtpPassword, smtpPasswordFile, smtpEncryption, from, format, subjectPrefix, subjectTemplate, logDirGlob string
+	var smtpAuth, smtpToken, smtpTokenFile, fromName, replyTo, since, until, bodyTemplate, timezone, resultFile, attachReport string
+	var to, cc, bcc, columns []string
+	var smtpPort, smtpRetries, inlineErrorLines, maxSnapshotRows int
+	var smtpInsecure, inlineErrors, attachCompress, attachAll, hostnameInSubject, exitOnFailure, onFailureOnly, stdin bool
+	var attachCompressMinSize, maxAttachmentSize int64
+	var smtpRetryDelay, smtpTimeout time.Duration
 
 	cmd := &cobra.Command{
-		Use:   "notify-email [log-directory]",
+		Use:   "notify-email [log-directory...]",
 		Short: "Send an email notification",
-		Long:  `Send an email notification using the configured SMTP settings. Parses JSON logs from the specified directory and generates a summary.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Send an email notification using the configured SMTP settings. Parses JSON logs from the specified directories and generates a summary.
+With --stdin, the output of restic backup --json is read from stdin instead.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
+			// With --stdin, the backup output is piped in instead
+			var logDirs []string
+			if stdin {
+				if len(args) > 0 || logDirGlob != "" {
+					return WithExitCode(ExitConfigError, fmt.Errorf("--stdin cannot be combined with log directories"))
+				}
+			} else {
+				var err error
+				logDirs, err = resolveLogDirs(args, logDirGlob)
+				if err != nil {
+					return err
+				}
+			}
+
+			password, err := shared.ResolveSMTPPassword(smtpPassword, smtpPasswordFile)
+			if err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid email config: %w", err))
+			}
+			token, err := shared.ResolveSMTPToken(smtpToken, smtpTokenFile)
+			if err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid email config: %w", err))
+			}
+
 			emailConfig := &shared.NotifyEmailConfig{
-				SMTPHost:     smtpHost,
-				SMTPPort:     smtpPort,
-				SMTPUsername: smtpUsername,
-				SMTPPassword: smtpPassword,
-				From:         from,
-				To:           to,
+				SMTPHost:               smtpHost,
+				SMTPPort:               smtpPort,
+				SMTPUsername:           smtpUsername,
+				SMTPPassword:           password,
+				SMTPAuthMethod:         smtpAuth,
+				SMTPToken:              token,
+				SMTPEncryption:         smtpEncryption,
+				SMTPInsecureSkipVerify: smtpInsecure,
+				SMTPRetries:            smtpRetries,
+				SMTPRetryDelay:         smtpRetryDelay,
+				SMTPTimeout:            smtpTimeout,
+				From:                   from,
+				FromName:               fromName,
+				ReplyTo:                replyTo,
+				To:                     strings.Join(to, ","),
+				Cc:                     strings.Join(cc, ","),
+				Bcc:                    strings.Join(bcc, ","),
+				Format:                 format,
+				InlineErrors:           inlineErrors,
+				InlineErrorLines:       inlineErrorLines,
+				AttachCompress:         attachCompress,
+				AttachCompressMinSize:  attachCompressMinSize,
+				AttachAll:              attachAll,
+				AttachReport:           attachReport,
+				MaxAttachmentSize:      maxAttachmentSize,
+				MaxSnapshotRows:        maxSnapshotRows,
+				Columns:                columns,
+				Timezone:               timezone,
+				SubjectPrefix:          subjectPrefix,
+				SubjectTemplate:        subjectTemplate,
+				HostnameInSubject:      hostnameInSubject,
+				RepoName:               repoName,
+				ExitOnFailure:          exitOnFailure,
+				OnFailureOnly:          onFailureOnly,
+				ResultFile:             resultFile,
+				Stdin:                  stdin,
+				Since:                  since,
+				Until:                  until,
+				BodyTemplate:           bodyTemplate,
 			}
 
 			if err := shared.ValidateNotifyEmailConfig(emailConfig); err != nil {
-				return fmt.Errorf("invalid email config: %w", err)
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid email config: %w", err))
+			}
+			if _, err := parseSnapshotColumns(columns); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid email config: %w", err))
 			}
 
+			action := NewNotifyEmailAction(emailConfig)
+			if bodyTemplate != "" {
+				tmpl, err := parseBodyTemplate(bodyTemplate)
+				if err != nil {
+					return WithExitCode(ExitConfigError, fmt.Errorf("invalid email config: %w", err))
+				}
+				action.bodyTemplate = tmpl
+			}
+
+			// A failed backup or notification is not a usage error
+			cmd.SilenceUsage = true
+
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 
-			action := NewNotifyEmailAction(emailConfig)
-			return action.Execute(args, dryRun)
+			return action.Execute(logDirs, dryRun)
 		},
 	}
 
 	cmd.Flags().StringVar(&smtpHost, "smtp-host", "", "SMTP server hostname (required)")
 	cmd.Flags().IntVar(&smtpPort, "smtp-port", 587, "SMTP server port")
 	cmd.Flags().StringVar(&smtpUsername, "smtp-username", "", "SMTP username (required)")
-	cmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "SMTP password (required)")
+	cmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "SMTP password (required unless --smtp-password-file or "+shared.SMTPPasswordEnvVar+" is set)")
+	cmd.Flags().StringVar(&smtpPasswordFile, "smtp-password-file", "", "File containing the SMTP password")
+	cmd.Flags().StringVar(&smtpAuth, "smtp-auth", "", "SMTP auth method: plain, login or xoauth2 (default: negotiated with the server)")
+	cmd.Flags().StringVar(&smtpToken, "smtp-token", "", "OAuth2 access token for --smtp-auth xoauth2")
+	cmd.Flags().StringVar(&smtpTokenFile, "smtp-token-file", "", "File containing the OAuth2 access token")
+	cmd.Flags().StringVar(&smtpEncryption, "smtp-encryption", "", "SMTP encryption: none, starttls or tls (default: tls on port 465, starttls otherwise)")
+	cmd.Flags().BoolVar(&smtpInsecure, "smtp-insecure", false, "Skip TLS certificate verification for the SMTP server")
+	cmd.Flags().IntVar(&smtpRetries, "smtp-retries", 0, "Number of retries for transient SMTP failures")
+	cmd.Flags().DurationVar(&smtpRetryDelay, "smtp-retry-delay", 2*time.Second, "Initial delay between SMTP retries")
+	cmd.Flags().DurationVar(&smtpTimeout, "smtp-timeout", 30*time.Second, "Timeout for each SMTP send attempt")
 	cmd.Flags().StringVar(&from, "from", "", "From email address (required)")
-	cmd.Flags().StringVar(&to, "to", "", "To email address (required)")
+	cmd.Flags().StringVar(&fromName, "from-name", "", "Display name for the From address, e.g. \"Backup Bot\"")
+	cmd.Flags().StringVar(&replyTo, "reply-to", "", "Reply-To address, e.g. a distribution list for replies")
+	cmd.Flags().StringSliceVar(&to, "to", nil, "To email address (required, repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&cc, "cc", nil, "Cc email address (repeatable or comma-separated)")
+	cmd.Flags().StringSliceVar(&bcc, "bcc", nil, "Bcc email address (repeatable or comma-separated)")
+	cmd.Flags().StringVar(&format, "format", "text", "Email body format: text or html")
+	cmd.Flags().StringVar(&bodyTemplate, "body-template", "", "Go text/template file to render the plain-text email body with")
+	cmd.Flags().StringVar(&subjectPrefix, "subject-prefix", "", "Prefix for the email subject, e.g. [backup]")
+	cmd.Flags().StringVar(&subjectTemplate, "subject-template", "", "Go template for the email subject, e.g. '[BACKUP {{.Status}}] {{.Hostname}}: {{.Failed}} of {{.Total}} failed'")
+	cmd.Flags().BoolVar(&hostnameInSubject, "hostname-in-subject", false, "Append the machine hostname to the email subject")
+	cmd.Flags().BoolVar(&inlineErrors, "inline-errors", false, "Include the tail of each failed action's error output in the email body")
+	cmd.Flags().IntVar(&inlineErrorLines, "inline-error-lines", 20, "Number of error output lines to include with --inline-errors")
+	cmd.Flags().BoolVar(&attachCompress, "attach-compress", false, "Gzip attachments larger than --attach-compress-min-size before sending")
+	cmd.Flags().Int64Var(&attachCompressMinSize, "attach-compress-min-size", 1024*1024, "Minimum attachment size in bytes for --attach-compress")
+	cmd.Flags().IntVar(&maxSnapshotRows, "max-snapshot-rows", 0, "Show only the newest N snapshots per path in the snapshot table (0 shows all)")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "Ordered, comma-separated columns of the snapshot table from time, new, modified, total, added, totalsize, duration, host and id (default time,id,new,modified,total,added,totalsize)")
+	cmd.Flags().StringVar(&timezone, "timezone", "", "Show snapshot times in this IANA timezone, e.g. UTC or America/New_York (default: as recorded)")
+	cmd.Flags().BoolVar(&attachAll, "attach-all", false, "Attach the logs of all actions, not only of failed ones")
+	cmd.Flags().StringVar(&attachReport, "attach-report", "", "Also save the plain-text report to this file, e.g. next to the logs (written in dry-run mode too)")
+	cmd.Flags().Int64Var(&maxAttachmentSize, "max-attachment-size", 0, "Skip log attachments larger than this many bytes (0 disables the limit)")
+	cmd.Flags().BoolVar(&exitOnFailure, "exit-on-failure", false, "Exit with a non-zero code after notifying if the backup failed")
+	cmd.Flags().BoolVar(&onFailureOnly, "on-failure-only", false, "Only send the email if the backup failed")
+	cmd.Flags().StringVar(&resultFile, "result-file", "", "Write the overall result as JSON to this file, even if sending fails")
+	cmd.Flags().StringVar(&since, "since", "", "Only report actions run after this time, RFC3339 or relative like 24h")
+	cmd.Flags().StringVar(&until, "until", "", "Only report actions run before this time, RFC3339 or relative like 1h")
+	cmd.Flags().StringVar(&logDirGlob, "log-dir-glob", "", "Glob pattern of additional log directories to include in the report, e.g. '/var/log/restic-kit/2024-*'")
+	cmd.Flags().BoolVar(&stdin, "stdin", false, "Report a single backup from restic backup --json output piped to stdin instead of log directories")
 
 	cmd.MarkFlagRequired("smtp-host")
 	cmd.MarkFlagRequired("smtp-username")
-	cmd.MarkFlagRequired("smtp-password")
 	cmd.MarkFlagRequired("from")
 	cmd.MarkFlagRequired("to")
 
@@ -2,10 +2,17 @@ package actions
 
 import (
 	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"restic-kit/restic"
 	"restic-kit/shared"
 )
 
@@ -201,6 +208,44 @@ func TestValidateNotifyEmailConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "to is required",
 		},
+		{
+			name: "multiple recipients with cc and bcc",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+				From:         "from@example.com",
+				To:           "one@example.com, Two <two@example.com>",
+				Cc:           "cc@example.com",
+				Bcc:          "bcc1@example.com,bcc2@example.com",
+			},
+			wantErr: false,
+		},
+		{
+			name: "no recipients in list",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+				From:         "from@example.com",
+				To:           " , ",
+			},
+			wantErr: true,
+			errMsg:  "at least one recipient is required",
+		},
+		{
+			name: "invalid cc address",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+				From:         "from@example.com",
+				To:           "to@example.com",
+				Cc:           "not-an-address",
+			},
+			wantErr: true,
+			errMsg:  `invalid email address "not-an-address": mail: missing '@' or angle-addr`,
+		},
 		{
 			name: "missing smtp-username",
 			config: &shared.NotifyEmailConfig{
@@ -212,6 +257,45 @@ func TestValidateNotifyEmailConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "smtp-username is required",
 		},
+		{
+			name: "invalid format",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+				From:         "from@example.com",
+				To:           "to@example.com",
+				Format:       "markdown",
+			},
+			wantErr: true,
+			errMsg:  "format must be either text or html",
+		},
+		{
+			name: "invalid smtp-encryption",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:       "smtp.example.com",
+				SMTPUsername:   "user",
+				SMTPPassword:   "pass",
+				SMTPEncryption: "ssl",
+				From:           "from@example.com",
+				To:             "to@example.com",
+			},
+			wantErr: true,
+			errMsg:  "smtp-encryption must be one of none, starttls or tls",
+		},
+		{
+			name: "negative smtp-retries",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPUsername: "user",
+				SMTPPassword: "pass",
+				SMTPRetries:  -1,
+				From:         "from@example.com",
+				To:           "to@example.com",
+			},
+			wantErr: true,
+			errMsg:  "smtp-retries must be non-negative",
+		},
 		{
 			name: "missing smtp-password",
 			config: &shared.NotifyEmailConfig{
@@ -223,6 +307,44 @@ func TestValidateNotifyEmailConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "smtp-password is required",
 		},
+		{
+			name: "xoauth2 with token and no password",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:       "smtp.example.com",
+				SMTPUsername:   "user",
+				SMTPAuthMethod: "xoauth2",
+				SMTPToken:      "token",
+				From:           "from@example.com",
+				To:             "to@example.com",
+			},
+			wantErr: false,
+		},
+		{
+			name: "xoauth2 without token",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:       "smtp.example.com",
+				SMTPUsername:   "user",
+				SMTPPassword:   "pass",
+				SMTPAuthMethod: "xoauth2",
+				From:           "from@example.com",
+				To:             "to@example.com",
+			},
+			wantErr: true,
+			errMsg:  "smtp-token is required with smtp-auth xoauth2",
+		},
+		{
+			name: "invalid smtp-auth",
+			config: &shared.NotifyEmailConfig{
+				SMTPHost:       "smtp.example.com",
+				SMTPUsername:   "user",
+				SMTPPassword:   "pass",
+				SMTPAuthMethod: "cram-md5",
+				From:           "from@example.com",
+				To:             "to@example.com",
+			},
+			wantErr: true,
+			errMsg:  "smtp-auth must be one of plain, login or xoauth2",
+		},
 	}
 
 	for _, tt := range tests {
@@ -237,3 +359,1294 @@ func TestValidateNotifyEmailConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateHTMLBodyFromActions(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{
+			Name:    "etc<1>",
+			Success: true,
+			Result:  &restic.BackupResult{FilesNew: 3, DataAdded: 2048},
+		},
+		&restic.CheckActionResult{
+			Name:    "check",
+			Success: false,
+			Result:  &restic.CheckResult{NumErrors: 2},
+		},
+		&restic.SnapshotsActionResult{
+			Name:    "snapshots",
+			Success: true,
+			Snapshots: []restic.Snapshot{
+				{Time: "2025-10-30T23:34:19.35394226+01:00", Paths: []string{"/etc"}},
+			},
+		},
+	}
+
+	body := generateHTMLBodyFromActions(actions, false, nil, 0, nil, nil)
+
+	expectedStrings := []string{
+		"Overall Status:",
+		"FAILURE",
+		"backup etc&lt;1&gt;",
+		"<th>Date &amp; Time</th>",
+		"<h4>Path: /etc</h4>",
+		"<td>2025-10-30 23:34</td>",
+		"#c62828",
+		"#2e7d32",
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected HTML body to contain '%s', but it didn't.\nActual body:\n%s", expected, body)
+		}
+	}
+}
+
+func TestNewDialerEncryption(t *testing.T) {
+	tests := []struct {
+		name       string
+		port       int
+		encryption string
+		insecure   bool
+		wantSSL    bool
+	}{
+		{name: "default on 587 uses starttls", port: 587, wantSSL: false},
+		{name: "default on 465 uses implicit tls", port: 465, wantSSL: true},
+		{name: "explicit tls", port: 2525, encryption: "tls", wantSSL: true},
+		{name: "explicit starttls on 465", port: 465, encryption: "starttls", wantSSL: false},
+		{name: "none", port: 25, encryption: "none", wantSSL: false},
+		{name: "insecure", port: 587, encryption: "starttls", insecure: true, wantSSL: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &shared.NotifyEmailConfig{
+				SMTPHost:               "smtp.example.com",
+				SMTPPort:               tt.port,
+				SMTPUsername:           "user",
+				SMTPPassword:           "pass",
+				SMTPEncryption:         tt.encryption,
+				SMTPInsecureSkipVerify: tt.insecure,
+				From:                   "from@example.com",
+				To:                     "to@example.com",
+			}
+			if err := shared.ValidateNotifyEmailConfig(cfg); err != nil {
+				t.Fatalf("ValidateNotifyEmailConfig() error = %v", err)
+			}
+
+			d := shared.NewDialer(cfg)
+			if d.SSL != tt.wantSSL {
+				t.Errorf("Expected SSL=%v, got %v", tt.wantSSL, d.SSL)
+			}
+			if tt.insecure && (d.TLSConfig == nil || !d.TLSConfig.InsecureSkipVerify) {
+				t.Errorf("Expected TLSConfig with InsecureSkipVerify, got %+v", d.TLSConfig)
+			}
+		})
+	}
+}
+
+func TestSplitAddresses(t *testing.T) {
+	got := shared.SplitAddresses(" one@example.com,, two@example.com ,")
+	want := []string{"one@example.com", "two@example.com"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestAnalyzeBackupResultsPrune(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logs-prune*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	pruneOut := `loading indexes...
+{"message_type":"status","percent_done":0.5}
+{"message_type":"summary","tobrepack":3,"blobs_removed":120,"packs_deleted":4,"size_freed":5242880}`
+
+	os.WriteFile(tmpDir+"/prune.exitcode", []byte("0"), 0644)
+	os.WriteFile(tmpDir+"/prune.out", []byte(pruneOut), 0644)
+
+	actions, overallSuccess, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !overallSuccess {
+		t.Error("Expected overall success")
+	}
+	if len(actions) != 1 {
+		t.Fatalf("Expected 1 action, got %d", len(actions))
+	}
+
+	prune, ok := actions[0].(*restic.PruneActionResult)
+	if !ok {
+		t.Fatalf("Expected PruneActionResult, got %T", actions[0])
+	}
+	if prune.Result.BlobsRemoved != 120 || prune.Result.PacksDeleted != 4 || prune.Result.ToBeRepacked != 3 {
+		t.Errorf("Unexpected prune result: %+v", prune.Result)
+	}
+
+	body := generateBodyFromActions(actions, overallSuccess, nil, 0, nil, nil)
+	if !strings.Contains(body, "✅ prune") || !strings.Contains(body, "5.0 MB freed") {
+		t.Errorf("Expected prune summary in body, got:\n%s", body)
+	}
+}
+
+func TestResolveSMTPPassword(t *testing.T) {
+	tmpDir := t.TempDir()
+	passwordFile := filepath.Join(tmpDir, "password")
+	os.WriteFile(passwordFile, []byte("from-file\n"), 0600)
+
+	t.Setenv(shared.SMTPPasswordEnvVar, "from-env")
+
+	tests := []struct {
+		name     string
+		password string
+		file     string
+		want     string
+	}{
+		{name: "explicit flag wins", password: "from-flag", file: passwordFile, want: "from-flag"},
+		{name: "file before env", file: passwordFile, want: "from-file"},
+		{name: "env fallback", want: "from-env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shared.ResolveSMTPPassword(tt.password, tt.file)
+			if err != nil {
+				t.Fatalf("ResolveSMTPPassword() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveSMTPPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := shared.ResolveSMTPPassword("", filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("Expected error for missing password file, got nil")
+	}
+}
+
+func TestNotifyEmailActionStdin(t *testing.T) {
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:     "localhost",
+		SMTPUsername: "test",
+		SMTPPassword: "test",
+		From:         "from@example.com",
+		To:           "to@example.com",
+		Stdin:        true,
+	}
+
+	action := NewNotifyEmailAction(emailConfig)
+	action.stdin = strings.NewReader(`{"message_type":"status","percent_done":0.5}
+{"message_type":"summary","files_new":7,"total_files_processed":12,"data_added":2048}
+`)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := action.Execute(nil, true)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "Overall Status: SUCCESS") || !strings.Contains(output, "✅ backup stdin") {
+		t.Errorf("Expected successful backup read from stdin, got:\n%s", output)
+	}
+
+	// Log directories are required without --stdin
+	emailConfig.Stdin = false
+	if err := NewNotifyEmailAction(emailConfig).Execute(nil, true); err == nil {
+		t.Error("Expected error without log directories")
+	}
+}
+
+func TestNotifyEmailActionAttachReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","files_new":4}`), 0644)
+
+	reportFile := filepath.Join(tmpDir, "report.txt")
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:     "localhost",
+		SMTPUsername: "test",
+		SMTPPassword: "test",
+		From:         "from@example.com",
+		To:           "to@example.com",
+		AttachReport: reportFile,
+	}
+
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	err := NewNotifyEmailAction(emailConfig).Execute([]string{tmpDir}, true)
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("Expected no error in dry-run mode, got %v", err)
+	}
+
+	// The would-be body is written in dry-run mode too
+	content, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("Expected report file: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "Overall Status: FAILURE") || !strings.Contains(string(content), "backup home") {
+		t.Errorf("Expected the report body, got:\n%s", content)
+	}
+}
+
+func TestAnalyzeBackupResultsMissingOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// The backup crashed before writing any output, the check ran normally
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte("no errors were found\n"), 0644)
+
+	actions, overallSuccess, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if overallSuccess {
+		t.Error("Expected an action without output to fail the report")
+	}
+	if len(actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(actions))
+	}
+
+	var missing *restic.MissingOutputActionResult
+	for _, action := range actions {
+		if m, ok := action.(*restic.MissingOutputActionResult); ok {
+			missing = m
+		}
+	}
+	if missing == nil || missing.Type != "backup" || missing.Name != "home" || missing.IsSuccess() {
+		t.Fatalf("Expected failed backup without output, got %+v", actions)
+	}
+	if actionTypeOf(missing) != "backup" {
+		t.Errorf("actionTypeOf() = %q, want backup", actionTypeOf(missing))
+	}
+
+	body := generateBodyFromActions(actions, overallSuccess, nil, 0, nil, nil)
+	if !strings.Contains(body, "❌ backup home (no output captured)\n") {
+		t.Errorf("Expected missing output in body, got:\n%s", body)
+	}
+	if !strings.Contains(body, "✅ check") {
+		t.Errorf("Expected the remaining actions to be reported, got:\n%s", body)
+	}
+	htmlBody := generateHTMLBodyFromActions(actions, overallSuccess, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "backup home (no output captured)</h3>") {
+		t.Errorf("Expected missing output in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestAnalyzeBackupResultsTextOutput(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logs-text*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	backupOut := `open repository
+using parent snapshot 1a2b3c4d
+
+Files:           5 new,     2 changed,   100 unmodified
+Dirs:            1 new,     0 changed,    50 unmodified
+Added to the repository: 1.500 MiB (512.000 KiB stored)
+
+processed 107 files, 2.000 GiB in 1:05
+snapshot 9f8e7d6c saved`
+	checkOut := `using temporary cache in /tmp/restic-check-cache
+create exclusive lock for repository
+load indexes
+check all packs
+check snapshots, trees and blobs
+no errors were found`
+
+	os.WriteFile(tmpDir+"/backup.etc.exitcode", []byte("0"), 0644)
+	os.WriteFile(tmpDir+"/backup.etc.out", []byte(backupOut), 0644)
+	os.WriteFile(tmpDir+"/check.exitcode", []byte("0"), 0644)
+	os.WriteFile(tmpDir+"/check.out", []byte(checkOut), 0644)
+
+	actions, _, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected text output to be parsed, got error: %v", err)
+	}
+
+	for _, action := range actions {
+		switch a := action.(type) {
+		case *restic.BackupActionResult:
+			r := a.Result
+			if r.FilesNew != 5 || r.FilesChanged != 2 || r.FilesUnmodified != 100 {
+				t.Errorf("Unexpected file counts: %+v", r)
+			}
+			if r.DirsNew != 1 || r.DirsUnmodified != 50 {
+				t.Errorf("Unexpected dir counts: %+v", r)
+			}
+			if r.DataAdded != 1572864 || r.DataAddedPacked != 524288 {
+				t.Errorf("Unexpected data added: %d (%d packed)", r.DataAdded, r.DataAddedPacked)
+			}
+			if r.TotalFilesProcessed != 107 || r.TotalBytesProcessed != 2147483648 || r.TotalDuration != 65 {
+				t.Errorf("Unexpected totals: %+v", r)
+			}
+		case *restic.CheckActionResult:
+			if a.Result.NumErrors != 0 {
+				t.Errorf("Expected no check errors, got %d", a.Result.NumErrors)
+			}
+		}
+	}
+}
+
+func TestInlineErrorExcerpts(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "logs-inline*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var errContent strings.Builder
+	for i := 1; i <= 30; i++ {
+		errContent.WriteString("error line " + strconv.Itoa(i) + "\n")
+	}
+	errFile := filepath.Join(tmpDir, "backup.home.err")
+	os.WriteFile(errFile, []byte(errContent.String()), 0644)
+
+	excerpt, err := readErrorExcerpt(errFile, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if excerpt != "error line 28\nerror line 29\nerror line 30" {
+		t.Errorf("Unexpected excerpt: %q", excerpt)
+	}
+
+	// Files larger than the byte cap are only read from the end
+	bigFile := filepath.Join(tmpDir, "check.err")
+	os.WriteFile(bigFile, []byte(strings.Repeat("x", 2*maxInlineErrorBytes)+"\nlast line\n"), 0644)
+	excerpt, err = readErrorExcerpt(bigFile, 20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if excerpt != "last line" {
+		t.Errorf("Unexpected excerpt for large file: %q", excerpt)
+	}
+
+	backup := &restic.BackupActionResult{Name: "home", Success: false, ErrFile: errFile}
+	excerpts := map[restic.ActionResult]string{backup: "Fatal: unable to open repository"}
+
+	body := generateBodyFromActions([]restic.ActionResult{backup}, false, excerpts, 0, nil, nil)
+	if !strings.Contains(body, "  Error output:\n    Fatal: unable to open repository\n") {
+		t.Errorf("Expected error excerpt in text body, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions([]restic.ActionResult{backup}, false, excerpts, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<pre style=\"background-color:#f5f5f5;padding:8px;\">Fatal: unable to open repository</pre>") {
+		t.Errorf("Expected error excerpt in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGzipToTempFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "backup.home.out")
+	content := strings.Repeat("restic output line\n", 1000)
+	if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzFile, err := gzipToTempFile(src)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer os.Remove(gzFile)
+
+	if !strings.HasPrefix(filepath.Base(gzFile), "backup.home.out.") || !strings.HasSuffix(gzFile, ".gz") {
+		t.Errorf("Unexpected compressed file name: %s", gzFile)
+	}
+
+	f, err := os.Open(gzFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decompressed) != content {
+		t.Error("Decompressed content does not match the original")
+	}
+}
+
+func TestNotifyEmailActionDryRunAttachCompress(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(strings.Repeat("x", 2048)), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.err"), []byte("small"), 0644)
+
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:              "localhost",
+		SMTPUsername:          "test",
+		SMTPPassword:          "test",
+		From:                  "from@example.com",
+		To:                    "to@example.com",
+		AttachCompress:        true,
+		AttachCompressMinSize: 1024,
+	}
+
+	// Capture stdout for validation
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewNotifyEmailAction(emailConfig).Execute([]string{tmpDir}, true)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Errorf("Expected no error in dry-run mode, got %v", err)
+	}
+	if !strings.Contains(output, "DRY RUN: Would compress attachment: "+filepath.Join(tmpDir, "backup.home.out")) {
+		t.Errorf("Expected large attachment to be compressed, got:\n%s", output)
+	}
+	if strings.Contains(output, "compress attachment: "+filepath.Join(tmpDir, "backup.home.err")) {
+		t.Errorf("Expected small attachment not to be compressed, got:\n%s", output)
+	}
+}
+
+func TestNotifyEmailActionOnFailureOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:      "localhost",
+		SMTPUsername:  "test",
+		SMTPPassword:  "test",
+		From:          "from@example.com",
+		To:            "to@example.com",
+		OnFailureOnly: true,
+	}
+
+	run := func() string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := NewNotifyEmailAction(emailConfig).Execute([]string{tmpDir}, true)
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		if err != nil {
+			t.Fatalf("Expected no error in dry-run mode, got %v", err)
+		}
+		return buf.String()
+	}
+
+	output := run()
+	if strings.Contains(output, "Would send email") || !strings.Contains(output, "no notification sent") {
+		t.Errorf("Expected no email for a successful backup, got:\n%s", output)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	if output := run(); !strings.Contains(output, "DRY RUN: Would send email") {
+		t.Errorf("Expected email for a failed backup, got:\n%s", output)
+	}
+}
+
+func TestAnalyzeBackupResultsStats(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","data_added":4096,"data_added_packed":2097152}`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "stats.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "stats.out"), []byte(`{"total_size":10737418240,"total_file_count":1500,"total_blob_count":3000,"snapshots_count":42}`), 0644)
+
+	actions, _, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var stats *restic.StatsActionResult
+	for _, action := range actions {
+		if s, ok := action.(*restic.StatsActionResult); ok {
+			stats = s
+		}
+	}
+	if stats == nil {
+		t.Fatal("Expected a StatsActionResult")
+	}
+	if stats.Result.TotalSize != 10737418240 || stats.Result.SnapshotsCount != 42 || stats.Result.TotalFileCount != 1500 {
+		t.Errorf("Unexpected stats result: %+v", stats.Result)
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	expectedStrings := []string{
+		"✅ stats",
+		"Repository size: 10.0 GB (+2.0 MB from this run)",
+		"42 snapshots, 1500 files, 3000 blobs",
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected body to contain '%s', but it didn't.\nActual body:\n%s", expected, body)
+		}
+	}
+}
+
+func TestGenerateBodyForgetReasons(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.ForgetActionResult{
+			Name:         "forget",
+			Success:      true,
+			RemovedCount: 3,
+			Reasons: []restic.ForgetReason{
+				{
+					Snapshot: restic.Snapshot{ID: "abc123456789", Time: "2025-10-30T23:34:19.35394226+01:00"},
+					Matches:  []string{"last snapshot", "daily snapshot"},
+				},
+				{
+					Snapshot: restic.Snapshot{ID: "def456789012", ShortID: "def45678", Time: "2025-10-29T23:34:19.35394226+01:00"},
+					Matches:  []string{"daily snapshot"},
+				},
+			},
+		},
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	expected := "  3 snapshots removed\n" +
+		"  Kept snapshots by rule:\n" +
+		"    last snapshot: abc12345 (2025-10-30 23:34)\n" +
+		"    daily snapshot: abc12345 (2025-10-30 23:34), def45678 (2025-10-29 23:34)\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected forget reasons in body, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<b>daily snapshot</b></td><td>abc12345 (2025-10-30 23:34), def45678 (2025-10-29 23:34)</td>") {
+		t.Errorf("Expected forget reasons in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGenerateBodyForgetRemovedSnapshots(t *testing.T) {
+	var removed []restic.Snapshot
+	for i := 1; i <= 12; i++ {
+		removed = append(removed, restic.Snapshot{
+			ID:    fmt.Sprintf("%02d3456789abc", i),
+			Time:  fmt.Sprintf("2025-10-%02dT23:34:19.35394226+01:00", i),
+			Paths: []string{"/home", "/etc"},
+		})
+	}
+	actions := []restic.ActionResult{
+		&restic.ForgetActionResult{Name: "forget", Success: true, Removed: removed, RemovedCount: len(removed)},
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	expected := "  12 snapshots removed\n" +
+		"    - 01345678 2025-10-01 23:34 /home, /etc\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected removed snapshots in body, got:\n%s", body)
+	}
+	if !strings.Contains(body, "    - 10345678 2025-10-10 23:34 /home, /etc\n    ... and 2 more\n") {
+		t.Errorf("Expected capped removed snapshots in body, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<li>01345678 2025-10-01 23:34 /home, /etc</li>") || !strings.Contains(htmlBody, "<li>... and 2 more</li>") {
+		t.Errorf("Expected removed snapshots in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGenerateBodySnapshotColumns(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.SnapshotsActionResult{
+			Name:    "snapshots",
+			Success: true,
+			Snapshots: []restic.Snapshot{
+				{
+					Time:     "2025-10-30T23:00:00Z",
+					Paths:    []string{"/home"},
+					Hostname: "nas",
+					ShortID:  "abcd1234",
+					Summary: restic.BackupSummary{
+						BackupStart: "2025-10-30T23:00:00Z",
+						BackupEnd:   "2025-10-30T23:01:30Z",
+						FilesNew:    3,
+					},
+				},
+			},
+		},
+	}
+
+	// The default columns keep the original table
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(body, "  Date & Time          | Snapshot |      New | Modified |  Total Files |   Added Size |   Total Size\n") {
+		t.Errorf("Expected default snapshot table header, got:\n%s", body)
+	}
+
+	columns, err := parseSnapshotColumns([]string{"host", "time", "duration"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = generateBodyFromActions(actions, true, nil, 0, nil, columns)
+	if !strings.Contains(body, "  Host         | Date & Time          | Duration\n") {
+		t.Errorf("Expected configured header, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  nas          | 2025-10-30 23:00     |    1m30s\n") {
+		t.Errorf("Expected configured row, got:\n%s", body)
+	}
+	if strings.Contains(body, "abcd1234") {
+		t.Errorf("Expected snapshot ID column to be left out, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, true, nil, 0, nil, columns)
+	if !strings.Contains(htmlBody, "<tr><th>Host</th><th>Date &amp; Time</th><th>Duration</th></tr>") ||
+		!strings.Contains(htmlBody, "<tr><td>nas</td><td>2025-10-30 23:00</td><td align=\"right\">1m30s</td></tr>") {
+		t.Errorf("Expected configured HTML table, got:\n%s", htmlBody)
+	}
+
+	if _, err := parseSnapshotColumns([]string{"time", "size"}); err == nil {
+		t.Error("Expected unknown column to be rejected")
+	}
+}
+
+func TestFormatSnapshotTime(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*60*60)
+	tests := []struct {
+		time string
+		loc  *time.Location
+		want string
+	}{
+		{"2025-10-30T23:34:19.35394226+01:00", nil, "2025-10-30 23:34"},
+		{"2025-10-30T23:34:19.35394226+01:00", time.UTC, "2025-10-30 22:34"},
+		{"2025-10-30T23:34:19.35394226+01:00", newYork, "2025-10-30 17:34"},
+		{"garbage", time.UTC, "garbage"},
+	}
+	for _, tt := range tests {
+		if got := formatSnapshotTime(tt.time, tt.loc); got != tt.want {
+			t.Errorf("formatSnapshotTime(%q, %v) = %q, want %q", tt.time, tt.loc, got, tt.want)
+		}
+	}
+
+	actions := []restic.ActionResult{
+		&restic.ForgetActionResult{
+			Name:    "forget",
+			Success: true,
+			Reasons: []restic.ForgetReason{
+				{Snapshot: restic.Snapshot{ID: "abc123456789", Time: "2025-10-30T23:34:19+01:00"}, Matches: []string{"last snapshot"}},
+			},
+		},
+	}
+	body := generateBodyFromActions(actions, true, nil, 0, time.UTC, nil)
+	if !strings.Contains(body, "last snapshot: abc12345 (2025-10-30 22:34)") {
+		t.Errorf("Expected converted time in body, got:\n%s", body)
+	}
+}
+
+func TestAnalyzeBackupResultsForgetGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	forgetOut := `[{"host":"server","paths":["/etc"],"keep":[{"id":"k1"}],"remove":[{"id":"r1"},{"id":"r2"}]},` +
+		`{"host":"server","paths":["/home"],"keep":[{"id":"k2"}],"remove":[{"id":"r3"},{"id":"r4"},{"id":"r5"}]}]`
+	os.WriteFile(filepath.Join(tmpDir, "forget.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "forget.out"), []byte(forgetOut), 0644)
+
+	actions, overallSuccess, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	body := generateBodyFromActions(actions, overallSuccess, nil, 0, nil, nil)
+	if !strings.Contains(body, "✅ forget\n  5 snapshots removed\n") {
+		t.Errorf("Expected removed count across all groups in body, got:\n%s", body)
+	}
+}
+
+func TestGenerateBodyBackupTotals(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{
+			Name:    "etc",
+			Success: true,
+			Result:  &restic.BackupResult{FilesNew: 2, FilesChanged: 1, DataAdded: 1024 * 1024, TotalBytesProcessed: 10 * 1024 * 1024},
+		},
+		&restic.BackupActionResult{
+			Name:    "home",
+			Success: true,
+			Result:  &restic.BackupResult{FilesNew: 3, FilesChanged: 4, DataAdded: 2 * 1024 * 1024, TotalBytesProcessed: 20 * 1024 * 1024},
+		},
+		&restic.CheckActionResult{Name: "check", Success: true, Result: &restic.CheckResult{}},
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	expected := "Overall Status: SUCCESS\n\n" +
+		"Total data added: 3.0 MB across 2 backups\n" +
+		"Total bytes processed: 30.0 MB\n" +
+		"Total files: 5 new, 5 changed\n\n"
+	if !strings.HasPrefix(body, expected) {
+		t.Errorf("Expected totals under the overall status, got:\n%s", body)
+	}
+
+	// Reports without backups have no totals
+	body = generateBodyFromActions(actions[2:], true, nil, 0, nil, nil)
+	if strings.Contains(body, "Total data added") {
+		t.Errorf("Expected no totals without backups, got:\n%s", body)
+	}
+}
+
+func TestGenerateBodyCheckErrors(t *testing.T) {
+	var checkErrors []string
+	for i := 1; i <= 7; i++ {
+		checkErrors = append(checkErrors, "pack "+strconv.Itoa(i)+": not referenced in any index")
+	}
+	actions := []restic.ActionResult{
+		&restic.CheckActionResult{
+			Name:    "check",
+			Success: false,
+			Result:  &restic.CheckResult{NumErrors: 7, Errors: checkErrors},
+		},
+	}
+
+	body := generateBodyFromActions(actions, false, nil, 0, nil, nil)
+	expected := "❌ check\n  FAILED\n" +
+		"  - pack 1: not referenced in any index\n" +
+		"  - pack 2: not referenced in any index\n" +
+		"  - pack 3: not referenced in any index\n" +
+		"  - pack 4: not referenced in any index\n" +
+		"  - pack 5: not referenced in any index\n" +
+		"  ... and 2 more errors\n\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected capped check errors in body, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, false, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<li>pack 1: not referenced in any index</li>") || !strings.Contains(htmlBody, "<li>... and 2 more errors</li>") {
+		t.Errorf("Expected check errors in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGenerateBodyDegradedBackup(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{
+			Name:    "home",
+			Success: false,
+			Result: &restic.BackupResult{
+				FilesNew: 3,
+				Errors:   []string{"/data/broken: read failed"},
+				Degraded: true,
+			},
+		},
+	}
+
+	body := generateBodyFromActions(actions, false, nil, 0, nil, nil)
+	if !strings.Contains(body, "❌ backup home (completed with errors)\n") {
+		t.Errorf("Expected degraded backup header, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  Files: 3 new") {
+		t.Errorf("Expected stats of degraded backup, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  Errors:\n  - /data/broken: read failed\n") {
+		t.Errorf("Expected backup errors in body, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, false, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "home (completed with errors)</h3>") || !strings.Contains(htmlBody, "<li>/data/broken: read failed</li>") {
+		t.Errorf("Expected degraded backup in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGenerateBodySnapshotTags(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.SnapshotsActionResult{
+			Name:    "snapshots",
+			Success: true,
+			Snapshots: []restic.Snapshot{
+				{Time: "2025-01-01T00:00:00Z", Paths: []string{"/home"}, Tags: []string{"nightly"}},
+				{Time: "2025-01-02T00:00:00Z", Paths: []string{"/home"}, Tags: []string{"manual", "nightly"}},
+				{Time: "2025-01-02T00:00:00Z", Paths: []string{"/etc"}},
+			},
+		},
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(body, "  Path: /home\n  Tags: manual, nightly\n") {
+		t.Errorf("Expected tags line for /home, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  Path: /etc\n  Snapshots: 1\n") {
+		t.Errorf("Expected no tags line for untagged path, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<p>Tags: manual, nightly</p>") {
+		t.Errorf("Expected tags in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestAnalyzeBackupResultsCompressedLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeGzip := func(name, content string) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write([]byte(content))
+		gz.Close()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Fully compressed backup logs
+	writeGzip("backup.etc.exitcode.gz", "1\n")
+	writeGzip("backup.etc.out.gz", `{"message_type":"summary","files_new":3}`)
+	writeGzip("backup.etc.err.gz", "error: permission denied\n")
+
+	// Plain exit code with a compressed output file
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	writeGzip("check.out.gz", `{"message_type":"summary","num_errors":0}`)
+
+	actions, success, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if success {
+		t.Error("Expected overall failure")
+	}
+	if len(actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(actions))
+	}
+
+	var backup *restic.BackupActionResult
+	for _, action := range actions {
+		if b, ok := action.(*restic.BackupActionResult); ok {
+			backup = b
+		}
+	}
+	if backup == nil || backup.Name != "etc" || backup.Success || backup.Result.FilesNew != 3 {
+		t.Fatalf("Unexpected backup result: %+v", backup)
+	}
+	if backup.ErrFile != filepath.Join(tmpDir, "backup.etc.err.gz") {
+		t.Errorf("Expected compressed err file, got %s", backup.ErrFile)
+	}
+
+	excerpt, err := readErrorExcerpt(backup.ErrFile, 20)
+	if err != nil || excerpt != "error: permission denied" {
+		t.Errorf("Unexpected excerpt %q (%v)", excerpt, err)
+	}
+}
+
+func TestReportTitle(t *testing.T) {
+	if got := reportTitle("", true); got != "Backup Report: SUCCESS" {
+		t.Errorf("reportTitle() = %q", got)
+	}
+	if got := reportTitle("offsite", false); got != "Backup Report (offsite): FAILURE" {
+		t.Errorf("reportTitle() = %q", got)
+	}
+}
+
+func TestSnapshotShortID(t *testing.T) {
+	tests := []struct {
+		snap restic.Snapshot
+		want string
+	}{
+		{restic.Snapshot{ID: "0123456789abcdef", ShortID: "01234567"}, "01234567"},
+		{restic.Snapshot{ID: "0123456789abcdef"}, "01234567"},
+		{restic.Snapshot{ID: "abc"}, "abc"},
+	}
+	for _, tt := range tests {
+		if got := snapshotShortID(tt.snap); got != tt.want {
+			t.Errorf("snapshotShortID(%+v) = %q, want %q", tt.snap, got, tt.want)
+		}
+	}
+}
+
+func TestDiffSummary(t *testing.T) {
+	action := &restic.DiffActionResult{
+		Name:    "diff",
+		Success: true,
+		Result: &restic.DiffResult{
+			SourceSnapshot: "1111111111111111",
+			TargetSnapshot: "2222222222222222",
+			FilesAdded:     3,
+			FilesRemoved:   1,
+			FilesChanged:   2,
+			SizeDelta:      -2048,
+		},
+	}
+	want := "11111111..22222222: 3 added, 1 removed, 2 changed files, -2.0 KB"
+	if got := diffSummary(action); got != want {
+		t.Errorf("diffSummary() = %q, want %q", got, want)
+	}
+
+	body := generateBodyFromActions([]restic.ActionResult{action}, true, nil, 0, nil, nil)
+	if !strings.Contains(body, "✅ diff\n  "+want) {
+		t.Errorf("Expected diff summary in body, got:\n%s", body)
+	}
+}
+
+func TestCopySummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "copy.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "copy.out"), []byte(`{"message_type":"summary","data_added":1048576}
+{"message_type":"summary","data_added":1048576}
+skipping snapshot 9988aabb, was already copied to snapshot 11223344
+`), 0644)
+
+	actions, _, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("Expected 1 action, got %d", len(actions))
+	}
+	action, ok := actions[0].(*restic.CopyActionResult)
+	if !ok {
+		t.Fatalf("Expected CopyActionResult, got %T", actions[0])
+	}
+
+	want := "2 snapshots copied (2.0 MB), 1 already present"
+	if got := copySummary(action); got != want {
+		t.Errorf("copySummary() = %q, want %q", got, want)
+	}
+
+	body := generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(body, "✅ copy\n  "+want) {
+		t.Errorf("Expected copy summary in body, got:\n%s", body)
+	}
+}
+
+func TestAnalyzeBackupResultsOrderingConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// More logs than parse workers, written with mtimes in reverse name order
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 3*maxParseWorkers; i++ {
+		name := fmt.Sprintf("%s/backup.path%02d", tmpDir, i)
+		os.WriteFile(name+".exitcode", []byte("0"), 0644)
+		os.WriteFile(name+".out", []byte(`{"message_type":"summary","files_new":1}`), 0644)
+		mtime := base.Add(-time.Duration(i) * time.Minute)
+		os.Chtimes(name+".exitcode", mtime, mtime)
+	}
+
+	actions, _, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(actions) != 3*maxParseWorkers {
+		t.Fatalf("Expected %d actions, got %d", 3*maxParseWorkers, len(actions))
+	}
+	for i, action := range actions {
+		want := fmt.Sprintf("path%02d", 3*maxParseWorkers-1-i)
+		if action.GetActionName() != want {
+			t.Errorf("Action %d: expected %s, got %s", i, want, action.GetActionName())
+		}
+	}
+
+	// An error in any of the logs is returned
+	os.WriteFile(tmpDir+"/backup.path05.out", []byte(`{"message_type":`), 0644)
+	if _, _, err := analyzeBackupResults(tmpDir); err == nil || !strings.Contains(err.Error(), "path05") {
+		t.Errorf("Expected parse error for path05, got %v", err)
+	}
+}
+
+func TestAnalyzeBackupResultsMultipleDirs(t *testing.T) {
+	root := t.TempDir()
+	morning := filepath.Join(root, "2025-10-30-morning")
+	evening := filepath.Join(root, "2025-10-30-evening")
+	os.Mkdir(morning, 0755)
+	os.Mkdir(evening, 0755)
+
+	base := time.Now().Add(-time.Hour)
+	for i, dir := range []string{morning, evening} {
+		for j, name := range []string{"backup.home", "backup.etc"} {
+			path := filepath.Join(dir, name)
+			os.WriteFile(path+".exitcode", []byte("0"), 0644)
+			os.WriteFile(path+".out", []byte(`{"message_type":"summary","files_new":1}`), 0644)
+			mtime := base.Add(time.Duration(2*i+j) * time.Minute)
+			os.Chtimes(path+".exitcode", mtime, mtime)
+		}
+	}
+
+	logDirs, err := resolveLogDirs(nil, filepath.Join(root, "2025-10-30-*"))
+	if err != nil {
+		t.Fatalf("resolveLogDirs() error = %v", err)
+	}
+	if len(logDirs) != 2 {
+		t.Fatalf("Expected 2 log directories, got %v", logDirs)
+	}
+
+	actions, _, err := analyzeBackupResults(logDirs...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var files []string
+	for _, action := range actions {
+		files = append(files, action.GetOutFile())
+	}
+	want := []string{
+		filepath.Join(morning, "backup.home.out"),
+		filepath.Join(morning, "backup.etc.out"),
+		filepath.Join(evening, "backup.home.out"),
+		filepath.Join(evening, "backup.etc.out"),
+	}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected actions in chronological order %v, got %v", want, files)
+	}
+
+	// Directories given twice are only included once
+	logDirs, err = resolveLogDirs([]string{morning}, filepath.Join(root, "*"))
+	if err != nil || len(logDirs) != 2 {
+		t.Errorf("Expected 2 unique log directories, got %v (%v)", logDirs, err)
+	}
+	if _, err := resolveLogDirs(nil, filepath.Join(root, "missing-*")); err == nil {
+		t.Error("Expected error when no directories match")
+	}
+}
+
+func TestAnalyzeBackupResultsInRange(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"old": 48 * time.Hour, "today": 2 * time.Hour} {
+		path := filepath.Join(tmpDir, "backup."+name)
+		os.WriteFile(path+".exitcode", []byte("1"), 0644)
+		os.WriteFile(path+".out", []byte(`{"message_type":"summary","files_new":1}`), 0644)
+		mtime := now.Add(-age)
+		os.Chtimes(path+".exitcode", mtime, mtime)
+	}
+
+	timeRange, err := shared.ParseTimeRange("24h", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, _, err := analyzeBackupResultsInRange(timeRange, tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(actions) != 1 || actions[0].GetActionName() != "today" {
+		t.Errorf("Expected only today's backup, got %v", actions)
+	}
+}
+
+func TestGenerateBodyExitSummary(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{Name: "home", Success: true, Result: &restic.BackupResult{}},
+		&restic.CheckActionResult{Name: "check", Success: false, ExitCode: 3, Result: &restic.CheckResult{}},
+	}
+
+	body := generateBodyFromActions(actions, false, nil, 0, nil, nil)
+	expected := "Action | Type      | Status | Exit\n" +
+		"------ | --------- | ------ | ----\n" +
+		"home   | backup    | OK     |    0\n" +
+		"check  | check     | FAILED |    3\n\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected exit summary table in body, got:\n%s", body)
+	}
+	if strings.Index(body, expected) > strings.Index(body, "✅ backup home") {
+		t.Errorf("Expected exit summary before the action details, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, false, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "<td>check</td><td>check</td>") || !strings.Contains(htmlBody, "<td align=\"right\">3</td>") {
+		t.Errorf("Expected exit summary table in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestParseActionLogExitCode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "check.exitcode"), []byte("3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "check.out"), []byte("Fatal: repository contains errors\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	action, err := parseActionLog(filepath.Join(dir, "check.exitcode"))
+	if err != nil {
+		t.Fatalf("parseActionLog failed: %v", err)
+	}
+	if action.GetExitCode() != 3 {
+		t.Errorf("Expected exit code 3, got %d", action.GetExitCode())
+	}
+}
+
+func TestParseActionLogGeneric(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "migrate.exitcode"), []byte("2"), 0644)
+	var output strings.Builder
+	for i := 1; i <= 8; i++ {
+		output.WriteString("step " + strconv.Itoa(i) + "\n\n")
+	}
+	os.WriteFile(filepath.Join(dir, "migrate.out"), []byte(output.String()), 0644)
+
+	action, err := parseActionLog(filepath.Join(dir, "migrate.exitcode"))
+	if err != nil {
+		t.Fatalf("parseActionLog failed: %v", err)
+	}
+	generic, ok := action.(*restic.GenericActionResult)
+	if !ok {
+		t.Fatalf("Expected GenericActionResult, got %T", action)
+	}
+	if generic.Name != "migrate" || generic.Success || generic.ExitCode != 2 {
+		t.Errorf("Unexpected generic result: %+v", generic)
+	}
+	expected := []string{"step 4", "step 5", "step 6", "step 7", "step 8"}
+	if strings.Join(generic.OutputTail, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected output tail %v, got %v", expected, generic.OutputTail)
+	}
+
+	body := generateBodyFromActions([]restic.ActionResult{generic}, false, nil, 0, nil, nil)
+	if !strings.Contains(body, "❌ migrate\n  Exit code: 2\n    step 4\n") {
+		t.Errorf("Expected generic action in body, got:\n%s", body)
+	}
+	if actionTypeOf(generic) != "migrate" {
+		t.Errorf("Expected generic action type migrate, got %q", actionTypeOf(generic))
+	}
+
+	// A missing output file is not an error
+	os.WriteFile(filepath.Join(dir, "repo-stats.exitcode"), []byte("0"), 0644)
+	action, err = parseActionLog(filepath.Join(dir, "repo-stats.exitcode"))
+	if err != nil || !action.IsSuccess() {
+		t.Errorf("Expected successful generic action without output, got %v, %v", action, err)
+	}
+}
+
+func TestNotifyEmailActionDryRunAttachAll(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary"}`+strings.Repeat(" ", 2048)), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.err"), []byte("small"), 0644)
+
+	emailConfig := &shared.NotifyEmailConfig{
+		SMTPHost:          "localhost",
+		SMTPUsername:      "test",
+		SMTPPassword:      "test",
+		From:              "from@example.com",
+		To:                "to@example.com",
+		MaxAttachmentSize: 1024,
+	}
+
+	run := func() string {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := NewNotifyEmailAction(emailConfig).Execute([]string{tmpDir}, true)
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		if err != nil {
+			t.Errorf("Expected no error in dry-run mode, got %v", err)
+		}
+		return buf.String()
+	}
+
+	// Logs of successful actions are not attached by default
+	if output := run(); strings.Contains(output, "Would attach") {
+		t.Errorf("Expected no attachments for a successful backup, got:\n%s", output)
+	}
+
+	emailConfig.AttachAll = true
+	output := run()
+	if !strings.Contains(output, "DRY RUN: Would attach: "+filepath.Join(tmpDir, "backup.home.err")) {
+		t.Errorf("Expected success log to be attached, got:\n%s", output)
+	}
+	if strings.Contains(output, "Would attach: "+filepath.Join(tmpDir, "backup.home.out")) {
+		t.Errorf("Expected oversized log not to be attached, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Attachments skipped (larger than 1.0 KB):\n  "+filepath.Join(tmpDir, "backup.home.out")) {
+		t.Errorf("Expected note about the skipped attachment, got:\n%s", output)
+	}
+}
+
+func TestAnalyzeBackupResultsWarnings(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","files_new":1}`), 0644)
+	var stderr strings.Builder
+	for i := 1; i <= 5; i++ {
+		stderr.WriteString(fmt.Sprintf("error: open /home/file%d: permission denied\n", i))
+	}
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.err"), []byte(stderr.String()), 0644)
+
+	actions, success, err := analyzeBackupResults(tmpDir)
+	if err != nil {
+		t.Fatalf("analyzeBackupResults failed: %v", err)
+	}
+	if !success || len(actions) != 1 {
+		t.Fatalf("Expected one successful action, got %d (success %v)", len(actions), success)
+	}
+	if got := len(actions[0].GetWarnings()); got != 5 {
+		t.Fatalf("Expected 5 warnings, got %d", got)
+	}
+
+	body := generateBodyFromActions(actions, success, nil, 0, nil, nil)
+	expected := "✅ backup home ⚠ 5 warnings\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("Expected warning count next to the backup, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  - error: open /home/file3: permission denied\n  ... and 2 more warnings\n") {
+		t.Errorf("Expected the first warnings inline, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, success, nil, 0, nil, nil)
+	if !strings.Contains(htmlBody, "⚠ 5 warnings</h3>") {
+		t.Errorf("Expected warning count in HTML body, got:\n%s", htmlBody)
+	}
+}
+
+func TestGenerateBodyMaxSnapshotRows(t *testing.T) {
+	var snapshots []restic.Snapshot
+	for day := 1; day <= 5; day++ {
+		snapshots = append(snapshots, restic.Snapshot{
+			ID:    fmt.Sprintf("%08d", day),
+			Time:  fmt.Sprintf("2025-10-%02dT02:00:00Z", day),
+			Paths: []string{"/data"},
+		})
+	}
+	actions := []restic.ActionResult{&restic.SnapshotsActionResult{Name: "snapshots", Success: true, Snapshots: snapshots}}
+
+	body := generateBodyFromActions(actions, true, nil, 2, nil, nil)
+	if !strings.Contains(body, "2025-10-05 02:00") || !strings.Contains(body, "2025-10-04 02:00") {
+		t.Errorf("Expected the newest snapshots, got:\n%s", body)
+	}
+	if strings.Contains(body, "2025-10-03 02:00") {
+		t.Errorf("Expected older snapshots to be left out, got:\n%s", body)
+	}
+	if !strings.Contains(body, "  ... and 3 older snapshots\n") {
+		t.Errorf("Expected note about older snapshots, got:\n%s", body)
+	}
+
+	htmlBody := generateHTMLBodyFromActions(actions, true, nil, 2, nil, nil)
+	if !strings.Contains(htmlBody, "<p>... and 3 older snapshots</p>") || strings.Contains(htmlBody, "2025-10-03 02:00") {
+		t.Errorf("Expected capped HTML table, got:\n%s", htmlBody)
+	}
+
+	// All rows are shown by default
+	body = generateBodyFromActions(actions, true, nil, 0, nil, nil)
+	if !strings.Contains(body, "2025-10-01 02:00") || strings.Contains(body, "older snapshots") {
+		t.Errorf("Expected all snapshots by default, got:\n%s", body)
+	}
+}
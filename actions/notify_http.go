@@ -1,26 +1,181 @@
 package actions
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"restic-kit/restic"
+	"restic-kit/shared"
 )
 
 // NotifyHTTPConfig holds configuration for HTTP notifications
 type NotifyHTTPConfig struct {
-	URL string
+	URL           string
+	Method        string
+	Template      string
+	RawHeaders    []string
+	Headers       map[string]string
+	Start         bool
+	StartSuffix   string
+	SuccessSuffix string
+	FailSuffix    string
+	Timeout       time.Duration
+	BasicAuth     string
+	Proxy         string
+	ProxyURL      *neturl.URL
+	AcceptStatus  []string
+	StatusRanges  []StatusRange
+	Retries       int
+	RetryDelay    time.Duration
+	RepoName      string
+	ExitOnFailure bool
+	OnFailureOnly bool
+	ResultFile    string
+	HMACSecret    string
+	HMACHeader    string
+}
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
 }
 
 // ValidateNotifyHTTPConfig validates the HTTP notification config
 func ValidateNotifyHTTPConfig(cfg *NotifyHTTPConfig) error {
 	if cfg.URL == "" {
-		return fmt.Errorf("url is required")
+		return shared.ExplainUnsetEnv("url", fmt.Errorf("url is required"))
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Method != http.MethodGet && cfg.Method != http.MethodPost {
+		return fmt.Errorf("method must be either GET or POST")
+	}
+	if cfg.Template != "" && cfg.Template != "slack" && cfg.Template != "discord" {
+		return fmt.Errorf("template must be slack, discord or empty")
+	}
+	if cfg.Template != "" {
+		// Webhook templates always POST their payload
+		cfg.Method = http.MethodPost
+	}
+	if cfg.Start && cfg.Template != "" {
+		return fmt.Errorf("start cannot be combined with a template")
+	}
+	if cfg.Start && cfg.OnFailureOnly {
+		return fmt.Errorf("start cannot be combined with on-failure-only")
+	}
+	if cfg.HMACSecret != "" && (cfg.Method != http.MethodPost || cfg.Start) {
+		return fmt.Errorf("hmac-secret requires a POST payload (--method POST or a template)")
+	}
+	if cfg.HMACHeader == "" {
+		cfg.HMACHeader = "X-Signature-256"
+	}
+	if cfg.StartSuffix == "" {
+		cfg.StartSuffix = "/start"
+	}
+	if cfg.FailSuffix == "" {
+		cfg.FailSuffix = "/fail"
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must be non-negative")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.Retries < 0 {
+		return fmt.Errorf("retries must be non-negative")
+	}
+	if cfg.RetryDelay < 0 {
+		return fmt.Errorf("retry-delay must be non-negative")
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = 2 * time.Second
+	}
+	u, err := neturl.Parse(cfg.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", redactURL(cfg.URL), err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url %q: scheme must be http or https", redactURL(cfg.URL))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid url %q: host is required", redactURL(cfg.URL))
+	}
+	if cfg.Template == "" && strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), cfg.FailSuffix) {
+		return fmt.Errorf("invalid url %q: must not end in %s, which is appended on failure", redactURL(cfg.URL), cfg.FailSuffix)
+	}
+	if err := validateBasicAuth(cfg.BasicAuth); err != nil {
+		return err
+	}
+	proxyURL, err := parseProxy(cfg.Proxy)
+	if err != nil {
+		return err
+	}
+	cfg.ProxyURL = proxyURL
+	cfg.StatusRanges = nil
+	for _, raw := range cfg.AcceptStatus {
+		r, err := parseStatusRange(raw)
+		if err != nil {
+			return err
+		}
+		cfg.StatusRanges = append(cfg.StatusRanges, r)
+	}
+	if len(cfg.StatusRanges) == 0 {
+		cfg.StatusRanges = []StatusRange{{Min: 200, Max: 299}}
+	}
+	if cfg.Headers == nil {
+		cfg.Headers = make(map[string]string)
+	}
+	for _, raw := range cfg.RawHeaders {
+		key, value, ok := strings.Cut(raw, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid header %q: expected \"Key: Value\"", raw)
+		}
+		cfg.Headers[key] = strings.TrimSpace(value)
 	}
 	return nil
 }
 
+// signPayload returns the hex-encoded HMAC-SHA256 of the payload, computed
+// over the exact bytes that are sent
+func signPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPReport is the JSON payload sent by notify-http for POST requests
+type HTTPReport struct {
+	Repository string             `json:"repository,omitempty"`
+	Status     string             `json:"status"`
+	Success    bool               `json:"success"`
+	Actions    []HTTPActionReport `json:"actions"`
+}
+
+// HTTPActionReport is the JSON representation of a single action result
+type HTTPActionReport struct {
+	Type    string            `json:"type"`
+	Name    string            `json:"name"`
+	Success bool              `json:"success"`
+	Summary map[string]string `json:"summary"`
+}
+
 type NotifyHTTPAction struct {
 	*BaseAction
 	config *NotifyHTTPConfig
@@ -33,61 +188,605 @@ func NewNotifyHTTPAction(cfg *NotifyHTTPConfig) *NotifyHTTPAction {
 	}
 }
 
-func (a *NotifyHTTPAction) Execute(args []string) error {
+func (a *NotifyHTTPAction) Execute(args []string, dryRun bool) error {
+	method := a.config.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	// The start ping is sent before any work begins, so there are no logs
+	if a.config.Start {
+		if len(args) > 1 {
+			return WithExitCode(ExitConfigError, fmt.Errorf("notify-http --start accepts at most one argument"))
+		}
+		startURL := appendURLSuffix(a.config.URL, a.config.StartSuffix)
+		if dryRun {
+			return a.printRequest(method, startURL, nil)
+		}
+		return WithExitCode(ExitNotifyError, a.send(method, startURL, nil))
+	}
+
 	if len(args) != 1 {
-		return fmt.Errorf("notify-http requires exactly one argument: the path to the log directory")
+		return WithExitCode(ExitConfigError, fmt.Errorf("notify-http requires exactly one argument: the path to the log directory"))
 	}
 
 	logDir := args[0]
 
-	_, overallSuccess, err := analyzeBackupResults(logDir)
+	actions, overallSuccess, err := analyzeBackupResults(logDir)
 	if err != nil {
 		return err
 	}
 
-	// Modify URL based on success/failure
+	result := newResultFile(a.GetName(), a.config.RepoName, actions, overallSuccess)
+
+	if a.config.OnFailureOnly && overallSuccess {
+		shared.Infof("All actions succeeded, no notification sent\n")
+		return writeResultFile(a.config.ResultFile, result, nil, dryRun)
+	}
+
+	// Modify URL based on success/failure. Webhook templates report the
+	// status in the payload, so their URL is left untouched.
 	url := a.config.URL
-	if !overallSuccess {
-		url = strings.TrimSuffix(url, "/") + "/fail"
+	if a.config.Template == "" {
+		if overallSuccess {
+			url = appendURLSuffix(url, a.config.SuccessSuffix)
+		} else {
+			failSuffix := a.config.FailSuffix
+			if failSuffix == "" {
+				failSuffix = "/fail"
+			}
+			url = appendURLSuffix(url, failSuffix)
+		}
+	}
+
+	var payload []byte
+	if method == http.MethodPost {
+		var body interface{}
+		switch a.config.Template {
+		case "slack":
+			body = buildSlackPayload(actions, overallSuccess, a.config.RepoName)
+		case "discord":
+			body = buildDiscordPayload(actions, overallSuccess, a.config.RepoName)
+		default:
+			body = buildHTTPReport(actions, overallSuccess, a.config.RepoName)
+		}
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal HTTP payload: %w", err)
+		}
+	}
+
+	if dryRun {
+		if err := a.printRequest(method, url, payload); err != nil {
+			return err
+		}
+		return writeResultFile(a.config.ResultFile, result, nil, dryRun)
+	}
+
+	sendErr := WithExitCode(ExitNotifyError, a.send(method, url, payload))
+	if err := writeResultFile(a.config.ResultFile, result, sendErr, dryRun); err != nil {
+		return errors.Join(sendErr, err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if a.config.ExitOnFailure && !overallSuccess {
+		return backupFailedError(actions)
+	}
+	return nil
+}
+
+// send performs the HTTP request with the configured headers
+func (a *NotifyHTTPAction) send(method, url string, payload []byte) error {
+	client := &http.Client{Timeout: a.config.Timeout, Transport: newHTTPTransport(a.config.ProxyURL)}
+	if acceptsRedirects(a.config.StatusRanges) {
+		// Accepted redirects are the final response and are not followed
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	// Credentials embedded in the URL must not end up in logs or cron mail
+	displayURL := redactURL(url)
+
+	delay := a.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		req, err := a.newRequest(method, url, payload)
+		if err != nil {
+			return err
+		}
+
+		shared.Verbosef("Sending HTTP %s request to %s (attempt %d)\n", method, displayURL, attempt+1)
+		statusCode, err := a.do(client, req, displayURL)
+		if err == nil {
+			shared.Infof("HTTP notification sent successfully (status: %d) to %s\n", statusCode, displayURL)
+			return nil
+		}
+
+		// Connection errors and 5xx responses are retried, 4xx are permanent
+		if attempt >= a.config.Retries || (statusCode != 0 && statusCode < 500) {
+			if attempt > 0 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+			}
+			return err
+		}
+
+		shared.Infof("HTTP notification failed (%v), retrying in %v...\n", err, delay)
+		time.Sleep(delay)
+		delay = shared.NextBackoff(delay, shared.MaxRetryDelay)
 	}
+}
 
-	resp, err := http.Get(url)
+// newRequest creates the notification request with the configured headers
+func (a *NotifyHTTPAction) newRequest(method, url string, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("failed to perform HTTP GET request to %s: %w", url, err)
+		return nil, fmt.Errorf("failed to create HTTP %s request to %s: %w", method, redactURL(url), err)
+	}
+	req.Header.Set("User-Agent", shared.UserAgent())
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range a.config.Headers {
+		req.Header.Set(key, value)
+	}
+	if a.config.HMACSecret != "" && payload != nil {
+		req.Header.Set(a.config.HMACHeader, signPayload(payload, a.config.HMACSecret))
+	}
+	setBasicAuth(req, a.config.BasicAuth)
+	return req, nil
+}
+
+// printRequest prints the request that would be sent in dry-run mode.
+// Credentials in the URL and the Authorization header are redacted.
+func (a *NotifyHTTPAction) printRequest(method, url string, payload []byte) error {
+	req, err := a.newRequest(method, url, payload)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("DRY RUN: Would send HTTP %s request to %s\n", method, redactURL(url))
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := strings.Join(req.Header.Values(key), ", ")
+		if key == "Authorization" {
+			value = "xxxxx"
+		}
+		fmt.Printf("DRY RUN: Header: %s: %s\n", key, value)
+	}
+	if payload != nil {
+		fmt.Println("DRY RUN: Body preview:")
+		fmt.Println(string(payload))
+	}
+	return nil
+}
+
+// do performs a single HTTP request and returns the response status code,
+// which is 0 if no response was received
+func (a *NotifyHTTPAction) do(client *http.Client, req *http.Request, displayURL string) (int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return 0, fmt.Errorf("HTTP %s request to %s timed out after %v", req.Method, displayURL, a.config.Timeout)
+		}
+		return 0, fmt.Errorf("failed to perform HTTP %s request to %s: %w", req.Method, displayURL, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("HTTP request to %s failed with status code: %d", url, resp.StatusCode)
+	if !statusAccepted(resp.StatusCode, a.config.StatusRanges) {
+		return resp.StatusCode, fmt.Errorf("HTTP request to %s failed with status code: %d", displayURL, resp.StatusCode)
 	}
+	return resp.StatusCode, nil
+}
 
-	fmt.Printf("HTTP notification sent successfully (status: %d) to %s\n", resp.StatusCode, url)
+// parseStatusRange parses a status code like "204" or a range like "200-399"
+func parseStatusRange(raw string) (StatusRange, error) {
+	raw = strings.TrimSpace(raw)
+	loStr, hiStr, isRange := strings.Cut(raw, "-")
+	if !isRange {
+		hiStr = loStr
+	}
+	lo, loErr := strconv.Atoi(strings.TrimSpace(loStr))
+	hi, hiErr := strconv.Atoi(strings.TrimSpace(hiStr))
+	if loErr != nil || hiErr != nil || lo < 100 || hi > 599 || lo > hi {
+		return StatusRange{}, fmt.Errorf("invalid accept-status %q: expected a status code or a range like 200-399", raw)
+	}
+	return StatusRange{Min: lo, Max: hi}, nil
+}
+
+// statusAccepted reports whether the status code is in one of the ranges.
+// Without any ranges, only 2xx status codes are accepted.
+func statusAccepted(code int, ranges []StatusRange) bool {
+	if len(ranges) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsRedirects reports whether any 3xx status code is in the ranges
+func acceptsRedirects(ranges []StatusRange) bool {
+	for _, r := range ranges {
+		if r.Min < 400 && r.Max >= 300 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBasicAuth checks that basic auth credentials are in user:pass format
+func validateBasicAuth(basicAuth string) error {
+	if basicAuth == "" {
+		return nil
+	}
+	if user, _, ok := strings.Cut(basicAuth, ":"); !ok || user == "" {
+		return fmt.Errorf("basic-auth must be in user:pass format")
+	}
 	return nil
 }
 
+// setBasicAuth adds basic auth credentials in user:pass format to the request
+func setBasicAuth(req *http.Request, basicAuth string) {
+	if user, pass, ok := strings.Cut(basicAuth, ":"); ok {
+		req.SetBasicAuth(user, pass)
+	}
+}
+
+// parseProxy parses the --proxy URL. Without a proxy, nil is returned and
+// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+func parseProxy(proxy string) (*neturl.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	u, err := neturl.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", redactURL(proxy), err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: expected a URL such as http://proxy:3128", redactURL(proxy))
+	}
+	return u, nil
+}
+
+// newHTTPTransport returns a transport using the given proxy, or the proxy
+// from the environment if proxy is nil
+func newHTTPTransport(proxy *neturl.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport
+}
+
+// redactURL replaces the password of a URL with "xxxxx"
+func redactURL(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil || u.User == nil {
+		return url
+	}
+	return u.Redacted()
+}
+
+// appendURLSuffix appends a suffix such as "/fail" to the path of the URL,
+// preserving any query string and fragment
+func appendURLSuffix(url, suffix string) string {
+	if suffix == "" {
+		return url
+	}
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return strings.TrimSuffix(url, "/") + suffix
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + suffix
+	if u.RawPath != "" {
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/") + suffix
+	}
+	return u.String()
+}
+
+// buildHTTPReport converts the parsed action results into the JSON payload
+func buildHTTPReport(actions []restic.ActionResult, success bool, repoName string) *HTTPReport {
+	report := &HTTPReport{
+		Repository: repoName,
+		Status:     map[bool]string{true: "SUCCESS", false: "FAILURE"}[success],
+		Success:    success,
+		Actions:    []HTTPActionReport{},
+	}
+
+	for _, action := range actions {
+		report.Actions = append(report.Actions, HTTPActionReport{
+			Type:    actionTypeOf(action),
+			Name:    action.GetActionName(),
+			Success: action.IsSuccess(),
+			Summary: action.GetSummaryInfo(),
+		})
+	}
+
+	return report
+}
+
+// slackPayload is the JSON body of a Slack incoming webhook message
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// buildSlackPayload renders the action results as a Slack message with a
+// green or red header and one section per action
+func buildSlackPayload(actions []restic.ActionResult, success bool, repoName string) *slackPayload {
+	title := reportTitle(repoName, success)
+	color := map[bool]string{true: "#2eb886", false: "#e01e5a"}[success]
+
+	attachment := slackAttachment{
+		Color: color,
+		Blocks: []slackBlock{
+			{
+				Type: "header",
+				Text: &slackText{Type: "plain_text", Text: title},
+			},
+		},
+	}
+
+	for _, action := range actions {
+		statusEmoji := "✅"
+		if !action.IsSuccess() {
+			statusEmoji = "❌"
+		}
+		text := fmt.Sprintf("%s *%s %s*", statusEmoji, actionTypeOf(action), action.GetActionName())
+		if summary := actionSummaryText(action); summary != "" {
+			text += "\n" + summary
+		}
+		attachment.Blocks = append(attachment.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: text},
+		})
+	}
+
+	return &slackPayload{
+		Text:        title,
+		Attachments: []slackAttachment{attachment},
+	}
+}
+
+// discordPayload is the JSON body of a Discord webhook message
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title  string         `json:"title"`
+	Color  int            `json:"color"`
+	Fields []discordField `json:"fields"`
+}
+
+type discordField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Discord embed limits, see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	discordMaxFields     = 25
+	discordMaxFieldName  = 256
+	discordMaxFieldValue = 1024
+	discordMaxTotal      = 6000
+)
+
+// buildDiscordPayload renders the action results as a Discord embed with a
+// green or red sidebar and one field per action. Fields exceeding Discord's
+// limits are truncated and a final field notes the omitted actions.
+func buildDiscordPayload(actions []restic.ActionResult, success bool, repoName string) *discordPayload {
+	embed := discordEmbed{
+		Title: reportTitle(repoName, success),
+		Color: map[bool]int{true: 0x2eb886, false: 0xe01e5a}[success],
+	}
+
+	// Reserve room for the truncation note
+	noteName := "Truncated"
+	noteValue := fmt.Sprintf("%d more actions not shown", len(actions))
+	reserved := len([]rune(noteName)) + len([]rune(noteValue))
+
+	total := len([]rune(embed.Title))
+	for i, action := range actions {
+		statusEmoji := "✅"
+		if !action.IsSuccess() {
+			statusEmoji = "❌"
+		}
+		field := discordField{
+			Name:  truncateRunes(fmt.Sprintf("%s %s %s", statusEmoji, actionTypeOf(action), action.GetActionName()), discordMaxFieldName),
+			Value: truncateRunes(actionSummaryText(action), discordMaxFieldValue),
+		}
+		if field.Value == "" {
+			// Discord rejects fields with empty values
+			field.Value = map[bool]string{true: "SUCCESS", false: "FAILURE"}[action.IsSuccess()]
+		}
+
+		size := len([]rune(field.Name)) + len([]rune(field.Value))
+		remaining := len(actions) - i
+		if remaining > 1 && (len(embed.Fields) >= discordMaxFields-1 || total+size+reserved > discordMaxTotal) ||
+			remaining == 1 && (len(embed.Fields) >= discordMaxFields || total+size > discordMaxTotal) {
+			embed.Fields = append(embed.Fields, discordField{
+				Name:  noteName,
+				Value: fmt.Sprintf("%d more actions not shown", remaining),
+			})
+			break
+		}
+
+		embed.Fields = append(embed.Fields, field)
+		total += size
+	}
+
+	return &discordPayload{Embeds: []discordEmbed{embed}}
+}
+
+// truncateRunes shortens s to at most limit characters, marking the cut with an ellipsis
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// actionSummaryText returns a short human readable summary of an action
+func actionSummaryText(action restic.ActionResult) string {
+	info := action.GetSummaryInfo()
+	switch a := action.(type) {
+	case *restic.BackupActionResult:
+		if len(info) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("Files: %s new, %s changed, %s unmodified\nData added: %s",
+			info["files_new"], info["files_changed"], info["files_unmodified"], info["data_added"])
+	case *restic.CheckActionResult:
+		return info["status"]
+	case *restic.SnapshotsActionResult:
+		return fmt.Sprintf("Repository Snapshots: %s", info["total_snapshots"])
+	case *restic.ForgetActionResult:
+		return fmt.Sprintf("%s snapshots removed", info["removed_snapshots"])
+	case *restic.PruneActionResult:
+		return fmt.Sprintf("%s freed", info["size_freed"])
+	case *restic.StatsActionResult:
+		return fmt.Sprintf("Repository size: %s", info["total_size"])
+	case *restic.DiffActionResult:
+		return diffSummary(a)
+	case *restic.CopyActionResult:
+		return copySummary(a)
+	case *restic.GenericActionResult:
+		return info["last_output"]
+	case *restic.MissingOutputActionResult:
+		return info["output"]
+	}
+	return ""
+}
+
+// actionTypeOf returns the restic command type of an action result
+func actionTypeOf(action restic.ActionResult) string {
+	switch a := action.(type) {
+	case *restic.BackupActionResult:
+		return "backup"
+	case *restic.CheckActionResult:
+		return "check"
+	case *restic.SnapshotsActionResult:
+		return "snapshots"
+	case *restic.ForgetActionResult:
+		return "forget"
+	case *restic.PruneActionResult:
+		return "prune"
+	case *restic.StatsActionResult:
+		return "stats"
+	case *restic.DiffActionResult:
+		return "diff"
+	case *restic.CopyActionResult:
+		return "copy"
+	case *restic.GenericActionResult:
+		// Generic actions are named after their command, e.g. copy
+		return action.GetActionName()
+	case *restic.MissingOutputActionResult:
+		return a.Type
+	}
+	return "unknown"
+}
+
 func NewNotifyHTTPCmd() *cobra.Command {
-	var url string
+	var url, method, template, startSuffix, successSuffix, failSuffix, basicAuth, proxy, hmacSecret, hmacHeader, resultFile string
+	var headers, acceptStatus []string
+	var start, exitOnFailure, onFailureOnly bool
+	var retries int
+	var timeout, retryDelay time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "notify-http [log-directory]",
 		Short: "Send an HTTP notification",
-		Long:  `Send an HTTP GET request to the configured URL. Appends "/fail" to the URL if the backup sequence failed.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Send an HTTP request to the configured URL. Appends "/fail" to the URL if the backup sequence failed.
+With --method POST, the parsed backup report is sent as a JSON payload.
+With --template slack or discord, a Slack or Discord webhook message is posted instead and the URL is not modified.
+With --start, only the start endpoint is pinged and no log directory is needed.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
 			httpConfig := &NotifyHTTPConfig{
-				URL: url,
+				URL:           url,
+				Method:        method,
+				Template:      template,
+				RawHeaders:    headers,
+				Start:         start,
+				StartSuffix:   startSuffix,
+				SuccessSuffix: successSuffix,
+				FailSuffix:    failSuffix,
+				Timeout:       timeout,
+				BasicAuth:     basicAuth,
+				Proxy:         proxy,
+				AcceptStatus:  acceptStatus,
+				Retries:       retries,
+				RetryDelay:    retryDelay,
+				RepoName:      repoName,
+				ExitOnFailure: exitOnFailure,
+				OnFailureOnly: onFailureOnly,
+				ResultFile:    resultFile,
+				HMACSecret:    hmacSecret,
+				HMACHeader:    hmacHeader,
 			}
 
 			if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
-				return fmt.Errorf("invalid HTTP config: %w", err)
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid HTTP config: %w", err))
 			}
 
+			// A failed backup or notification is not a usage error
+			cmd.SilenceUsage = true
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			action := NewNotifyHTTPAction(httpConfig)
-			return action.Execute(args)
+			return action.Execute(args, dryRun)
 		},
 	}
 
 	cmd.Flags().StringVar(&url, "url", "", "HTTP URL to send the notification to (required)")
+	cmd.Flags().StringVar(&method, "method", "GET", "HTTP method to use: GET or POST")
+	cmd.Flags().StringVar(&template, "template", "", "Webhook payload template: slack or discord (implies POST)")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, "Additional HTTP header in \"Key: Value\" format (repeatable)")
+	cmd.Flags().BoolVar(&start, "start", false, "Ping the start endpoint instead of reporting results")
+	cmd.Flags().StringVar(&startSuffix, "start-suffix", "/start", "Suffix appended to the URL for the start ping")
+	cmd.Flags().StringVar(&successSuffix, "success-suffix", "", "Suffix appended to the URL on success")
+	cmd.Flags().StringVar(&failSuffix, "fail-suffix", "/fail", "Suffix appended to the URL on failure")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for the HTTP request")
+	cmd.Flags().StringVar(&basicAuth, "basic-auth", "", "HTTP basic auth credentials in user:pass format")
+	cmd.Flags().StringVar(&proxy, "proxy", "", "Proxy URL, e.g. http://proxy:3128 (default: HTTP_PROXY/HTTPS_PROXY environment variables)")
+	cmd.Flags().StringVar(&hmacSecret, "hmac-secret", "", "Sign POST payloads with this HMAC-SHA256 secret")
+	cmd.Flags().StringVar(&hmacHeader, "hmac-header", "X-Signature-256", "Header carrying the hex-encoded HMAC signature")
+	cmd.Flags().IntVar(&retries, "retries", 0, "Number of retries for connection errors and 5xx responses")
+	cmd.Flags().DurationVar(&retryDelay, "retry-delay", 2*time.Second, "Initial delay between retries")
+	cmd.Flags().StringSliceVar(&acceptStatus, "accept-status", nil, "Accepted status codes or ranges, e.g. 200-299,302 (default 200-299)")
+	cmd.Flags().BoolVar(&exitOnFailure, "exit-on-failure", false, "Exit with a non-zero code after notifying if the backup failed")
+	cmd.Flags().BoolVar(&onFailureOnly, "on-failure-only", false, "Only send a notification if the backup failed")
+	cmd.Flags().StringVar(&resultFile, "result-file", "", "Write the overall result as JSON to this file, even if the request fails")
 	cmd.MarkFlagRequired("url")
 
 	return cmd
@@ -1,11 +1,21 @@
 package actions
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"restic-kit/restic"
 )
 
 func TestNotifyHTTPAction(t *testing.T) {
@@ -42,19 +52,19 @@ func TestNotifyHTTPAction(t *testing.T) {
 	action := NewNotifyHTTPAction(httpConfig)
 
 	// Test successful request
-	err = action.Execute([]string{tmpDir})
+	err = action.Execute([]string{tmpDir}, false)
 	if err != nil {
 		t.Errorf("Expected success, got error: %v", err)
 	}
 
 	// Test with no arguments (should fail)
-	err = action.Execute([]string{})
+	err = action.Execute([]string{}, false)
 	if err == nil {
 		t.Error("Expected error for no arguments, got nil")
 	}
 
 	// Test with too many arguments (should fail)
-	err = action.Execute([]string{tmpDir, "extra"})
+	err = action.Execute([]string{tmpDir, "extra"}, false)
 	if err == nil {
 		t.Error("Expected error for too many arguments, got nil")
 	}
@@ -85,12 +95,729 @@ func TestNotifyHTTPActionFailure(t *testing.T) {
 
 	action := NewNotifyHTTPAction(httpConfig)
 
-	err = action.Execute([]string{tmpDir})
+	err = action.Execute([]string{tmpDir}, false)
 	if err == nil {
 		t.Error("Expected error for invalid URL, got nil")
 	}
 }
 
+func TestNotifyHTTPActionPost(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "http-post-test*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// One failing backup so the payload reports a failure
+	os.WriteFile(filepath.Join(tmpDir, "backup.test.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "forget.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.test.out"), []byte(`{"message_type":"summary","files_new":3,"files_changed":0,"files_unmodified":100}`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "forget.out"), []byte(`[{"tags":null,"host":"","paths":["/test"],"keep":[{"id":"abc123"}],"remove":[{"id":"def456"}]}]`), 0644)
+
+	var gotPath, gotContentType string
+	var report HTTPReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		gotPath = r.URL.Path
+		gotContentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpConfig := &NotifyHTTPConfig{URL: server.URL, Method: "post"}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewNotifyHTTPAction(httpConfig)
+	if err := action.Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if gotPath != "/fail" {
+		t.Errorf("Expected /fail path, got %s", gotPath)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("Expected application/json content type, got %s", gotContentType)
+	}
+	if report.Success || report.Status != "FAILURE" {
+		t.Errorf("Expected failure status in payload, got %+v", report)
+	}
+	if len(report.Actions) != 2 {
+		t.Fatalf("Expected 2 actions in payload, got %d", len(report.Actions))
+	}
+
+	byType := make(map[string]HTTPActionReport)
+	for _, a := range report.Actions {
+		byType[a.Type] = a
+	}
+	if byType["backup"].Name != "test" || byType["backup"].Summary["files_new"] != "3" {
+		t.Errorf("Unexpected backup entry: %+v", byType["backup"])
+	}
+	if byType["forget"].Summary["removed_snapshots"] != "1" {
+		t.Errorf("Unexpected forget entry: %+v", byType["forget"])
+	}
+}
+
+func TestNotifyHTTPActionHMAC(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	const secret = "webhook-secret"
+	for _, tt := range []struct {
+		name   string
+		secret string
+		header string
+	}{
+		{name: "default header", secret: secret, header: "X-Signature-256"},
+		{name: "custom header", secret: secret, header: "X-Hub-Signature"},
+		{name: "no secret", header: "X-Signature-256"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var verified, gotHeader bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				signature := r.Header.Get(tt.header)
+				gotHeader = signature != ""
+
+				// Verify like a webhook receiver would
+				mac := hmac.New(sha256.New, []byte(secret))
+				mac.Write(body)
+				expected := hex.EncodeToString(mac.Sum(nil))
+				verified = hmac.Equal([]byte(signature), []byte(expected))
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			httpConfig := &NotifyHTTPConfig{URL: server.URL, Method: "POST", HMACSecret: tt.secret}
+			if tt.header != "X-Signature-256" {
+				httpConfig.HMACHeader = tt.header
+			}
+			if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false); err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+
+			if tt.secret == "" {
+				if gotHeader {
+					t.Error("Expected no signature header without a secret")
+				}
+				return
+			}
+			if !verified {
+				t.Error("Expected a valid signature of the payload")
+			}
+		})
+	}
+
+	// GET requests have no payload to sign
+	httpConfig := &NotifyHTTPConfig{URL: "http://example.com", HMACSecret: secret}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err == nil {
+		t.Error("Expected error for hmac-secret without POST")
+	}
+}
+
+func TestNotifyHTTPActionHeaders(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "http-headers-test*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	var gotAuth, gotSource, gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotSource = r.Header.Get("X-Source")
+		gotUserAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpConfig := &NotifyHTTPConfig{
+		URL:        server.URL,
+		RawHeaders: []string{"Authorization: Bearer secret-token", "X-Source:backup-host"},
+	}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewNotifyHTTPAction(httpConfig)
+	if err := action.Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if gotAuth != "Bearer secret-token" {
+		t.Errorf("Expected Authorization header, got %q", gotAuth)
+	}
+	if gotSource != "backup-host" {
+		t.Errorf("Expected X-Source header, got %q", gotSource)
+	}
+	if gotUserAgent != "restic-kit/dev" {
+		t.Errorf("Expected default User-Agent header, got %q", gotUserAgent)
+	}
+}
+
+func TestNotifyHTTPActionSlack(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "http-slack-test*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.etc.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.etc.out"), []byte(`{"message_type":"summary","files_new":7,"files_changed":1,"files_unmodified":10,"data_added":2048}`), 0644)
+
+	var gotPath string
+	var payload slackPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpConfig := &NotifyHTTPConfig{URL: server.URL + "/services/T000/B000/XXX", Template: "slack"}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewNotifyHTTPAction(httpConfig)
+	if err := action.Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if gotPath != "/services/T000/B000/XXX" {
+		t.Errorf("Expected webhook URL to be unmodified, got %s", gotPath)
+	}
+	if payload.Text != "Backup Report: FAILURE" {
+		t.Errorf("Unexpected text: %q", payload.Text)
+	}
+	if len(payload.Attachments) != 1 || payload.Attachments[0].Color != "#e01e5a" {
+		t.Fatalf("Expected a single red attachment, got %+v", payload.Attachments)
+	}
+	blocks := payload.Attachments[0].Blocks
+	if len(blocks) != 2 {
+		t.Fatalf("Expected header and one section, got %d blocks", len(blocks))
+	}
+	if !strings.Contains(blocks[1].Text.Text, "backup etc") || !strings.Contains(blocks[1].Text.Text, "Files: 7 new, 1 changed, 10 unmodified") {
+		t.Errorf("Unexpected section text: %q", blocks[1].Text.Text)
+	}
+}
+
+func TestNotifyHTTPActionDiscord(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.etc.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.etc.out"), []byte(`{"message_type":"summary","files_new":7,"files_changed":1,"files_unmodified":10,"data_added":2048}`), 0644)
+
+	var gotPath string
+	var payload discordPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	httpConfig := &NotifyHTTPConfig{URL: server.URL + "/api/webhooks/1/abc", Template: "discord"}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if gotPath != "/api/webhooks/1/abc" {
+		t.Errorf("Expected webhook URL to be unmodified, got %s", gotPath)
+	}
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("Expected a single embed, got %+v", payload.Embeds)
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "Backup Report: SUCCESS" || embed.Color != 0x2eb886 {
+		t.Errorf("Unexpected embed: %+v", embed)
+	}
+	if len(embed.Fields) != 1 || embed.Fields[0].Name != "✅ backup etc" || !strings.Contains(embed.Fields[0].Value, "Files: 7 new") {
+		t.Errorf("Unexpected fields: %+v", embed.Fields)
+	}
+}
+
+func TestBuildDiscordPayloadTruncation(t *testing.T) {
+	var actions []restic.ActionResult
+	for i := 0; i < 40; i++ {
+		actions = append(actions, &restic.CheckActionResult{Name: "check", Success: false, Result: &restic.CheckResult{NumErrors: 1}})
+	}
+
+	embed := buildDiscordPayload(actions, false, "").Embeds[0]
+	if len(embed.Fields) != discordMaxFields {
+		t.Fatalf("Expected %d fields, got %d", discordMaxFields, len(embed.Fields))
+	}
+	last := embed.Fields[len(embed.Fields)-1]
+	if last.Name != "Truncated" || last.Value != "16 more actions not shown" {
+		t.Errorf("Unexpected truncation field: %+v", last)
+	}
+	if embed.Color != 0xe01e5a {
+		t.Errorf("Expected red color, got %x", embed.Color)
+	}
+
+	if got := truncateRunes(strings.Repeat("ä", 10), 5); got != "ääää…" {
+		t.Errorf("truncateRunes() = %q", got)
+	}
+}
+
+func TestNotifyHTTPActionTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	httpConfig := &NotifyHTTPConfig{URL: server.URL, Timeout: 50 * time.Millisecond}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestNotifyHTTPActionBasicAuth(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "monitor" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpConfig := &NotifyHTTPConfig{URL: server.URL, BasicAuth: "monitor:s3cret"}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false); err != nil {
+		t.Errorf("Expected success with basic auth, got %v", err)
+	}
+
+	// Passwords in the URL are redacted from errors
+	httpConfig = &NotifyHTTPConfig{URL: strings.Replace(server.URL, "http://", "http://monitor:wrong@", 1)}
+	if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+		t.Fatal(err)
+	}
+	err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false)
+	if err == nil || strings.Contains(err.Error(), "wrong") || !strings.Contains(err.Error(), "monitor:xxxxx@") {
+		t.Errorf("Expected error with redacted URL, got %v", err)
+	}
+
+	if err := ValidateNotifyHTTPConfig(&NotifyHTTPConfig{URL: server.URL, BasicAuth: "nopassword"}); err == nil {
+		t.Error("Expected error for invalid basic auth, got nil")
+	}
+}
+
+func TestNotifyHTTPActionAcceptStatus(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/elsewhere")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		acceptStatus []string
+		wantErr      bool
+	}{
+		{name: "default rejects redirects", acceptStatus: nil, wantErr: true},
+		{name: "single code", acceptStatus: []string{"200", "302"}, wantErr: false},
+		{name: "range", acceptStatus: []string{"200-399"}, wantErr: false},
+		{name: "other range", acceptStatus: []string{"400-499"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpConfig := &NotifyHTTPConfig{URL: server.URL, AcceptStatus: tt.acceptStatus}
+			if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+				t.Fatal(err)
+			}
+
+			err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"abc", "300-200", "99", "200-", "700"} {
+		if err := ValidateNotifyHTTPConfig(&NotifyHTTPConfig{URL: server.URL, AcceptStatus: []string{invalid}}); err == nil {
+			t.Errorf("Expected error for accept-status %q, got nil", invalid)
+		}
+	}
+}
+
+func TestNotifyHTTPActionRetries(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		wantCalls int
+		wantErr   string
+	}{
+		{name: "single attempt by default", statuses: []int{503, 200}, retries: 0, wantCalls: 1, wantErr: "status code: 503"},
+		{name: "recovers after 5xx", statuses: []int{503, 502, 200}, retries: 3, wantCalls: 3},
+		{name: "no retry on 4xx", statuses: []int{404, 200}, retries: 3, wantCalls: 1, wantErr: "status code: 404"},
+		{name: "exhausted retries", statuses: []int{503, 503, 500}, retries: 2, wantCalls: 3, wantErr: "giving up after 3 attempts: HTTP request to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[min(calls, len(tt.statuses)-1)])
+				calls++
+			}))
+			defer server.Close()
+
+			httpConfig := &NotifyHTTPConfig{URL: server.URL, Retries: tt.retries, RetryDelay: time.Millisecond}
+			if err := ValidateNotifyHTTPConfig(httpConfig); err != nil {
+				t.Fatal(err)
+			}
+
+			err := NewNotifyHTTPAction(httpConfig).Execute([]string{tmpDir}, false)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected success, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestBuildPayloadsRepoName(t *testing.T) {
+	actions := []restic.ActionResult{&restic.BackupActionResult{Name: "home", Success: true}}
+
+	report := buildHTTPReport(actions, true, "offsite")
+	if report.Repository != "offsite" {
+		t.Errorf("Expected repository in report, got %q", report.Repository)
+	}
+	payload, _ := json.Marshal(buildHTTPReport(actions, true, ""))
+	if strings.Contains(string(payload), "repository") {
+		t.Errorf("Expected no repository field without a name, got %s", payload)
+	}
+
+	if title := buildSlackPayload(actions, true, "offsite").Text; title != "Backup Report (offsite): SUCCESS" {
+		t.Errorf("Unexpected Slack title %q", title)
+	}
+	if title := buildDiscordPayload(actions, true, "offsite").Embeds[0].Title; title != "Backup Report (offsite): SUCCESS" {
+		t.Errorf("Unexpected Discord title %q", title)
+	}
+}
+
+func TestNotifyHTTPActionExitOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Without the flag, a delivered notification is a success
+	if err := NewNotifyHTTPAction(&NotifyHTTPConfig{URL: server.URL}).Execute([]string{tmpDir}, false); err != nil {
+		t.Errorf("Expected no error without --exit-on-failure, got %v", err)
+	}
+
+	err := NewNotifyHTTPAction(&NotifyHTTPConfig{URL: server.URL, ExitOnFailure: true}).Execute([]string{tmpDir}, false)
+	if err == nil || err.Error() != "backup failed: 1 of 1 actions failed (backup home)" {
+		t.Errorf("Expected backup failed error, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the notification to be sent before failing, got %d requests", requests)
+	}
+}
+
+func TestNotifyHTTPActionOnFailureOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &NotifyHTTPConfig{URL: server.URL, OnFailureOnly: true}
+	if err := ValidateNotifyHTTPConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewNotifyHTTPAction(cfg).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request for a successful backup, got %d", requests)
+	}
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	if err := NewNotifyHTTPAction(cfg).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a request for a failed backup, got %d", requests)
+	}
+
+	if err := ValidateNotifyHTTPConfig(&NotifyHTTPConfig{URL: server.URL, Start: true, OnFailureOnly: true}); err == nil {
+		t.Error("Expected start with on-failure-only to be rejected")
+	}
+}
+
+func TestNotifyHTTPActionResultFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	resultFile := filepath.Join(t.TempDir(), "result.json")
+	cfg := &NotifyHTTPConfig{URL: server.URL, ResultFile: resultFile}
+	if err := ValidateNotifyHTTPConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewNotifyHTTPAction(cfg).Execute([]string{tmpDir}, false); ExitCode(err) != ExitNotifyError {
+		t.Fatalf("Expected notification error for a rejected notification, got %v", err)
+	}
+
+	// The result is written even though the notification failed
+	data, err := os.ReadFile(resultFile)
+	if err != nil {
+		t.Fatalf("Expected result file: %v", err)
+	}
+	var result ResultFile
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Expected JSON result file, got %q: %v", data, err)
+	}
+	if result.Command != "notify-http" || result.Success || result.Timestamp == "" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Actions) != 1 || result.Actions[0].Name != "home" || result.Actions[0].Success {
+		t.Errorf("Unexpected actions: %+v", result.Actions)
+	}
+	if !strings.Contains(result.NotifyError, "500") {
+		t.Errorf("Expected notification error in result file, got %q", result.NotifyError)
+	}
+}
+
+func TestNotifyHTTPActionProxy(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	cfg := &NotifyHTTPConfig{URL: "http://hc.invalid/ping/abc", Proxy: proxy.URL}
+	if err := ValidateNotifyHTTPConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewNotifyHTTPAction(cfg).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected notification through the proxy, got %v", err)
+	}
+	if proxiedURL != "http://hc.invalid/ping/abc" {
+		t.Errorf("Expected proxied request for the ping URL, got %q", proxiedURL)
+	}
+
+	if err := ValidateNotifyHTTPConfig(&NotifyHTTPConfig{URL: "http://hc.invalid/ping", Proxy: "proxy:3128"}); err == nil {
+		t.Error("Expected proxy without scheme to be rejected")
+	}
+}
+
+func TestNotifyHTTPActionDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(""), 0644)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &NotifyHTTPConfig{
+		URL:        server.URL + "/ping",
+		Method:     "POST",
+		RawHeaders: []string{"X-Team: ops"},
+		BasicAuth:  "monitor:s3cret",
+	}
+	if err := ValidateNotifyHTTPConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewNotifyHTTPAction(cfg).Execute([]string{tmpDir}, true)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("Expected no error in dry-run mode, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request in dry-run mode, got %d", requests)
+	}
+	for _, want := range []string{
+		"DRY RUN: Would send HTTP POST request to " + server.URL + "/ping/fail\n",
+		"DRY RUN: Header: X-Team: ops\n",
+		"DRY RUN: Header: Authorization: xxxxx\n",
+		"DRY RUN: Body preview:\n",
+		`"success":false`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in dry-run output, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "s3cret") {
+		t.Errorf("Expected credentials to be redacted, got:\n%s", output)
+	}
+}
+
+func TestAppendURLSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		suffix string
+		want   string
+	}{
+		{name: "plain url", url: "https://hc.example.com/ping/abc", suffix: "/fail", want: "https://hc.example.com/ping/abc/fail"},
+		{name: "trailing slash", url: "https://hc.example.com/ping/abc/", suffix: "/fail", want: "https://hc.example.com/ping/abc/fail"},
+		{name: "query string", url: "https://hc.example.com/ping?token=x", suffix: "/fail", want: "https://hc.example.com/ping/fail?token=x"},
+		{name: "fragment", url: "https://hc.example.com/ping#frag", suffix: "/fail", want: "https://hc.example.com/ping/fail#frag"},
+		{name: "host only", url: "https://hc.example.com", suffix: "/start", want: "https://hc.example.com/start"},
+		{name: "empty suffix", url: "https://hc.example.com/ping?token=x", suffix: "", want: "https://hc.example.com/ping?token=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendURLSuffix(tt.url, tt.suffix); got != tt.want {
+				t.Errorf("appendURLSuffix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyHTTPActionSuffixes(t *testing.T) {
+	successDir, err := os.MkdirTemp("", "http-suffix-ok*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(successDir)
+	os.WriteFile(filepath.Join(successDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(successDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	failDir, err := os.MkdirTemp("", "http-suffix-fail*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(failDir)
+	os.WriteFile(filepath.Join(failDir, "check.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(failDir, "check.out"), []byte(`{"message_type":"summary","num_errors":1}`), 0644)
+
+	var gotPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		config   *NotifyHTTPConfig
+		args     []string
+		wantPath string
+	}{
+		{name: "default start", config: &NotifyHTTPConfig{URL: server.URL + "/ping/abc", Start: true}, args: nil, wantPath: "/ping/abc/start"},
+		{name: "default success", config: &NotifyHTTPConfig{URL: server.URL + "/ping/abc"}, args: []string{successDir}, wantPath: "/ping/abc"},
+		{name: "default fail", config: &NotifyHTTPConfig{URL: server.URL + "/ping/abc"}, args: []string{failDir}, wantPath: "/ping/abc/fail"},
+		{name: "custom success", config: &NotifyHTTPConfig{URL: server.URL + "/ping/abc", SuccessSuffix: "/up"}, args: []string{successDir}, wantPath: "/ping/abc/up"},
+		{name: "custom fail", config: &NotifyHTTPConfig{URL: server.URL + "/ping/abc", FailSuffix: "/down"}, args: []string{failDir}, wantPath: "/ping/abc/down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateNotifyHTTPConfig(tt.config); err != nil {
+				t.Fatal(err)
+			}
+			gotPaths = nil
+			action := NewNotifyHTTPAction(tt.config)
+			if err := action.Execute(tt.args, false); err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+			if len(gotPaths) != 1 || gotPaths[0] != tt.wantPath {
+				t.Errorf("Expected request to %s, got %v", tt.wantPath, gotPaths)
+			}
+		})
+	}
+}
+
 func TestValidateNotifyHTTPConfig(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -102,11 +829,81 @@ func TestValidateNotifyHTTPConfig(t *testing.T) {
 			config:  &NotifyHTTPConfig{URL: "https://example.com/notify"},
 			wantErr: false,
 		},
+		{
+			name:    "post method",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", Method: "POST"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid method",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", Method: "DELETE"},
+			wantErr: true,
+		},
+		{
+			name:    "valid headers",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", RawHeaders: []string{"Authorization: Bearer abc"}},
+			wantErr: false,
+		},
+		{
+			name:    "header without colon",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", RawHeaders: []string{"Authorization Bearer abc"}},
+			wantErr: true,
+		},
+		{
+			name:    "header without key",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", RawHeaders: []string{": value"}},
+			wantErr: true,
+		},
+		{
+			name:    "slack template",
+			config:  &NotifyHTTPConfig{URL: "https://hooks.slack.com/services/x", Template: "slack"},
+			wantErr: false,
+		},
+		{
+			name:    "discord template",
+			config:  &NotifyHTTPConfig{URL: "https://discord.com/api/webhooks/x", Template: "discord"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown template",
+			config:  &NotifyHTTPConfig{URL: "https://example.com/notify", Template: "teams"},
+			wantErr: true,
+		},
+		{
+			name:    "start with template",
+			config:  &NotifyHTTPConfig{URL: "https://hooks.slack.com/services/x", Template: "slack", Start: true},
+			wantErr: true,
+		},
 		{
 			name:    "missing url",
 			config:  &NotifyHTTPConfig{},
 			wantErr: true,
 		},
+		{
+			name:    "url without scheme",
+			config:  &NotifyHTTPConfig{URL: "hc-ping.com/abc"},
+			wantErr: true,
+		},
+		{
+			name:    "url with bad scheme",
+			config:  &NotifyHTTPConfig{URL: "htttp://hc-ping.com/abc"},
+			wantErr: true,
+		},
+		{
+			name:    "url without host",
+			config:  &NotifyHTTPConfig{URL: "https:///abc"},
+			wantErr: true,
+		},
+		{
+			name:    "url ending in fail suffix",
+			config:  &NotifyHTTPConfig{URL: "https://hc-ping.com/abc/fail"},
+			wantErr: true,
+		},
+		{
+			name:    "valid http url with port",
+			config:  &NotifyHTTPConfig{URL: "http://localhost:8080/ping"},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
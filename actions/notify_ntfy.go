@@ -0,0 +1,160 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"restic-kit/shared"
+)
+
+// NotifyNtfyConfig holds configuration for ntfy notifications
+type NotifyNtfyConfig struct {
+	Server   string
+	Topic    string
+	Token    string
+	RepoName string
+	Timeout  time.Duration
+}
+
+// ValidateNotifyNtfyConfig validates the ntfy notification config
+func ValidateNotifyNtfyConfig(cfg *NotifyNtfyConfig) error {
+	if cfg.Server == "" {
+		cfg.Server = "https://ntfy.sh"
+	}
+	if cfg.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	if strings.Contains(cfg.Topic, "/") {
+		return fmt.Errorf("topic must not contain a slash")
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must be non-negative")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return nil
+}
+
+type NotifyNtfyAction struct {
+	*BaseAction
+	config *NotifyNtfyConfig
+}
+
+func NewNotifyNtfyAction(cfg *NotifyNtfyConfig) *NotifyNtfyAction {
+	return &NotifyNtfyAction{
+		BaseAction: NewBaseAction("notify-ntfy"),
+		config:     cfg,
+	}
+}
+
+func (a *NotifyNtfyAction) Execute(args []string, dryRun bool) error {
+	if len(args) != 1 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("notify-ntfy requires exactly one argument: the path to the log directory"))
+	}
+
+	logDir := args[0]
+
+	actions, overallSuccess, err := analyzeBackupResults(logDir)
+	if err != nil {
+		return err
+	}
+
+	title := reportTitle(a.config.RepoName, overallSuccess)
+	body := generateBodyFromActions(actions, overallSuccess, nil, 0, nil, nil)
+
+	// Failures are pushed with a higher priority so they stand out
+	priority := "default"
+	tags := "white_check_mark"
+	if !overallSuccess {
+		priority = "high"
+		tags = "rotating_light"
+	}
+
+	url := strings.TrimSuffix(a.config.Server, "/") + "/" + a.config.Topic
+
+	if dryRun {
+		fmt.Println("DRY RUN: Would send ntfy notification to", url)
+		fmt.Printf("DRY RUN: Title: %s, Priority: %s, Tags: %s\n", title, priority, tags)
+		fmt.Println("DRY RUN: Message preview:")
+		fmt.Println(body)
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request to %s: %w", url, err)
+	}
+	req.Header.Set("User-Agent", shared.UserAgent())
+	req.Header.Set("Title", title)
+	req.Header.Set("Priority", priority)
+	req.Header.Set("Tags", tags)
+	if a.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.config.Token)
+	}
+
+	shared.Verbosef("Sending ntfy notification to %s\n", url)
+	client := &http.Client{Timeout: a.config.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return WithExitCode(ExitNotifyError, fmt.Errorf("ntfy request to %s timed out after %v", url, a.config.Timeout))
+		}
+		return WithExitCode(ExitNotifyError, fmt.Errorf("failed to send ntfy notification to %s: %w", url, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return WithExitCode(ExitNotifyError, fmt.Errorf("ntfy request to %s failed with status code: %d", url, resp.StatusCode))
+	}
+
+	shared.Infof("ntfy notification sent successfully to %s\n", url)
+	return nil
+}
+
+func NewNotifyNtfyCmd() *cobra.Command {
+	var server, topic, token string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "notify-ntfy [log-directory]",
+		Short: "Send an ntfy push notification",
+		Long: `Send a backup report for the logs in the specified directory to an ntfy topic.
+Failures are sent with a high priority and a rotating_light tag.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
+			ntfyConfig := &NotifyNtfyConfig{
+				Server:   server,
+				Topic:    topic,
+				Token:    token,
+				RepoName: repoName,
+				Timeout:  timeout,
+			}
+
+			if err := ValidateNotifyNtfyConfig(ntfyConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid ntfy config: %w", err))
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			action := NewNotifyNtfyAction(ntfyConfig)
+			return action.Execute(args, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "https://ntfy.sh", "ntfy server URL")
+	cmd.Flags().StringVar(&topic, "topic", "", "ntfy topic to publish to (required)")
+	cmd.Flags().StringVar(&token, "token", "", "Access token for protected topics")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for the ntfy request")
+	cmd.MarkFlagRequired("topic")
+
+	return cmd
+}
@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyNtfyAction(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","files_new":1}`), 0644)
+
+	var gotPath, gotBody string
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected POST request, got %s", r.Method)
+		}
+		gotPath = r.URL.Path
+		gotHeaders = r.Header
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &NotifyNtfyConfig{Server: server.URL + "/", Topic: "backups", Token: "tk_secret"}
+	if err := ValidateNotifyNtfyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewNotifyNtfyAction(cfg).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if gotPath != "/backups" {
+		t.Errorf("Unexpected request path: %s", gotPath)
+	}
+	if gotHeaders.Get("Title") != "Backup Report: FAILURE" || gotHeaders.Get("Priority") != "high" || gotHeaders.Get("Tags") != "rotating_light" {
+		t.Errorf("Unexpected headers: %v", gotHeaders)
+	}
+	if gotHeaders.Get("Authorization") != "Bearer tk_secret" {
+		t.Errorf("Expected bearer token, got %q", gotHeaders.Get("Authorization"))
+	}
+	if !strings.Contains(gotBody, "❌ backup home") {
+		t.Errorf("Unexpected body:\n%s", gotBody)
+	}
+}
+
+func TestNotifyNtfyActionTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	cfg := &NotifyNtfyConfig{Server: server.URL, Topic: "backups", Timeout: 50 * time.Millisecond}
+	if err := ValidateNotifyNtfyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewNotifyNtfyAction(cfg).Execute([]string{tmpDir}, false)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestValidateNotifyNtfyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *NotifyNtfyConfig
+		wantErr bool
+	}{
+		{name: "valid config", config: &NotifyNtfyConfig{Topic: "backups"}, wantErr: false},
+		{name: "missing topic", config: &NotifyNtfyConfig{Server: "https://ntfy.example.com"}, wantErr: true},
+		{name: "topic with slash", config: &NotifyNtfyConfig{Topic: "a/b"}, wantErr: true},
+		{name: "negative timeout", config: &NotifyNtfyConfig{Topic: "backups", Timeout: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNotifyNtfyConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateNotifyNtfyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.config.Server != "https://ntfy.sh" {
+				t.Errorf("Expected default server, got %s", tt.config.Server)
+			}
+			if err == nil && tt.config.Timeout != 30*time.Second {
+				t.Errorf("Expected default timeout, got %v", tt.config.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+// NotifyTelegramConfig holds configuration for Telegram notifications
+type NotifyTelegramConfig struct {
+	BotToken string
+	ChatID   string
+	APIURL   string
+	RepoName string
+	Timeout  time.Duration
+}
+
+// ValidateNotifyTelegramConfig validates the Telegram notification config
+func ValidateNotifyTelegramConfig(cfg *NotifyTelegramConfig) error {
+	if cfg.BotToken == "" {
+		return fmt.Errorf("bot-token is required")
+	}
+	if cfg.ChatID == "" {
+		return fmt.Errorf("chat-id is required")
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.telegram.org"
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must be non-negative")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return nil
+}
+
+// telegramMessage is the JSON body of a Telegram sendMessage request
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+type NotifyTelegramAction struct {
+	*BaseAction
+	config *NotifyTelegramConfig
+}
+
+func NewNotifyTelegramAction(cfg *NotifyTelegramConfig) *NotifyTelegramAction {
+	return &NotifyTelegramAction{
+		BaseAction: NewBaseAction("notify-telegram"),
+		config:     cfg,
+	}
+}
+
+func (a *NotifyTelegramAction) Execute(args []string, dryRun bool) error {
+	if len(args) != 1 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("notify-telegram requires exactly one argument: the path to the log directory"))
+	}
+
+	logDir := args[0]
+
+	actions, overallSuccess, err := analyzeBackupResults(logDir)
+	if err != nil {
+		return err
+	}
+
+	text := buildTelegramMessage(actions, overallSuccess, a.config.RepoName)
+
+	if dryRun {
+		fmt.Println("DRY RUN: Would send Telegram message to chat", a.config.ChatID)
+		fmt.Println("DRY RUN: Message preview:")
+		fmt.Println(text)
+		return nil
+	}
+
+	payload, err := json.Marshal(telegramMessage{
+		ChatID:    a.config.ChatID,
+		Text:      text,
+		ParseMode: "MarkdownV2",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram message: %w", err)
+	}
+
+	// The URL contains the bot token, so it is never included in errors
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(a.config.APIURL, "/"), a.config.BotToken)
+	shared.Verbosef("Sending Telegram message to chat %s\n", a.config.ChatID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", redactToken(err, a.config.BotToken))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", shared.UserAgent())
+
+	client := &http.Client{Timeout: a.config.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return WithExitCode(ExitNotifyError, fmt.Errorf("telegram API request timed out after %v", a.config.Timeout))
+		}
+		return WithExitCode(ExitNotifyError, fmt.Errorf("failed to send Telegram message: %w", redactToken(err, a.config.BotToken)))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return WithExitCode(ExitNotifyError, fmt.Errorf("telegram API request failed with status code: %d", resp.StatusCode))
+	}
+
+	shared.Infof("Telegram notification sent successfully\n")
+	return nil
+}
+
+// maxTelegramMessageLength is the longest message text the Bot API accepts
+const maxTelegramMessageLength = 4096
+
+// buildTelegramMessage renders the action results as a MarkdownV2 message.
+// Actions that would push the message over the Bot API limit are left out
+// and counted in a final line instead, so the request is not rejected.
+func buildTelegramMessage(actions []restic.ActionResult, success bool, repoName string) string {
+	var text strings.Builder
+
+	text.WriteString(fmt.Sprintf("*%s*\n", escapeTelegramMarkdown(reportTitle(repoName, success))))
+
+	// Leave room for the line about omitted actions
+	limit := maxTelegramMessageLength - 64
+	for i, action := range actions {
+		statusEmoji := "✅"
+		if !action.IsSuccess() {
+			statusEmoji = "❌"
+		}
+		entry := fmt.Sprintf("\n%s *%s %s*\n", statusEmoji,
+			escapeTelegramMarkdown(actionTypeOf(action)), escapeTelegramMarkdown(action.GetActionName()))
+		if summary := actionSummaryText(action); summary != "" {
+			entry += escapeTelegramMarkdown(summary) + "\n"
+		}
+		if utf8.RuneCountInString(text.String())+utf8.RuneCountInString(entry) > limit {
+			text.WriteString(escapeTelegramMarkdown(fmt.Sprintf("\n… and %d more actions, see the logs for details", len(actions)-i)) + "\n")
+			break
+		}
+		text.WriteString(entry)
+	}
+
+	return text.String()
+}
+
+// escapeTelegramMarkdown escapes the characters reserved by MarkdownV2
+func escapeTelegramMarkdown(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// redactToken removes the bot token from an error message
+func redactToken(err error, token string) error {
+	return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), token, "<redacted>"))
+}
+
+func NewNotifyTelegramCmd() *cobra.Command {
+	var botToken, chatID, apiURL string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "notify-telegram [log-directory]",
+		Short: "Send a Telegram notification",
+		Long:  `Send a backup report for the logs in the specified directory to a Telegram chat using the Bot API.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
+			telegramConfig := &NotifyTelegramConfig{
+				BotToken: botToken,
+				ChatID:   chatID,
+				APIURL:   apiURL,
+				RepoName: repoName,
+				Timeout:  timeout,
+			}
+
+			if err := ValidateNotifyTelegramConfig(telegramConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid Telegram config: %w", err))
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			action := NewNotifyTelegramAction(telegramConfig)
+			return action.Execute(args, dryRun)
+		},
+	}
+
+	cmd.Flags().StringVar(&botToken, "bot-token", "", "Telegram bot token (required)")
+	cmd.Flags().StringVar(&chatID, "chat-id", "", "Telegram chat ID to send the message to (required)")
+	cmd.Flags().StringVar(&apiURL, "api-url", "https://api.telegram.org", "Telegram Bot API base URL")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Timeout for the Telegram API request")
+	cmd.MarkFlagRequired("bot-token")
+	cmd.MarkFlagRequired("chat-id")
+
+	return cmd
+}
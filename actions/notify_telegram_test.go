@@ -0,0 +1,153 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+func TestNotifyTelegramAction(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","files_new":1,"files_changed":2,"files_unmodified":100,"data_added":2048}`), 0644)
+
+	var received telegramMessage
+	var path, userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		userAgent = r.Header.Get("User-Agent")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &NotifyTelegramConfig{BotToken: "123:abc", ChatID: "-100", APIURL: server.URL}
+	if err := ValidateNotifyTelegramConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewNotifyTelegramAction(cfg).Execute([]string{tmpDir}, false); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("Unexpected request path: %s", path)
+	}
+	if userAgent != shared.UserAgent() {
+		t.Errorf("Expected User-Agent %q, got %q", shared.UserAgent(), userAgent)
+	}
+	if received.ChatID != "-100" || received.ParseMode != "MarkdownV2" {
+		t.Errorf("Unexpected message: %+v", received)
+	}
+	if !strings.Contains(received.Text, "*Backup Report: SUCCESS*") || !strings.Contains(received.Text, "✅ *backup home*") {
+		t.Errorf("Unexpected message text:\n%s", received.Text)
+	}
+
+	// Non-2xx responses are reported as errors
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer failServer.Close()
+
+	cfg.APIURL = failServer.URL
+	if err := NewNotifyTelegramAction(cfg).Execute([]string{tmpDir}, false); err == nil {
+		t.Error("Expected error for failed request, got nil")
+	}
+}
+
+func TestNotifyTelegramActionTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	cfg := &NotifyTelegramConfig{BotToken: "123:abc", ChatID: "-100", APIURL: server.URL, Timeout: 50 * time.Millisecond}
+	if err := ValidateNotifyTelegramConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewNotifyTelegramAction(cfg).Execute([]string{tmpDir}, false)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestBuildTelegramMessageTruncation(t *testing.T) {
+	var actions []restic.ActionResult
+	for i := range 500 {
+		actions = append(actions, &restic.BackupActionResult{Name: fmt.Sprintf("host-%03d", i), Success: true})
+	}
+
+	text := buildTelegramMessage(actions, true, "")
+	if n := utf8.RuneCountInString(text); n > maxTelegramMessageLength {
+		t.Errorf("Expected at most %d characters, got %d", maxTelegramMessageLength, n)
+	}
+	if !strings.Contains(text, "more actions, see the logs for details") {
+		t.Errorf("Expected a note about omitted actions, got:\n%s", text)
+	}
+	if !strings.Contains(text, "✅ *backup host\\-000*") {
+		t.Errorf("Expected the first actions to be kept, got:\n%s", text)
+	}
+}
+
+func TestBuildTelegramMessageEscaping(t *testing.T) {
+	actions := []restic.ActionResult{
+		&restic.BackupActionResult{Name: "docker-confs", Success: false},
+	}
+
+	text := buildTelegramMessage(actions, false, "")
+	if !strings.Contains(text, "❌ *backup docker\\-confs*") {
+		t.Errorf("Expected escaped action name, got:\n%s", text)
+	}
+
+	if got := escapeTelegramMarkdown("1.5 MB (2 files)!"); got != "1\\.5 MB \\(2 files\\)\\!" {
+		t.Errorf("escapeTelegramMarkdown() = %q", got)
+	}
+}
+
+func TestValidateNotifyTelegramConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *NotifyTelegramConfig
+		wantErr bool
+	}{
+		{name: "valid config", config: &NotifyTelegramConfig{BotToken: "123:abc", ChatID: "42"}, wantErr: false},
+		{name: "missing bot token", config: &NotifyTelegramConfig{ChatID: "42"}, wantErr: true},
+		{name: "missing chat id", config: &NotifyTelegramConfig{BotToken: "123:abc"}, wantErr: true},
+		{name: "negative timeout", config: &NotifyTelegramConfig{BotToken: "123:abc", ChatID: "42", Timeout: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNotifyTelegramConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateNotifyTelegramConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.config.APIURL != "https://api.telegram.org" {
+				t.Errorf("Expected default API URL, got %s", tt.config.APIURL)
+			}
+			if err == nil && tt.config.Timeout != 30*time.Second {
+				t.Errorf("Expected default timeout, got %v", tt.config.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// PreviewConfig holds configuration for previewing the email report
+type PreviewConfig struct {
+	Format          string
+	RepoName        string
+	MaxSnapshotRows int
+	Columns         []string
+	Timezone        string
+	Location        *time.Location
+}
+
+// ValidatePreviewConfig validates the preview config
+func ValidatePreviewConfig(cfg *PreviewConfig) error {
+	if cfg.Format == "" {
+		cfg.Format = "text"
+	}
+	if cfg.Format != "text" && cfg.Format != "html" {
+		return fmt.Errorf("format must be either text or html")
+	}
+	if cfg.MaxSnapshotRows < 0 {
+		return fmt.Errorf("max-snapshot-rows must be non-negative")
+	}
+	if _, err := parseSnapshotColumns(cfg.Columns); err != nil {
+		return err
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+		}
+		cfg.Location = loc
+	}
+	return nil
+}
+
+// PreviewAction prints the report notify-email would send
+type PreviewAction struct {
+	*BaseAction
+	config *PreviewConfig
+}
+
+func NewPreviewAction(cfg *PreviewConfig) *PreviewAction {
+	return &PreviewAction{
+		BaseAction: NewBaseAction("preview"),
+		config:     cfg,
+	}
+}
+
+func (a *PreviewAction) Execute(args []string) error {
+	if len(args) == 0 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("preview requires at least one log directory"))
+	}
+
+	columns, err := parseSnapshotColumns(a.config.Columns)
+	if err != nil {
+		return err
+	}
+
+	actions, overallSuccess, err := analyzeBackupResults(args...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Subject: %s\n\n", reportTitle(a.config.RepoName, overallSuccess))
+	if a.config.Format == "html" {
+		fmt.Print(generateHTMLBodyFromActions(actions, overallSuccess, nil, a.config.MaxSnapshotRows, a.config.Location, columns))
+		return nil
+	}
+	fmt.Print(generateBodyFromActions(actions, overallSuccess, nil, a.config.MaxSnapshotRows, a.config.Location, columns))
+	return nil
+}
+
+func NewPreviewCmd() *cobra.Command {
+	var format, timezone string
+	var maxSnapshotRows int
+	var columns []string
+
+	cmd := &cobra.Command{
+		Use:   "preview [log-directory...]",
+		Short: "Print the email report without sending it",
+		Long: `Parse the logs in the given directories and print the subject and body notify-email would send.
+No SMTP settings are needed, which makes it easy to check the parsing against a real log directory.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoName, _ := cmd.Flags().GetString("repo-name")
+
+			logDirs, err := resolveLogDirs(args, "")
+			if err != nil {
+				return err
+			}
+
+			previewConfig := &PreviewConfig{
+				Format:          format,
+				RepoName:        repoName,
+				MaxSnapshotRows: maxSnapshotRows,
+				Columns:         columns,
+				Timezone:        timezone,
+			}
+
+			if err := ValidatePreviewConfig(previewConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid preview config: %w", err))
+			}
+
+			action := NewPreviewAction(previewConfig)
+			return action.Execute(logDirs)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "Body format: text or html")
+	cmd.Flags().IntVar(&maxSnapshotRows, "max-snapshot-rows", 0, "Show only the newest N snapshots per path in the snapshot table (0 shows all)")
+	cmd.Flags().StringSliceVar(&columns, "columns", nil, "Ordered, comma-separated columns of the snapshot table, as on notify-email")
+	cmd.Flags().StringVar(&timezone, "timezone", "", "Show snapshot times in this IANA timezone, e.g. UTC or America/New_York (default: as recorded)")
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewAction(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "backup.home.out"), []byte(`{"message_type":"summary","files_new":3}`), 0644)
+
+	cfg := &PreviewConfig{RepoName: "nas"}
+	if err := ValidatePreviewConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := NewPreviewAction(cfg).Execute([]string{tmpDir})
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(output, "Subject: Backup Report (nas): SUCCESS\n\nOverall Status: SUCCESS\n") {
+		t.Errorf("Expected subject and body, got:\n%s", output)
+	}
+	if !strings.Contains(output, "✅ backup home\n  Files: 3 new") {
+		t.Errorf("Expected backup section, got:\n%s", output)
+	}
+
+	if err := NewPreviewAction(cfg).Execute(nil); err == nil {
+		t.Error("Expected error without log directory")
+	}
+}
+
+func TestValidatePreviewConfig(t *testing.T) {
+	if err := ValidatePreviewConfig(&PreviewConfig{Format: "pdf"}); err == nil {
+		t.Error("Expected error for invalid format")
+	}
+	if err := ValidatePreviewConfig(&PreviewConfig{MaxSnapshotRows: -1}); err == nil {
+		t.Error("Expected error for negative max-snapshot-rows")
+	}
+	cfg := &PreviewConfig{}
+	if err := ValidatePreviewConfig(cfg); err != nil || cfg.Format != "text" {
+		t.Errorf("Expected text format by default, got %q (%v)", cfg.Format, err)
+	}
+}
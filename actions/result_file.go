@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+// ResultFile is the machine-readable overall result written with
+// --result-file, for tools that should not parse the console output
+type ResultFile struct {
+	Command     string             `json:"command"`
+	Repository  string             `json:"repository,omitempty"`
+	Success     bool               `json:"success"`
+	Timestamp   string             `json:"timestamp"`
+	Actions     []HTTPActionReport `json:"actions,omitempty"`
+	Violations  []AuditCheckResult `json:"violations,omitempty"`
+	NotifyError string             `json:"notify_error,omitempty"`
+}
+
+// newResultFile builds the result of a notify command from the parsed
+// action results
+func newResultFile(command, repoName string, actions []restic.ActionResult, success bool) *ResultFile {
+	return &ResultFile{
+		Command:    command,
+		Repository: repoName,
+		Success:    success,
+		Actions:    buildHTTPReport(actions, success, repoName).Actions,
+	}
+}
+
+// writeResultFile writes the result as JSON to path, which is a no-op if
+// path is empty. notifyErr is recorded in the file, so a failed
+// notification does not hide the result. The file is replaced atomically,
+// readers never see a partial document.
+func writeResultFile(path string, result *ResultFile, notifyErr error, dryRun bool) error {
+	if path == "" {
+		return nil
+	}
+	if dryRun {
+		fmt.Fprintln(shared.LogOutput(), "DRY RUN: Would write result file:", path)
+		return nil
+	}
+
+	result.Timestamp = time.Now().Format(time.RFC3339)
+	if notifyErr != nil {
+		result.NotifyError = notifyErr.Error()
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".restic-kit-result-*")
+	if err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	return nil
+}
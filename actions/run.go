@@ -0,0 +1,148 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"restic-kit/shared"
+)
+
+// RunConfig holds configuration for running a restic command
+type RunConfig struct {
+	LogDir string
+}
+
+// ValidateRunConfig validates the run config
+func ValidateRunConfig(cfg *RunConfig) error {
+	if cfg.LogDir == "" {
+		return fmt.Errorf("log-dir is required")
+	}
+	return nil
+}
+
+type RunAction struct {
+	*BaseAction
+	config *RunConfig
+}
+
+func NewRunAction(cfg *RunConfig) *RunAction {
+	return &RunAction{
+		BaseAction: NewBaseAction("run"),
+		config:     cfg,
+	}
+}
+
+func (a *RunAction) Execute(args []string, dryRun bool) error {
+	if len(args) < 2 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("run requires a name and the command to run"))
+	}
+
+	name := args[0]
+	command := args[1:]
+	// Flag parsing stops at the name, so a separating "--" is passed through
+	if command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		return WithExitCode(ExitConfigError, fmt.Errorf("run requires a name and the command to run"))
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return WithExitCode(ExitConfigError, fmt.Errorf("invalid name %q: must not be empty or contain path separators", name))
+	}
+
+	base := filepath.Join(a.config.LogDir, name)
+	outFile, errFile, exitcodeFile := base+".out", base+".err", base+".exitcode"
+
+	if dryRun {
+		fmt.Printf("DRY RUN: Would run %s\n", strings.Join(command, " "))
+		fmt.Printf("DRY RUN: Would write %s, %s and %s\n", outFile, errFile, exitcodeFile)
+		return nil
+	}
+
+	if err := os.MkdirAll(a.config.LogDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory %s: %w", a.config.LogDir, err)
+	}
+
+	stdout, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outFile, err)
+	}
+	defer stdout.Close()
+
+	stderr, err := os.Create(errFile)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", errFile, err)
+	}
+	defer stderr.Close()
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	shared.Verbosef("Running %s\n", strings.Join(command, " "))
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			// The command could not be started, record it like a shell would
+			exitCode = 127
+			fmt.Fprintf(stderr, "restic-kit: %v\n", err)
+		} else {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+
+	if err := os.WriteFile(exitcodeFile, []byte(fmt.Sprintf("%d\n", exitCode)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exitcodeFile, err)
+	}
+
+	if exitCode != 0 {
+		return &ExitCodeError{Code: exitCode}
+	}
+
+	shared.Infof("Command %s completed, logs written to %s\n", name, a.config.LogDir)
+	return nil
+}
+
+func NewRunCmd() *cobra.Command {
+	var logDir string
+
+	cmd := &cobra.Command{
+		Use:   "run [name] -- [command...]",
+		Short: "Run a restic command and record its output",
+		Long: `Run the given command (usually a restic invocation) and write its stdout, stderr and exit code
+to <name>.out, <name>.err and <name>.exitcode in the log directory, as expected by the other commands.
+The exit code of the command is preserved.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runConfig := &RunConfig{
+				LogDir: logDir,
+			}
+
+			if err := ValidateRunConfig(runConfig); err != nil {
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid run config: %w", err))
+			}
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			// A failing wrapped command is not a usage error
+			cmd.SilenceUsage = true
+
+			action := NewRunAction(runConfig)
+			return action.Execute(args, dryRun)
+		},
+	}
+
+	// Flags after the name belong to the wrapped command
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVar(&logDir, "log-dir", "", "Directory to write the log files to (required)")
+	cmd.MarkFlagRequired("log-dir")
+
+	return cmd
+}
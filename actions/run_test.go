@@ -0,0 +1,71 @@
+package actions
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"restic-kit/restic"
+)
+
+func TestRunAction(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "logs")
+	action := NewRunAction(&RunConfig{LogDir: logDir})
+
+	err := action.Execute([]string{"backup.etc", "sh", "-c", "echo out; echo err >&2; exit 3"}, false)
+	var exitErr *ExitCodeError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("Expected exit code 3, got %v", err)
+	}
+
+	for file, expected := range map[string]string{
+		"backup.etc.out":      "out\n",
+		"backup.etc.err":      "err\n",
+		"backup.etc.exitcode": "3\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(logDir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q", file, expected, string(content))
+		}
+	}
+
+	// Successful commands write a zero exit code
+	if err := action.Execute([]string{"check", "--", "true"}, false); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if code, err := restic.ReadExitCode(filepath.Join(logDir, "check.exitcode")); err != nil || code != 0 {
+		t.Errorf("Expected exit code 0, got %d (%v)", code, err)
+	}
+
+	// Commands that cannot be started are recorded as failures
+	err = action.Execute([]string{"missing", "/does/not/exist"}, false)
+	if !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		t.Fatalf("Expected exit code 127, got %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(logDir, "missing.err"))
+	if !strings.Contains(string(content), "/does/not/exist") {
+		t.Errorf("Expected start error in err file, got %q", string(content))
+	}
+}
+
+func TestRunActionInvalidArgs(t *testing.T) {
+	action := NewRunAction(&RunConfig{LogDir: t.TempDir()})
+
+	if err := action.Execute([]string{"backup.etc"}, false); err == nil {
+		t.Error("Expected error for missing command, got nil")
+	}
+	if err := action.Execute([]string{"backup.etc", "--"}, false); err == nil {
+		t.Error("Expected error for missing command after --, got nil")
+	}
+	if err := action.Execute([]string{"../backup.etc", "true"}, false); err == nil {
+		t.Error("Expected error for name with path separator, got nil")
+	}
+	if err := ValidateRunConfig(&RunConfig{}); err == nil {
+		t.Error("Expected error for missing log-dir, got nil")
+	}
+}
@@ -0,0 +1,135 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"restic-kit/restic"
+	"restic-kit/shared"
+)
+
+// snapshotColumn is a column of the snapshots table in the email report
+type snapshotColumn struct {
+	header string
+	width  int
+	// left aligns the column to the left, numbers are aligned to the right
+	left  bool
+	value func(snap restic.Snapshot, loc *time.Location) string
+}
+
+// snapshotColumns are the columns selectable with --columns
+var snapshotColumns = map[string]snapshotColumn{
+	"time": {header: "Date & Time", width: 20, left: true, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return formatSnapshotTime(snap.Time, loc)
+	}},
+	"id": {header: "Snapshot", width: 8, left: true, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return snapshotShortID(snap)
+	}},
+	"new": {header: "New", width: 8, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return summaryCount(snap, snap.Summary.FilesNew)
+	}},
+	"modified": {header: "Modified", width: 8, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return summaryCount(snap, snap.Summary.FilesChanged)
+	}},
+	"total": {header: "Total Files", width: 12, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return summaryCount(snap, snap.Summary.TotalFilesProcessed)
+	}},
+	"added": {header: "Added Size", width: 12, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return summarySize(snap, snap.Summary.DataAdded)
+	}},
+	"totalsize": {header: "Total Size", width: 12, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return summarySize(snap, snap.Summary.TotalBytesProcessed)
+	}},
+	"duration": {header: "Duration", width: 8, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return snapshotDuration(snap)
+	}},
+	"host": {header: "Host", width: 12, left: true, value: func(snap restic.Snapshot, loc *time.Location) string {
+		return snap.Hostname
+	}},
+}
+
+// defaultSnapshotColumns is the column set of the snapshots table without
+// --columns
+var defaultSnapshotColumns = []string{"time", "id", "new", "modified", "total", "added", "totalsize"}
+
+// parseSnapshotColumns resolves the column names given with --columns. An
+// empty list selects the default columns.
+func parseSnapshotColumns(names []string) ([]snapshotColumn, error) {
+	if len(names) == 0 {
+		names = defaultSnapshotColumns
+	}
+
+	columns := make([]snapshotColumn, 0, len(names))
+	for _, name := range names {
+		column, ok := snapshotColumns[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q, expected one of time, new, modified, total, added, totalsize, duration, host, id", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// textSnapshotHeader returns the header and separator lines of the plain-text
+// snapshots table
+func textSnapshotHeader(columns []snapshotColumn) string {
+	headers := make([]string, len(columns))
+	separators := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.pad(column.header)
+		separators[i] = strings.Repeat("-", column.width)
+	}
+	return "  " + strings.Join(headers, " | ") + "\n" +
+		"  " + strings.Join(separators, " | ") + "\n"
+}
+
+// textSnapshotRow returns a row of the plain-text snapshots table
+func textSnapshotRow(columns []snapshotColumn, snap restic.Snapshot, loc *time.Location) string {
+	cells := make([]string, len(columns))
+	for i, column := range columns {
+		cells[i] = column.pad(column.value(snap, loc))
+	}
+	return "  " + strings.Join(cells, " | ") + "\n"
+}
+
+func (c snapshotColumn) pad(s string) string {
+	if c.left {
+		return fmt.Sprintf("%-*s", c.width, s)
+	}
+	return fmt.Sprintf("%*s", c.width, s)
+}
+
+// hasFileCounts reports whether a snapshot has a backup summary. Snapshots
+// taken before restic recorded summaries show zeros.
+func hasFileCounts(snap restic.Snapshot) bool {
+	return snap.Summary.FilesNew > 0 || snap.Summary.FilesChanged > 0 || snap.Summary.FilesUnmodified > 0
+}
+
+func summaryCount(snap restic.Snapshot, count int) string {
+	if !hasFileCounts(snap) {
+		return "0"
+	}
+	return fmt.Sprintf("%d", count)
+}
+
+func summarySize(snap restic.Snapshot, size int64) string {
+	if !hasFileCounts(snap) {
+		return "0 B"
+	}
+	return shared.FormatBytes(size)
+}
+
+// snapshotDuration returns how long the backup of a snapshot took, or "-"
+// if the summary has no start and end time
+func snapshotDuration(snap restic.Snapshot) string {
+	start, err := time.Parse(time.RFC3339Nano, snap.Summary.BackupStart)
+	if err != nil {
+		return "-"
+	}
+	end, err := time.Parse(time.RFC3339Nano, snap.Summary.BackupEnd)
+	if err != nil || end.Before(start) {
+		return "-"
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
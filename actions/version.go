@@ -0,0 +1,27 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"restic-kit/shared"
+)
+
+func NewVersionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print version information",
+		Long:  `Print the version, git commit and build date of this build.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			commit, buildDate := shared.Commit, shared.BuildDate
+			if commit == "" {
+				commit = "unknown"
+			}
+			if buildDate == "" {
+				buildDate = "unknown"
+			}
+			fmt.Printf("restic-kit %s (commit %s, built %s)\n", shared.VersionString(), commit, buildDate)
+		},
+	}
+}
@@ -1,38 +1,123 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"restic-kit/shared"
 )
 
 // WaitOnlineConfig holds configuration for waiting online
 type WaitOnlineConfig struct {
-	URL          string
-	Timeout      time.Duration
-	InitialDelay time.Duration
-	MaxDelay     time.Duration
+	URL            string
+	URLs           []string
+	Mode           string
+	CheckType      string
+	TCPAddress     string
+	Timeout        time.Duration
+	RequestTimeout time.Duration
+	Concurrency    int
+	InitialDelay   time.Duration
+	MaxDelay       time.Duration
+	BasicAuth      string
+	Proxy          string
+	ProxyURL       *url.URL
 }
 
+// defaultRequestTimeout is the timeout of a single check without
+// --request-timeout
+const defaultRequestTimeout = 10 * time.Second
+
 // ValidateWaitOnlineConfig validates the wait online config and sets defaults
 func ValidateWaitOnlineConfig(cfg *WaitOnlineConfig) error {
 	if cfg.URL == "" {
-		cfg.URL = "https://www.google.com"
+		if len(cfg.URLs) > 0 {
+			cfg.URL = cfg.URLs[0]
+		} else {
+			cfg.URL = "https://www.google.com"
+		}
+	}
+	if len(cfg.URLs) == 0 {
+		cfg.URLs = []string{cfg.URL}
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = "all"
+	}
+	if cfg.Mode != "all" && cfg.Mode != "any" {
+		return fmt.Errorf("mode must be either all or any")
 	}
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 5 * time.Minute
 	}
+	if cfg.RequestTimeout < 0 {
+		return fmt.Errorf("request-timeout must be non-negative")
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = defaultRequestTimeout
+	}
+	if cfg.Concurrency < 0 {
+		return fmt.Errorf("concurrency must be non-negative")
+	}
 	if cfg.InitialDelay == 0 {
 		cfg.InitialDelay = 1 * time.Second
 	}
 	if cfg.MaxDelay == 0 {
 		cfg.MaxDelay = 30 * time.Second
 	}
+	if cfg.CheckType == "" {
+		cfg.CheckType = "http"
+	}
+	if err := validateBasicAuth(cfg.BasicAuth); err != nil {
+		return err
+	}
+	proxyURL, err := parseProxy(cfg.Proxy)
+	if err != nil {
+		return err
+	}
+	cfg.ProxyURL = proxyURL
+	switch cfg.CheckType {
+	case "http":
+	case "tcp":
+		if cfg.TCPAddress == "" {
+			// Derive the addresses from the URLs, e.g. https://example.com -> example.com:443
+			for _, rawURL := range cfg.URLs {
+				if _, err := tcpAddressFromURL(rawURL); err != nil {
+					return err
+				}
+			}
+			if len(cfg.URLs) == 1 {
+				cfg.TCPAddress, _ = tcpAddressFromURL(cfg.URLs[0])
+			}
+		} else if _, _, err := net.SplitHostPort(cfg.TCPAddress); err != nil {
+			return fmt.Errorf("invalid tcp-address %q: %w", cfg.TCPAddress, err)
+		}
+	default:
+		return fmt.Errorf("check must be either http or tcp")
+	}
 	return nil
 }
 
+// tcpAddressFromURL derives a host:port address from a URL
+func tcpAddressFromURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("tcp-address is required when it cannot be derived from the url %q", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[bool]string{true: "443", false: "80"}[u.Scheme == "https"]
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
 type WaitOnlineAction struct {
 	*BaseAction
 	config *WaitOnlineConfig
@@ -47,60 +132,277 @@ func NewWaitOnlineAction(cfg *WaitOnlineConfig) *WaitOnlineAction {
 
 func (a *WaitOnlineAction) Execute(args []string) error {
 	if len(args) != 0 {
-		return fmt.Errorf("wait-online does not accept any arguments")
+		return WithExitCode(ExitConfigError, fmt.Errorf("wait-online does not accept any arguments"))
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second, // 10 second timeout for each request
+	targets := a.targets()
+	check := a.checkHTTP
+	if a.config.CheckType == "tcp" {
+		check = a.checkTCP
 	}
 
 	startTime := time.Now()
+	deadline := startTime.Add(a.config.Timeout)
 	delay := a.config.InitialDelay
 
+	// Every check is bound to the deadline as well, a check started just
+	// before it cannot outlast --timeout
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	// In "all" mode, targets that were reached once are not checked again.
+	// With a concurrency limit, each round starts with the targets after
+	// the ones the previous round started with.
+	pending := targets
+	offset := 0
+	networkUp := false
+	var notFound map[string]int
 	for {
-		resp, err := client.Get(a.config.URL)
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				fmt.Printf("Successfully reached %s after %v\n", a.config.URL, time.Since(startTime))
-				return nil
-			}
+		reached, failed, errs := checkConcurrently(pending, func(target string) error {
+			return check(ctx, target)
+		}, a.config.Concurrency, offset, deadline)
+		if a.config.Concurrency > 0 {
+			offset += a.config.Concurrency
+		}
+
+		if a.config.Mode == "any" && len(reached) > 0 {
+			shared.Infof("Successfully reached %s after %v\n", redactURL(reached[0]), time.Since(startTime))
+			return nil
+		}
+		if a.config.Mode != "any" && len(failed) == 0 {
+			shared.Infof("Successfully reached %s after %v\n", joinRedacted(targets), time.Since(startTime))
+			return nil
+		}
+		if a.config.Mode != "any" {
+			pending = failed
+		}
+
+		// A hostname that does not exist while the network is up is most
+		// likely a typo, and retrying until the timeout is pointless. In any
+		// mode this only applies once no target can resolve.
+		networkUp = networkUp || len(reached) > 0
+		notFound = countNotFound(failed, errs, notFound)
+		if err := unresolvableHost(failed, errs, notFound, networkUp, a.config.Mode == "any"); err != nil {
+			return err
 		}
 
-		if time.Since(startTime) >= a.config.Timeout {
-			return fmt.Errorf("timeout reached: could not reach %s within %v", a.config.URL, a.config.Timeout)
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("timeout reached: could not reach %s within %v", joinRedacted(pending), a.config.Timeout)
 		}
 
-		fmt.Printf("Failed to reach %s, retrying in %v...\n", a.config.URL, delay)
-		time.Sleep(delay)
+		// The last retry happens right at the deadline
+		wait := min(delay, time.Until(deadline))
+		shared.Infof("Failed to reach %s, retrying in %v...\n", joinRedacted(pending), wait)
+		time.Sleep(wait)
+		delay = shared.NextBackoff(delay, a.config.MaxDelay)
+	}
+}
+
+// targets returns the URLs or TCP addresses to check
+func (a *WaitOnlineAction) targets() []string {
+	urls := a.config.URLs
+	if len(urls) == 0 {
+		urls = []string{a.config.URL}
+	}
+	if a.config.CheckType != "tcp" {
+		return urls
+	}
+	if a.config.TCPAddress != "" {
+		return []string{a.config.TCPAddress}
+	}
 
-		// Exponential backoff with max delay
-		delay *= 2
-		if delay > a.config.MaxDelay {
-			delay = a.config.MaxDelay
+	var addresses []string
+	for _, rawURL := range urls {
+		if addr, err := tcpAddressFromURL(rawURL); err == nil {
+			addresses = append(addresses, addr)
 		}
 	}
+	return addresses
+}
+
+// joinRedacted joins the targets for display with any URL passwords redacted
+func joinRedacted(targets []string) string {
+	redacted := make([]string, len(targets))
+	for i, target := range targets {
+		redacted[i] = redactURL(target)
+	}
+	return strings.Join(redacted, ", ")
+}
+
+// requestTimeout returns the timeout of a single check
+func (a *WaitOnlineAction) requestTimeout() time.Duration {
+	if a.config.RequestTimeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return a.config.RequestTimeout
+}
+
+// errDeadline is the error of targets that were not checked because the
+// overall timeout was reached first
+var errDeadline = errors.New("timeout reached before the check started")
+
+// checkConcurrently runs the check against the targets with at most
+// concurrency checks in flight, or all at once if concurrency is 0. The
+// targets are handed out round-robin, starting at offset. Checks that would
+// start after the deadline fail with errDeadline. It returns the reached and
+// failed targets, each in their original order, along with the error of
+// each failed target.
+func checkConcurrently(targets []string, check func(string) error, concurrency, offset int, deadline time.Time) ([]string, []string, []error) {
+	results := make([]error, len(targets))
+	if concurrency <= 0 || concurrency > len(targets) {
+		concurrency = len(targets)
+	}
+
+	next := make(chan int, len(targets))
+	for i := range targets {
+		next <- (offset + i) % len(targets)
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if !time.Now().Before(deadline) {
+					results[i] = errDeadline
+					continue
+				}
+				results[i] = check(targets[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	var reached, failed []string
+	var errs []error
+	for i, target := range targets {
+		if results[i] == nil {
+			reached = append(reached, target)
+		} else {
+			failed = append(failed, target)
+			errs = append(errs, results[i])
+		}
+	}
+	return reached, failed, errs
+}
+
+// unresolvableRounds is the number of consecutive rounds a hostname has to
+// fail with NXDOMAIN before it counts as nonexistent while no target was
+// reached. Stub resolvers such as systemd-resolved answer NXDOMAIN during
+// boot until the upstream DNS is ready.
+const unresolvableRounds = 3
+
+// countNotFound returns for each failed target the number of consecutive
+// rounds its hostname did not exist, continuing the counts of the previous
+// round
+func countNotFound(failed []string, errs []error, previous map[string]int) map[string]int {
+	counts := make(map[string]int)
+	for i, target := range failed {
+		if isNotFound(errs[i]) {
+			counts[target] = previous[target] + 1
+		}
+	}
+	return counts
+}
+
+// unresolvableHost returns an error if a check failed because its hostname
+// does not exist, either while another target was reached or for
+// unresolvableRounds consecutive rounds. With all set, every failed check
+// has to have failed that way.
+func unresolvableHost(failed []string, errs []error, notFound map[string]int, networkUp, all bool) error {
+	var found *net.DNSError
+	for i, target := range failed {
+		var dnsErr *net.DNSError
+		if errors.As(errs[i], &dnsErr) && dnsErr.IsNotFound && (networkUp || notFound[target] >= unresolvableRounds) {
+			if found == nil {
+				found = dnsErr
+			}
+		} else if all {
+			return nil
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return fmt.Errorf("hostname does not resolve: %s: %w", found.Name, found)
+}
+
+// isNotFound reports whether the error is an NXDOMAIN answer
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// checkHTTP checks that the URL responds with a 2xx status code
+func (a *WaitOnlineAction) checkHTTP(ctx context.Context, target string) error {
+	client := &http.Client{
+		Timeout:   a.requestTimeout(),
+		Transport: newHTTPTransport(a.config.ProxyURL),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", shared.UserAgent())
+	setBasicAuth(req, a.config.BasicAuth)
+
+	shared.Verbosef("Checking %s\n", redactURL(target))
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkTCP checks that a TCP connection to the address can be opened
+func (a *WaitOnlineAction) checkTCP(ctx context.Context, target string) error {
+	shared.Verbosef("Connecting to %s\n", target)
+	dialer := net.Dialer{Timeout: a.requestTimeout()}
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
 }
 
 func NewWaitOnlineCmd() *cobra.Command {
-	var url string
-	var timeout, initialDelay, maxDelay time.Duration
+	var checkURLs []string
+	var mode, checkType, tcpAddress, basicAuth, proxy string
+	var timeout, requestTimeout, initialDelay, maxDelay time.Duration
+	var concurrency int
 
 	cmd := &cobra.Command{
 		Use:   "wait-online",
 		Short: "Wait for network connectivity",
-		Long:  `Wait for the configured URL to be reachable with exponential backoff.`,
-		Args:  cobra.NoArgs,
+		Long: `Wait for the configured URL to be reachable with exponential backoff.
+With multiple --url flags, --mode all waits for every URL and --mode any for the first reachable one.
+With --check tcp, a TCP connection to --tcp-address (or the URL's host and port) is opened instead of an HTTP request.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			waitConfig := &WaitOnlineConfig{
-				URL:          url,
-				Timeout:      timeout,
-				InitialDelay: initialDelay,
-				MaxDelay:     maxDelay,
+				URLs:           checkURLs,
+				Mode:           mode,
+				CheckType:      checkType,
+				TCPAddress:     tcpAddress,
+				Timeout:        timeout,
+				RequestTimeout: requestTimeout,
+				Concurrency:    concurrency,
+				InitialDelay:   initialDelay,
+				MaxDelay:       maxDelay,
+				BasicAuth:      basicAuth,
+				Proxy:          proxy,
 			}
 
 			if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
-				return fmt.Errorf("invalid wait-online config: %w", err)
+				return WithExitCode(ExitConfigError, fmt.Errorf("invalid wait-online config: %w", err))
 			}
 
 			action := NewWaitOnlineAction(waitConfig)
@@ -108,10 +410,17 @@ func NewWaitOnlineCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&url, "url", "https://www.google.com", "URL to check for connectivity")
+	cmd.Flags().StringArrayVar(&checkURLs, "url", []string{"https://www.google.com"}, "URL to check for connectivity (repeatable)")
+	cmd.Flags().StringVar(&mode, "mode", "all", "Wait for all or any of the URLs to be reachable")
+	cmd.Flags().StringVar(&checkType, "check", "http", "Connectivity check: http or tcp")
+	cmd.Flags().StringVar(&tcpAddress, "tcp-address", "", "host:port to dial in tcp mode (default: derived from --url)")
 	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "Total timeout for waiting")
+	cmd.Flags().DurationVar(&requestTimeout, "request-timeout", defaultRequestTimeout, "Timeout for each single check, never extending past --timeout")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 0, "Maximum number of checks running at once (0 checks all targets at once)")
 	cmd.Flags().DurationVar(&initialDelay, "initial-delay", 1*time.Second, "Initial delay between retries")
 	cmd.Flags().DurationVar(&maxDelay, "max-delay", 30*time.Second, "Maximum delay between retries")
+	cmd.Flags().StringVar(&basicAuth, "basic-auth", "", "HTTP basic auth credentials in user:pass format")
+	cmd.Flags().StringVar(&proxy, "proxy", "", "Proxy URL for http checks, e.g. http://proxy:3128 (default: HTTP_PROXY/HTTPS_PROXY environment variables)")
 
 	return cmd
 }
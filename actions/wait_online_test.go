@@ -1,8 +1,14 @@
 package actions
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -48,6 +54,74 @@ func TestWaitOnlineAction(t *testing.T) {
 	}
 }
 
+func TestWaitOnlineActionBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "monitor" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	waitConfig := &WaitOnlineConfig{
+		URL:          server.URL,
+		BasicAuth:    "monitor:s3cret",
+		Timeout:      time.Second,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWaitOnlineAction(waitConfig).Execute([]string{}); err != nil {
+		t.Errorf("Expected success with basic auth, got error: %v", err)
+	}
+
+	// Without credentials the endpoint is never reached, the URL password is redacted
+	secretURL := strings.Replace(server.URL, "http://", "http://monitor:wrong@", 1)
+	waitConfig = &WaitOnlineConfig{
+		URL:          secretURL,
+		Timeout:      50 * time.Millisecond,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	err := NewWaitOnlineAction(waitConfig).Execute([]string{})
+	if err == nil || strings.Contains(err.Error(), "wrong") {
+		t.Errorf("Expected timeout error with redacted URL, got %v", err)
+	}
+}
+
+func TestWaitOnlineActionProxy(t *testing.T) {
+	// The proxy answers for the unresolvable target host
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	waitConfig := &WaitOnlineConfig{
+		URL:          "http://backup-target.invalid/health",
+		Proxy:        proxy.URL,
+		Timeout:      time.Second,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWaitOnlineAction(waitConfig).Execute([]string{}); err != nil {
+		t.Errorf("Expected success through the proxy, got error: %v", err)
+	}
+	if proxiedHost != "backup-target.invalid" {
+		t.Errorf("Expected request for backup-target.invalid via the proxy, got %q", proxiedHost)
+	}
+}
+
 func TestWaitOnlineActionTimeout(t *testing.T) {
 	// Create a server that always returns 500
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -78,6 +152,393 @@ func TestWaitOnlineActionTimeout(t *testing.T) {
 	}
 }
 
+func TestWaitOnlineActionTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	waitConfig := &WaitOnlineConfig{
+		CheckType:    "tcp",
+		TCPAddress:   listener.Addr().String(),
+		Timeout:      1 * time.Second,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     50 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	action := NewWaitOnlineAction(waitConfig)
+	if err := action.Execute([]string{}); err != nil {
+		t.Errorf("Expected success, got error: %v", err)
+	}
+
+	// A closed port should time out
+	addr := listener.Addr().String()
+	listener.Close()
+
+	waitConfig = &WaitOnlineConfig{
+		CheckType:    "tcp",
+		TCPAddress:   addr,
+		Timeout:      100 * time.Millisecond,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     50 * time.Millisecond,
+	}
+	action = NewWaitOnlineAction(waitConfig)
+	if err := action.Execute([]string{}); err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+}
+
+func TestWaitOnlineActionMultipleURLs(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	tests := []struct {
+		name    string
+		mode    string
+		wantErr bool
+	}{
+		{name: "any succeeds if one url is up", mode: "any", wantErr: false},
+		{name: "all fails if one url is down", mode: "all", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitConfig := &WaitOnlineConfig{
+				URLs:         []string{down.URL, up.URL},
+				Mode:         tt.mode,
+				Timeout:      100 * time.Millisecond,
+				InitialDelay: 10 * time.Millisecond,
+				MaxDelay:     50 * time.Millisecond,
+			}
+			if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+				t.Fatal(err)
+			}
+
+			err := NewWaitOnlineAction(waitConfig).Execute([]string{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// In all mode, every url must eventually be reached
+	waitConfig := &WaitOnlineConfig{
+		URLs:         []string{up.URL, up.URL + "/other"},
+		Timeout:      1 * time.Second,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     50 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWaitOnlineAction(waitConfig).Execute([]string{}); err != nil {
+		t.Errorf("Expected success, got error: %v", err)
+	}
+}
+
+func TestWaitOnlineActionConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight, requests := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var urls []string
+	for i := range 6 {
+		urls = append(urls, fmt.Sprintf("%s/%d", server.URL, i))
+	}
+	waitConfig := &WaitOnlineConfig{
+		URLs:         urls,
+		Concurrency:  2,
+		Timeout:      5 * time.Second,
+		InitialDelay: 10 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewWaitOnlineAction(waitConfig).Execute([]string{}); err != nil {
+		t.Fatalf("Expected success, got error: %v", err)
+	}
+	if requests != 6 {
+		t.Errorf("Expected every url to be checked once, got %d requests", requests)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", maxInFlight)
+	}
+}
+
+func TestCheckConcurrentlyRoundRobin(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	check := func(target string) error {
+		mu.Lock()
+		order = append(order, target)
+		mu.Unlock()
+		return fmt.Errorf("down")
+	}
+
+	_, failed, _ := checkConcurrently([]string{"a", "b", "c"}, check, 1, 1, time.Now().Add(time.Minute))
+	if strings.Join(order, "") != "bca" {
+		t.Errorf("Expected checks to start at the offset, got %v", order)
+	}
+	if strings.Join(failed, "") != "abc" {
+		t.Errorf("Expected failed targets in their original order, got %v", failed)
+	}
+
+	// Checks that would start after the deadline are not run
+	order = nil
+	_, failed, errs := checkConcurrently([]string{"a", "b"}, check, 1, 0, time.Now())
+	if len(order) != 0 || len(failed) != 2 || !errors.Is(errs[0], errDeadline) {
+		t.Errorf("Expected no checks after the deadline, got order %v, errors %v", order, errs)
+	}
+}
+
+func TestWaitOnlineActionRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		requestTimeout time.Duration
+	}{
+		{name: "request timeout", requestTimeout: 50 * time.Millisecond},
+		{name: "overall timeout bounds the request timeout", requestTimeout: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitConfig := &WaitOnlineConfig{
+				URL:            server.URL,
+				Timeout:        200 * time.Millisecond,
+				RequestTimeout: tt.requestTimeout,
+				InitialDelay:   10 * time.Millisecond,
+				MaxDelay:       50 * time.Millisecond,
+			}
+			if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			err := NewWaitOnlineAction(waitConfig).Execute([]string{})
+			if err == nil {
+				t.Error("Expected timeout error, got nil")
+			}
+			if duration := time.Since(start); duration > time.Second {
+				t.Errorf("Expected the overall timeout to bound the wait, took %v", duration)
+			}
+		})
+	}
+}
+
+func TestWaitOnlineActionUnresponsiveServer(t *testing.T) {
+	// A server that accepts connections but never sends a response
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	waitConfig := &WaitOnlineConfig{
+		URL:            "http://" + listener.Addr().String(),
+		Timeout:        200 * time.Millisecond,
+		RequestTimeout: time.Minute,
+		InitialDelay:   10 * time.Millisecond,
+		MaxDelay:       50 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+	action := NewWaitOnlineAction(waitConfig)
+
+	start := time.Now()
+	if err := action.Execute([]string{}); err == nil {
+		t.Error("Expected timeout error, got nil")
+	}
+	if duration := time.Since(start); duration > time.Second {
+		t.Errorf("Expected the overall timeout to bound the wait, took %v", duration)
+	}
+
+	// A check started right at the deadline must not run unbounded
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	start = time.Now()
+	if err := action.checkHTTP(ctx, waitConfig.URL); err == nil {
+		t.Error("Expected an error for an HTTP check past the deadline, got nil")
+	}
+	if err := action.checkTCP(ctx, listener.Addr().String()); err == nil {
+		t.Error("Expected an error for a TCP check past the deadline, got nil")
+	}
+	if duration := time.Since(start); duration > time.Second {
+		t.Errorf("Expected checks past the deadline to fail at once, took %v", duration)
+	}
+}
+
+func TestUnresolvableHost(t *testing.T) {
+	notFound := fmt.Errorf("dial: %w", &net.DNSError{Err: "no such host", Name: "typo.example", IsNotFound: true})
+	temporary := fmt.Errorf("dial: %w", &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true})
+	refused := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		rounds    int
+		networkUp bool
+		all       bool
+		wantErr   bool
+	}{
+		{name: "no errors", errs: nil, rounds: 1, wantErr: false},
+		{name: "temporary DNS error", errs: []error{temporary}, rounds: unresolvableRounds, wantErr: false},
+		{name: "not found while booting", errs: []error{refused, notFound}, rounds: 1, wantErr: false},
+		{name: "not found while another target is reachable", errs: []error{refused, notFound}, rounds: 1, networkUp: true, wantErr: true},
+		{name: "not found for several rounds", errs: []error{refused, notFound}, rounds: unresolvableRounds, wantErr: true},
+		{name: "not found with other failures in any mode", errs: []error{refused, notFound}, rounds: unresolvableRounds, all: true, wantErr: false},
+		{name: "all not found in any mode", errs: []error{notFound, notFound}, rounds: unresolvableRounds, all: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed := make([]string, len(tt.errs))
+			for i := range tt.errs {
+				failed[i] = fmt.Sprintf("http://target%d", i)
+			}
+			var counts map[string]int
+			for range tt.rounds {
+				counts = countNotFound(failed, tt.errs, counts)
+			}
+
+			err := unresolvableHost(failed, tt.errs, counts, tt.networkUp, tt.all)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unresolvableHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "hostname does not resolve: typo.example") {
+				t.Errorf("Unexpected error message: %v", err)
+			}
+		})
+	}
+}
+
+func TestCountNotFound(t *testing.T) {
+	notFound := &net.DNSError{Err: "no such host", Name: "typo.example", IsNotFound: true}
+	refused := errors.New("connection refused")
+
+	counts := countNotFound([]string{"a", "b"}, []error{notFound, notFound}, nil)
+	counts = countNotFound([]string{"a", "b"}, []error{notFound, refused}, counts)
+	if counts["a"] != 2 || counts["b"] != 0 {
+		t.Errorf("Expected consecutive counts a=2 b=0, got %v", counts)
+	}
+	// A round without NXDOMAIN resets the count
+	counts = countNotFound([]string{"a"}, []error{refused}, counts)
+	if counts["a"] != 0 {
+		t.Errorf("Expected count to reset, got %v", counts)
+	}
+}
+
+func TestWaitOnlineActionUnresolvableWhileOthersFail(t *testing.T) {
+	if _, err := net.LookupHost("does-not-exist.invalid"); !isNotFound(err) {
+		t.Skipf("Resolver does not answer NXDOMAIN in this environment: %v", err)
+	}
+
+	// A closed port, so the other target fails with connection refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refusedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	waitConfig := &WaitOnlineConfig{
+		URLs:         []string{refusedURL, "http://does-not-exist.invalid"},
+		Timeout:      100 * time.Millisecond,
+		InitialDelay: time.Second,
+		MaxDelay:     time.Second,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fewer rounds than unresolvableRounds fit into the timeout, so the
+	// command keeps retrying until it times out
+	err = NewWaitOnlineAction(waitConfig).Execute([]string{})
+	if err == nil || !strings.Contains(err.Error(), "timeout reached") {
+		t.Errorf("Expected to keep retrying until the timeout, got %v", err)
+	}
+}
+
+func TestWaitOnlineActionUnresolvableHost(t *testing.T) {
+	waitConfig := &WaitOnlineConfig{
+		URL:          "http://does-not-exist.invalid",
+		Timeout:      30 * time.Second,
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+	}
+	if err := ValidateWaitOnlineConfig(waitConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := NewWaitOnlineAction(waitConfig).Execute([]string{})
+	if err == nil {
+		t.Fatal("Expected error for unresolvable host, got nil")
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+		t.Skipf("Resolver did not answer NXDOMAIN in this environment: %v", err)
+	}
+	if !strings.Contains(err.Error(), "hostname does not resolve") {
+		t.Errorf("Expected hostname error, got %v", err)
+	}
+	if time.Since(start) > 15*time.Second {
+		t.Errorf("Expected to fail fast, took %v", time.Since(start))
+	}
+}
+
 func TestWaitOnlineActionWithArguments(t *testing.T) {
 	waitConfig := &WaitOnlineConfig{
 		URL:          "http://example.com",
@@ -115,7 +576,24 @@ func TestValidateWaitOnlineConfig(t *testing.T) {
 				return c.URL == "https://www.google.com" &&
 					c.Timeout == 5*time.Minute &&
 					c.InitialDelay == 1*time.Second &&
-					c.MaxDelay == 30*time.Second
+					c.MaxDelay == 30*time.Second &&
+					c.CheckType == "http" &&
+					c.Mode == "all" &&
+					len(c.URLs) == 1 && c.URLs[0] == "https://www.google.com"
+			},
+		},
+		{
+			name:   "tcp address derived from url",
+			config: &WaitOnlineConfig{URL: "https://repo.example.com/path", CheckType: "tcp"},
+			check: func(c *WaitOnlineConfig) bool {
+				return c.TCPAddress == "repo.example.com:443"
+			},
+		},
+		{
+			name:   "explicit tcp address",
+			config: &WaitOnlineConfig{CheckType: "tcp", TCPAddress: "nas.local:22"},
+			check: func(c *WaitOnlineConfig) bool {
+				return c.TCPAddress == "nas.local:22"
 			},
 		},
 	}
@@ -132,3 +610,25 @@ func TestValidateWaitOnlineConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWaitOnlineConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *WaitOnlineConfig
+	}{
+		{name: "unknown check", config: &WaitOnlineConfig{CheckType: "icmp"}},
+		{name: "unknown mode", config: &WaitOnlineConfig{Mode: "some"}},
+		{name: "tcp address without port", config: &WaitOnlineConfig{CheckType: "tcp", TCPAddress: "nas.local"}},
+		{name: "proxy without scheme", config: &WaitOnlineConfig{Proxy: "proxy:3128"}},
+		{name: "negative concurrency", config: &WaitOnlineConfig{Concurrency: -1}},
+		{name: "negative request timeout", config: &WaitOnlineConfig{RequestTimeout: -time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateWaitOnlineConfig(tt.config); err == nil {
+				t.Error("ValidateWaitOnlineConfig() expected error, got nil")
+			}
+		})
+	}
+}
@@ -29,6 +29,10 @@ export AWS_SECRET_ACCESS_KEY="${AWS_SECRET_ACCESS_KEY:-}"
 # Create temporary directory for logs
 TEMP_DIR=$(mktemp -d)
 
+# Signal the start of the backup to the dead-man's-switch service
+$RESTIC_HOOKS notify-http --start \
+    --url "https://hc-ping.com/<uuid>" || true
+
 # Setup remote restic binary
 echo "Setting up restic binary on remote host..."
 REMOTE_RESTIC=$(ssh -p "$SSH_PORT" "$SSH_HOST" "mktemp")
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"restic-kit/actions"
+	"restic-kit/shared"
 )
 
 func main() {
@@ -13,19 +14,80 @@ func main() {
 		Use:   "restic-kit",
 		Short: "Restic hooks for backup automation",
 		Long:  `A tool for executing hooks during restic backup operations.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			if quiet && verbose {
+				return actions.WithExitCode(actions.ExitConfigError, fmt.Errorf("--quiet and --verbose cannot be combined"))
+			}
+			if quiet {
+				shared.SetLogLevel(shared.LogQuiet)
+			} else if verbose {
+				shared.SetLogLevel(shared.LogVerbose)
+			}
+
+			configPath, _ := cmd.Flags().GetString("config")
+			if configPath != "" {
+				fileConfig, err := shared.LoadConfigFile(configPath)
+				if err != nil {
+					return actions.WithExitCode(actions.ExitConfigError, err)
+				}
+				if err := shared.ApplyConfigFile(cmd.Flags(), fileConfig, cmd.Name()); err != nil {
+					return actions.WithExitCode(actions.ExitConfigError, err)
+				}
+			}
+
+			// Cobra checks required flags after this hook, so the config
+			// file can provide them. They are checked here as well to
+			// report missing flags as a config error.
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return actions.WithExitCode(actions.ExitConfigError, err)
+			}
+
+			byteUnits, _ := cmd.Flags().GetString("byte-units")
+			return actions.WithExitCode(actions.ExitConfigError, shared.SetByteUnits(byteUnits))
+		},
 	}
 
+	// Malformed flags and arguments are config errors as well
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return actions.WithExitCode(actions.ExitConfigError, err)
+	})
+
 	rootCmd.PersistentFlags().Bool("dry-run", false, "dry run mode")
+	rootCmd.PersistentFlags().Bool("quiet", false, "only print errors and failures")
+	rootCmd.PersistentFlags().Bool("verbose", false, "print details such as parsed files and HTTP attempts")
+	rootCmd.PersistentFlags().String("repo-name", "", "repository name to label reports with when backing up to several repositories")
+	rootCmd.PersistentFlags().String("byte-units", "iec", "units for sizes in reports: iec (base 1024) or si (base 1000)")
+	rootCmd.PersistentFlags().String("config", "", "path to a YAML config file providing default flag values")
 
 	// Add action commands
 	rootCmd.AddCommand(actions.NewNotifyEmailCmd())
 	rootCmd.AddCommand(actions.NewNotifyHTTPCmd())
+	rootCmd.AddCommand(actions.NewNotifyTelegramCmd())
+	rootCmd.AddCommand(actions.NewNotifyNtfyCmd())
 	rootCmd.AddCommand(actions.NewWaitOnlineCmd())
 	rootCmd.AddCommand(actions.NewCleanupCmd())
 	rootCmd.AddCommand(actions.NewAuditCmd())
+	rootCmd.AddCommand(actions.NewMetricsCmd())
+	rootCmd.AddCommand(actions.NewRunCmd())
+	rootCmd.AddCommand(actions.NewCheckDiskCmd())
+	rootCmd.AddCommand(actions.NewPreviewCmd())
+	rootCmd.AddCommand(actions.NewVersionCmd())
+
+	for _, cmd := range rootCmd.Commands() {
+		if validateArgs := cmd.Args; validateArgs != nil {
+			cmd.Args = func(cmd *cobra.Command, args []string) error {
+				return actions.WithExitCode(actions.ExitConfigError, validateArgs(cmd, args))
+			}
+		}
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		// The exit code tells the failure category apart, see
+		// actions.ExitConfigError and friends. Commands wrapped by run keep
+		// their own exit code.
+		os.Exit(actions.ExitCode(err))
 	}
 }
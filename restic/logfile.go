@@ -0,0 +1,80 @@
+package restic
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadExitCode reads the exit code from an exitcode file, which may be
+// gzip-compressed
+func ReadExitCode(exitcodeFile string) (int, error) {
+	content, err := ReadLogFile(ResolveLogFile(exitcodeFile))
+	if err != nil {
+		return -1, err
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return -1, fmt.Errorf("invalid exit code in %s: %w", exitcodeFile, err)
+	}
+	return code, nil
+}
+
+// ResolveLogFile returns the gzip-compressed variant of a log file if only
+// that one exists, so logs can be compressed before the reports are made
+func ResolveLogFile(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(path + ".gz"); err == nil {
+			return path + ".gz"
+		}
+	}
+	return path
+}
+
+// ReadLogFile reads a log file, decompressing it if its name ends in .gz
+func ReadLogFile(path string) ([]byte, error) {
+	reader, err := OpenLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// OpenLogFile opens a log file for streaming, decompressing it if its name
+// ends in .gz
+func OpenLogFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// gzipFile closes both the decompressor and the underlying file
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
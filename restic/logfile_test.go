@@ -0,0 +1,54 @@
+package restic
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadExitCode(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{name: "zero", content: "0", want: 0},
+		{name: "trailing newline", content: "3\n", want: 3},
+		{name: "whitespace", content: "  1 \r\n", want: 1},
+		{name: "invalid", content: "failed", wantErr: true},
+		{name: "empty", content: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".exitcode")
+			os.WriteFile(path, []byte(tt.content), 0644)
+			got, err := ReadExitCode(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadExitCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ReadExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	// Compressed files are used when the plain file is missing
+	path := filepath.Join(dir, "backup.home.exitcode")
+	f, _ := os.Create(path + ".gz")
+	zw := gzip.NewWriter(f)
+	zw.Write([]byte("2\n"))
+	zw.Close()
+	f.Close()
+	if got, err := ReadExitCode(path); err != nil || got != 2 {
+		t.Errorf("ReadExitCode() of compressed file = %d, %v, want 2", got, err)
+	}
+
+	if _, err := ReadExitCode(filepath.Join(dir, "missing.exitcode")); err == nil {
+		t.Error("Expected error for missing exitcode file")
+	}
+}
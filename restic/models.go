@@ -2,6 +2,8 @@ package restic
 
 import (
 	"fmt"
+
+	"restic-kit/shared"
 )
 
 // ResticMessage represents a message from restic JSON output
@@ -21,13 +23,45 @@ type ResticMessage struct {
 	TotalFilesProcessed int     `json:"total_files_processed,omitempty"`
 	TotalBytesProcessed int64   `json:"total_bytes_processed,omitempty"`
 	TotalDuration       float64 `json:"total_duration,omitempty"`
+	BackupStart         string  `json:"backup_start,omitempty"`
+	BackupEnd           string  `json:"backup_end,omitempty"`
 	SnapshotID          string  `json:"snapshot_id,omitempty"`
 	// For check summary
 	NumErrors int `json:"num_errors,omitempty"`
-	// For status
+	// For prune summary
+	ToBeRepacked int   `json:"tobrepack,omitempty"`
+	BlobsRemoved int   `json:"blobs_removed,omitempty"`
+	PacksDeleted int   `json:"packs_deleted,omitempty"`
+	SizeFreed    int64 `json:"size_freed,omitempty"`
+	// For status and exit errors
 	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
 	// For snapshots
 	Snapshots []SnapshotGroup `json:"snapshots,omitempty"`
+	// For diff
+	Path           string     `json:"path,omitempty"`
+	Modifier       string     `json:"modifier,omitempty"`
+	SourceSnapshot string     `json:"source_snapshot,omitempty"`
+	TargetSnapshot string     `json:"target_snapshot,omitempty"`
+	ChangedFiles   int        `json:"changed_files,omitempty"`
+	Added          *DiffStats `json:"added,omitempty"`
+	Removed        *DiffStats `json:"removed,omitempty"`
+	// For errors
+	Error  *ErrorDetail `json:"error,omitempty"`
+	During string       `json:"during,omitempty"`
+	Item   string       `json:"item,omitempty"`
+}
+
+// ErrorDetail is the error of a JSON error message
+type ErrorDetail struct {
+	Message string `json:"message"`
+}
+
+// DiffStats represents the added or removed part of diff statistics
+type DiffStats struct {
+	Files int   `json:"files"`
+	Dirs  int   `json:"dirs"`
+	Bytes int64 `json:"bytes"`
 }
 
 // SnapshotGroup represents a group of snapshots
@@ -49,6 +83,7 @@ type Snapshot struct {
 	Parent         string        `json:"parent"`
 	Tree           string        `json:"tree"`
 	Paths          []string      `json:"paths"`
+	Tags           []string      `json:"tags"`
 	Hostname       string        `json:"hostname"`
 	Username       string        `json:"username"`
 	ProgramVersion string        `json:"program_version"`
@@ -75,6 +110,32 @@ type BackupSummary struct {
 	TotalBytesProcessed int64  `json:"total_bytes_processed"`
 }
 
+// Validate reports the first counter of a snapshot summary that is negative,
+// which only happens with corrupt or garbled output
+func (s BackupSummary) Validate() error {
+	counters := []struct {
+		name  string
+		value int64
+	}{
+		{"files_new", int64(s.FilesNew)},
+		{"files_changed", int64(s.FilesChanged)},
+		{"files_unmodified", int64(s.FilesUnmodified)},
+		{"dirs_new", int64(s.DirsNew)},
+		{"dirs_changed", int64(s.DirsChanged)},
+		{"dirs_unmodified", int64(s.DirsUnmodified)},
+		{"data_added", s.DataAdded},
+		{"data_added_packed", s.DataAddedPacked},
+		{"total_files_processed", int64(s.TotalFilesProcessed)},
+		{"total_bytes_processed", s.TotalBytesProcessed},
+	}
+	for _, counter := range counters {
+		if counter.value < 0 {
+			return fmt.Errorf("%s is negative: %d", counter.name, counter.value)
+		}
+	}
+	return nil
+}
+
 // ActionResult defines the interface for all action results
 type ActionResult interface {
 	GetActionName() string
@@ -82,6 +143,8 @@ type ActionResult interface {
 	GetSummaryInfo() map[string]string
 	GetOutFile() string
 	GetErrFile() string
+	GetExitCode() int
+	GetWarnings() []string
 }
 
 // BackupResult represents the result of a backup operation
@@ -97,15 +160,21 @@ type BackupResult struct {
 	TotalFilesProcessed int     `json:"total_files_processed,omitempty"`
 	TotalBytesProcessed int64   `json:"total_bytes_processed,omitempty"`
 	TotalDuration       float64 `json:"total_duration,omitempty"`
+	// Errors are the error messages restic reported during the backup
+	Errors []string `json:"errors,omitempty"`
+	// Degraded is set if the backup failed but still reported a summary
+	Degraded bool `json:"degraded,omitempty"`
 }
 
 // BackupActionResult implements ActionResult for backup operations
 type BackupActionResult struct {
-	Name    string
-	Success bool
-	Result  *BackupResult
-	OutFile string
-	ErrFile string
+	Name     string
+	Success  bool
+	Result   *BackupResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
 }
 
 func (r *BackupActionResult) GetActionName() string {
@@ -125,12 +194,13 @@ func (r *BackupActionResult) GetSummaryInfo() map[string]string {
 		info["dirs_new"] = fmt.Sprintf("%d", r.Result.DirsNew)
 		info["dirs_changed"] = fmt.Sprintf("%d", r.Result.DirsChanged)
 		info["dirs_unmodified"] = fmt.Sprintf("%d", r.Result.DirsUnmodified)
-		info["data_added"] = formatBytes(r.Result.DataAdded)
-		info["data_added_packed"] = formatBytes(r.Result.DataAddedPacked)
+		info["data_added"] = shared.FormatBytes(r.Result.DataAdded)
+		info["data_added_packed"] = shared.FormatBytes(r.Result.DataAddedPacked)
 		info["total_files_processed"] = fmt.Sprintf("%d", r.Result.TotalFilesProcessed)
-		info["total_bytes_processed"] = formatBytes(r.Result.TotalBytesProcessed)
+		info["total_bytes_processed"] = shared.FormatBytes(r.Result.TotalBytesProcessed)
 		if r.Result.TotalDuration > 0 {
 			info["duration"] = fmt.Sprintf("%.2f", r.Result.TotalDuration)
+			info["throughput"] = shared.FormatBytes(int64(float64(r.Result.TotalBytesProcessed)/r.Result.TotalDuration)) + "/s"
 		}
 	}
 	return info
@@ -144,18 +214,29 @@ func (r *BackupActionResult) GetErrFile() string {
 	return r.ErrFile
 }
 
+func (r *BackupActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *BackupActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
 // CheckResult represents the result of a check operation
 type CheckResult struct {
-	NumErrors int `json:"num_errors,omitempty"`
+	NumErrors int      `json:"num_errors,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
 }
 
 // CheckActionResult implements ActionResult for check operations
 type CheckActionResult struct {
-	Name    string
-	Success bool
-	Result  *CheckResult
-	OutFile string
-	ErrFile string
+	Name     string
+	Success  bool
+	Result   *CheckResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
 }
 
 func (r *CheckActionResult) GetActionName() string {
@@ -187,6 +268,14 @@ func (r *CheckActionResult) GetErrFile() string {
 	return r.ErrFile
 }
 
+func (r *CheckActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *CheckActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
 // SnapshotsActionResult implements ActionResult for snapshots operations
 type SnapshotsActionResult struct {
 	Name      string
@@ -194,6 +283,8 @@ type SnapshotsActionResult struct {
 	Snapshots []Snapshot
 	OutFile   string
 	ErrFile   string
+	ExitCode  int
+	Warnings  []string
 }
 
 func (r *SnapshotsActionResult) GetActionName() string {
@@ -218,14 +309,40 @@ func (r *SnapshotsActionResult) GetErrFile() string {
 	return r.ErrFile
 }
 
+func (r *SnapshotsActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *SnapshotsActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// ForgetReason describes why restic kept a snapshot
+type ForgetReason struct {
+	Snapshot Snapshot `json:"snapshot"`
+	Matches  []string `json:"matches"`
+}
+
+// ForgetResult represents the result of a forget operation
+type ForgetResult struct {
+	Kept         []Snapshot
+	Reasons      []ForgetReason
+	Removed      []Snapshot
+	RemovedCount int
+}
+
 // ForgetActionResult implements ActionResult for forget operations
 type ForgetActionResult struct {
 	Name         string
 	Success      bool
 	Snapshots    []Snapshot
+	Reasons      []ForgetReason
+	Removed      []Snapshot
 	RemovedCount int
 	OutFile      string
 	ErrFile      string
+	ExitCode     int
+	Warnings     []string
 }
 
 func (r *ForgetActionResult) GetActionName() string {
@@ -250,16 +367,318 @@ func (r *ForgetActionResult) GetErrFile() string {
 	return r.ErrFile
 }
 
-// formatBytes formats bytes into human readable format
-func formatBytes(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+func (r *ForgetActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *ForgetActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// PruneResult represents the result of a prune operation
+type PruneResult struct {
+	ToBeRepacked int   `json:"tobrepack,omitempty"`
+	BlobsRemoved int   `json:"blobs_removed,omitempty"`
+	PacksDeleted int   `json:"packs_deleted,omitempty"`
+	SizeFreed    int64 `json:"size_freed,omitempty"`
+}
+
+// PruneActionResult implements ActionResult for prune operations
+type PruneActionResult struct {
+	Name     string
+	Success  bool
+	Result   *PruneResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
+}
+
+func (r *PruneActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *PruneActionResult) IsSuccess() bool {
+	return r.Success
+}
+
+func (r *PruneActionResult) GetSummaryInfo() map[string]string {
+	info := make(map[string]string)
+	if r.Result != nil {
+		info["packs_to_repack"] = fmt.Sprintf("%d", r.Result.ToBeRepacked)
+		info["blobs_removed"] = fmt.Sprintf("%d", r.Result.BlobsRemoved)
+		info["packs_deleted"] = fmt.Sprintf("%d", r.Result.PacksDeleted)
+		info["size_freed"] = shared.FormatBytes(r.Result.SizeFreed)
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	return info
+}
+
+func (r *PruneActionResult) GetOutFile() string {
+	return r.OutFile
+}
+
+func (r *PruneActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *PruneActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *PruneActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// StatsResult represents the result of a stats operation
+type StatsResult struct {
+	TotalSize      int64 `json:"total_size"`
+	TotalFileCount int   `json:"total_file_count"`
+	TotalBlobCount int   `json:"total_blob_count"`
+	SnapshotsCount int   `json:"snapshots_count"`
+}
+
+// StatsActionResult implements ActionResult for stats operations
+type StatsActionResult struct {
+	Name     string
+	Success  bool
+	Result   *StatsResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
+}
+
+func (r *StatsActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *StatsActionResult) IsSuccess() bool {
+	return r.Success
+}
+
+func (r *StatsActionResult) GetSummaryInfo() map[string]string {
+	info := make(map[string]string)
+	if r.Result != nil {
+		info["total_size"] = shared.FormatBytes(r.Result.TotalSize)
+		info["total_file_count"] = fmt.Sprintf("%d", r.Result.TotalFileCount)
+		info["total_blob_count"] = fmt.Sprintf("%d", r.Result.TotalBlobCount)
+		info["snapshots_count"] = fmt.Sprintf("%d", r.Result.SnapshotsCount)
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return info
+}
+
+func (r *StatsActionResult) GetOutFile() string {
+	return r.OutFile
+}
+
+func (r *StatsActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *StatsActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *StatsActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// DiffResult represents the result of a diff operation
+type DiffResult struct {
+	SourceSnapshot string `json:"source_snapshot,omitempty"`
+	TargetSnapshot string `json:"target_snapshot,omitempty"`
+	FilesAdded     int    `json:"files_added"`
+	FilesRemoved   int    `json:"files_removed"`
+	FilesChanged   int    `json:"files_changed"`
+	SizeDelta      int64  `json:"size_delta"`
+}
+
+// DiffActionResult implements ActionResult for diff operations
+type DiffActionResult struct {
+	Name     string
+	Success  bool
+	Result   *DiffResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
+}
+
+func (r *DiffActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *DiffActionResult) IsSuccess() bool {
+	return r.Success
+}
+
+func (r *DiffActionResult) GetSummaryInfo() map[string]string {
+	info := make(map[string]string)
+	if r.Result != nil {
+		info["files_added"] = fmt.Sprintf("%d", r.Result.FilesAdded)
+		info["files_removed"] = fmt.Sprintf("%d", r.Result.FilesRemoved)
+		info["files_changed"] = fmt.Sprintf("%d", r.Result.FilesChanged)
+		if r.Result.SizeDelta < 0 {
+			info["size_delta"] = "-" + shared.FormatBytes(-r.Result.SizeDelta)
+		} else {
+			info["size_delta"] = "+" + shared.FormatBytes(r.Result.SizeDelta)
+		}
+	}
+	return info
+}
+
+func (r *DiffActionResult) GetOutFile() string {
+	return r.OutFile
+}
+
+func (r *DiffActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *DiffActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *DiffActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// CopyResult represents the result of copying snapshots to another repository
+type CopyResult struct {
+	SnapshotsCopied  int   `json:"snapshots_copied"`
+	SnapshotsSkipped int   `json:"snapshots_skipped"`
+	BytesCopied      int64 `json:"bytes_copied"`
+}
+
+// CopyActionResult implements ActionResult for copy operations
+type CopyActionResult struct {
+	Name     string
+	Success  bool
+	Result   *CopyResult
+	OutFile  string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
+}
+
+func (r *CopyActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *CopyActionResult) IsSuccess() bool {
+	return r.Success
+}
+
+func (r *CopyActionResult) GetSummaryInfo() map[string]string {
+	info := make(map[string]string)
+	if r.Result != nil {
+		info["snapshots_copied"] = fmt.Sprintf("%d", r.Result.SnapshotsCopied)
+		info["snapshots_skipped"] = fmt.Sprintf("%d", r.Result.SnapshotsSkipped)
+		info["bytes_copied"] = shared.FormatBytes(r.Result.BytesCopied)
+	}
+	return info
+}
+
+func (r *CopyActionResult) GetOutFile() string {
+	return r.OutFile
+}
+
+func (r *CopyActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *CopyActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *CopyActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// GenericActionResult is the result of an action without a dedicated
+// parser, e.g. a custom hook or a restic command such as copy. Only the
+// last lines of its output are kept.
+type GenericActionResult struct {
+	Name       string
+	Success    bool
+	OutputTail []string
+	OutFile    string
+	ErrFile    string
+	ExitCode   int
+	Warnings   []string
+}
+
+func (r *GenericActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *GenericActionResult) IsSuccess() bool {
+	return r.Success
+}
+
+func (r *GenericActionResult) GetSummaryInfo() map[string]string {
+	info := make(map[string]string)
+	info["exit_code"] = fmt.Sprintf("%d", r.ExitCode)
+	if len(r.OutputTail) > 0 {
+		info["last_output"] = r.OutputTail[len(r.OutputTail)-1]
+	}
+	return info
+}
+
+func (r *GenericActionResult) GetOutFile() string {
+	return r.OutFile
+}
+
+func (r *GenericActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *GenericActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *GenericActionResult) GetWarnings() []string {
+	return r.Warnings
+}
+
+// MissingOutputActionResult is the result of an action whose .out file is
+// missing, e.g. because it crashed before writing any output. It is always
+// reported as failed, whatever its exit code.
+type MissingOutputActionResult struct {
+	Type     string
+	Name     string
+	ErrFile  string
+	ExitCode int
+	Warnings []string
+}
+
+func (r *MissingOutputActionResult) GetActionName() string {
+	return r.Name
+}
+
+func (r *MissingOutputActionResult) IsSuccess() bool {
+	return false
+}
+
+func (r *MissingOutputActionResult) GetSummaryInfo() map[string]string {
+	return map[string]string{
+		"exit_code": fmt.Sprintf("%d", r.ExitCode),
+		"output":    "no output captured",
+	}
+}
+
+func (r *MissingOutputActionResult) GetOutFile() string {
+	return ""
+}
+
+func (r *MissingOutputActionResult) GetErrFile() string {
+	return r.ErrFile
+}
+
+func (r *MissingOutputActionResult) GetExitCode() int {
+	return r.ExitCode
+}
+
+func (r *MissingOutputActionResult) GetWarnings() []string {
+	return r.Warnings
 }
@@ -1,34 +1,150 @@
 package restic
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"math"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 )
 
+// warningPrefixes are the prefixes of the warning and error lines restic
+// writes to stderr, e.g. "error: lstat /root/.cache: permission denied"
+var warningPrefixes = []string{"error:", "warning:", "warn:"}
+
+// ParseWarnings collects the warnings and errors restic wrote to stderr.
+// Both plain text lines and JSON error messages are recognized, all other
+// lines are ignored.
+func ParseWarnings(r io.Reader) ([]string, error) {
+	var warnings []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "{") {
+			if warning, ok := parseErrorMessage(line); ok {
+				warnings = append(warnings, warning)
+			}
+			continue
+		}
+
+		lower := strings.ToLower(line)
+		for _, prefix := range warningPrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				warnings = append(warnings, line)
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read error output: %w", err)
+	}
+	return warnings, nil
+}
+
+// parseErrorMessage returns the text of a JSON error message, prefixed with
+// the affected item unless the message already names it
+func parseErrorMessage(line string) (string, bool) {
+	var msg ResticMessage
+	if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.MessageType != "error" || msg.Error == nil {
+		return "", false
+	}
+	text := msg.Error.Message
+	if msg.Item != "" && !strings.Contains(text, msg.Item) {
+		text = msg.Item + ": " + text
+	}
+	return text, true
+}
+
 // ParseBackupOutput parses backup JSON output
 func ParseBackupOutput(content string, success bool) (*BackupResult, error) {
-	lines := strings.Split(content, "\n")
-	var lastLine string
+	return ParseBackupReader(strings.NewReader(content), success)
+}
 
-	// Find the last non-empty line (summary is always on the last line)
-	for i := len(lines) - 1; i >= 0; i-- {
-		line := strings.TrimSpace(lines[i])
-		if line != "" {
-			lastLine = line
-			break
+// ParseOutputTail returns the last non-empty lines of an output, at most
+// the given number. The output is streamed, so only the tail is kept.
+func ParseOutputTail(r io.Reader, lines int) ([]string, error) {
+	var tail []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		tail = append(tail, line)
+		if len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	return tail, nil
+}
+
+// maxLogLineSize is the longest line accepted when streaming a log file
+const maxLogLineSize = 16 * 1024 * 1024
+
+// ParseBackupReader parses backup JSON output line by line. Only the
+// summary, error messages and the lines of a human-readable summary are
+// kept, so memory use does not grow with the size of the log. A failed
+// backup that still reported a summary is marked as degraded.
+func ParseBackupReader(r io.Reader, success bool) (*BackupResult, error) {
+	var lastLine, summaryLine string
+	var textSummary strings.Builder
+	var errors []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
 		}
+		lastLine = line
+		switch {
+		case !strings.HasPrefix(line, "{"):
+			if isTextSummaryLine(line) {
+				textSummary.WriteString(line)
+				textSummary.WriteString("\n")
+			}
+		case strings.Contains(line, `"message_type":"summary"`):
+			summaryLine = line
+		case strings.Contains(line, `"message_type":"error"`):
+			if text, ok := parseErrorMessage(line); ok {
+				errors = append(errors, text)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read backup output: %w", err)
 	}
 
 	if lastLine == "" {
 		return &BackupResult{}, nil
 	}
 
+	// Fall back to the human-readable summary if restic ran without --json
+	if !strings.HasPrefix(lastLine, "{") && summaryLine == "" {
+		result := parseBackupText(textSummary.String())
+		result.Errors = errors
+		result.Degraded = !success && textSummary.Len() > 0
+		return result, nil
+	}
+
+	// An error message may follow the summary of a failed backup
+	if summaryLine == "" {
+		summaryLine = lastLine
+	}
+
 	var msg ResticMessage
-	if err := json.Unmarshal([]byte(lastLine), &msg); err != nil {
+	if err := json.Unmarshal([]byte(summaryLine), &msg); err != nil {
 		return nil, fmt.Errorf("failed to parse backup summary JSON: %w", err)
 	}
 
@@ -44,24 +160,217 @@ func ParseBackupOutput(content string, success bool) (*BackupResult, error) {
 		TotalFilesProcessed: msg.TotalFilesProcessed,
 		TotalBytesProcessed: msg.TotalBytesProcessed,
 		TotalDuration:       msg.TotalDuration,
+		Errors:              errors,
+		Degraded:            !success && msg.MessageType == "summary",
+	}
+
+	// Some restic versions only report the start and end of the backup
+	if result.TotalDuration == 0 && msg.BackupStart != "" && msg.BackupEnd != "" {
+		start, startErr := time.Parse(time.RFC3339Nano, msg.BackupStart)
+		end, endErr := time.Parse(time.RFC3339Nano, msg.BackupEnd)
+		if startErr == nil && endErr == nil && end.After(start) {
+			result.TotalDuration = end.Sub(start).Seconds()
+		}
 	}
 
+	clampBackupResult(result)
 	return result, nil
 }
 
-// ParseCheckOutput parses check JSON output
+// exitCodeIncomplete is the exit code of restic backup if some source files
+// could not be read
+const exitCodeIncomplete = 3
+
+// ParseBackupStream parses the newline-delimited JSON of restic backup
+// --json piped into restic-kit. There is no exit code file in that case, so
+// the exit code is derived from the messages: the code of an exit_error
+// message, 3 if restic reported errors for individual files, 1 if there is
+// no summary and 0 otherwise.
+func ParseBackupStream(r io.Reader) (*BackupResult, int, error) {
+	var summaryLine string
+	var errors []string
+	exitCode := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var msg ResticMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+		switch msg.MessageType {
+		case "summary":
+			summaryLine = line
+		case "error":
+			if text, ok := parseErrorMessage(line); ok {
+				errors = append(errors, text)
+			}
+		case "exit_error":
+			exitCode = max(msg.Code, 1)
+			errors = append(errors, msg.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read backup output: %w", err)
+	}
+
+	if exitCode == 0 {
+		switch {
+		case summaryLine == "":
+			exitCode = 1
+		case len(errors) > 0:
+			exitCode = exitCodeIncomplete
+		}
+	}
+
+	if summaryLine == "" {
+		return &BackupResult{Errors: errors}, exitCode, nil
+	}
+	result, err := ParseBackupReader(strings.NewReader(summaryLine), exitCode == 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	result.Errors = errors
+	return result, exitCode, nil
+}
+
+// clampBackupResult resets negative values of a garbled summary to zero, so
+// they cannot distort the totals of a report
+func clampBackupResult(result *BackupResult) {
+	for _, counter := range []*int{&result.FilesNew, &result.FilesChanged, &result.FilesUnmodified,
+		&result.DirsNew, &result.DirsChanged, &result.DirsUnmodified, &result.TotalFilesProcessed} {
+		*counter = max(*counter, 0)
+	}
+	for _, size := range []*int64{&result.DataAdded, &result.DataAddedPacked, &result.TotalBytesProcessed} {
+		*size = max(*size, 0)
+	}
+	if result.TotalDuration < 0 || math.IsNaN(result.TotalDuration) || math.IsInf(result.TotalDuration, 0) {
+		result.TotalDuration = 0
+	}
+}
+
+// ParseCheckOutput parses check JSON output. restic emits one JSON message
+// per line, the summary message is used if present.
 func ParseCheckOutput(content string, success bool) (*CheckResult, error) {
-	var msg ResticMessage
-	if err := json.Unmarshal([]byte(content), &msg); err != nil {
+	// Fall back to the human-readable output if restic ran without --json
+	if !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return parseCheckText(content), nil
+	}
+
+	messages, err := splitJSONMessages(content)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse check output as JSON: %w", err)
 	}
 
-	result := &CheckResult{
-		NumErrors: msg.NumErrors,
+	result := &CheckResult{}
+	foundSummary := false
+	foundNumErrors := false
+	for _, raw := range messages {
+		var msg ResticMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse check output as JSON: %w", err)
+		}
+
+		switch {
+		case msg.MessageType == "error":
+			if msg.Message != "" {
+				result.Errors = append(result.Errors, msg.Message)
+			}
+		case msg.MessageType == "summary":
+			result.NumErrors = msg.NumErrors
+			foundSummary = true
+		case !foundSummary && strings.Contains(string(raw), `"num_errors"`):
+			result.NumErrors = msg.NumErrors
+			foundNumErrors = true
+		}
+	}
+
+	// Without a summary, the collected error messages are the best count
+	if !foundSummary && !foundNumErrors {
+		result.NumErrors = len(result.Errors)
 	}
 	return result, nil
 }
 
+// splitJSONMessages splits restic JSON output into its messages. The output
+// is usually one message per line, but may also be pretty-printed. If it is
+// not a clean stream of JSON values, lines not starting with "{" are skipped.
+func splitJSONMessages(content string) ([]json.RawMessage, error) {
+	var messages []json.RawMessage
+	decoder := json.NewDecoder(strings.NewReader(content))
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			break
+		}
+		messages = append(messages, raw)
+	}
+
+	messages = nil
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("invalid JSON message %q", line)
+		}
+		messages = append(messages, json.RawMessage(line))
+	}
+	return messages, nil
+}
+
+// ParsePruneOutput parses prune JSON output. Non-JSON lines are ignored and
+// the last summary message is used.
+func ParsePruneOutput(content string, success bool) (*PruneResult, error) {
+	lines := strings.Split(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var msg ResticMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse prune output as JSON: %w", err)
+		}
+		if msg.MessageType != "summary" {
+			continue
+		}
+
+		return &PruneResult{
+			ToBeRepacked: msg.ToBeRepacked,
+			BlobsRemoved: msg.BlobsRemoved,
+			PacksDeleted: msg.PacksDeleted,
+			SizeFreed:    msg.SizeFreed,
+		}, nil
+	}
+
+	return &PruneResult{}, nil
+}
+
+// ParseStatsOutput parses stats JSON output
+func ParseStatsOutput(content string, success bool) (*StatsResult, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return &StatsResult{}, nil
+	}
+
+	var result StatsResult
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse stats output as JSON: %w", err)
+	}
+	return &result, nil
+}
+
 // ParseSnapshotsOutput parses snapshots JSON output
 func ParseSnapshotsOutput(content string) ([]Snapshot, error) {
 	var snapshotGroups []SnapshotGroup
@@ -71,90 +380,169 @@ func ParseSnapshotsOutput(content string) ([]Snapshot, error) {
 
 	var snapshots []Snapshot
 	for _, group := range snapshotGroups {
-		snapshots = append(snapshots, group.Snapshots...)
+		for _, snap := range group.Snapshots {
+			// Tags are only part of the group key when grouping by tags
+			if len(snap.Tags) == 0 {
+				snap.Tags = group.GroupKey.Tags
+			}
+			snapshots = append(snapshots, snap)
+		}
 	}
 	return snapshots, nil
 }
 
+// ParseDiffOutput parses diff JSON output. The statistics message is used if
+// present, otherwise the per-file change messages are counted.
+func ParseDiffOutput(content string) (*DiffResult, error) {
+	messages, err := splitJSONMessages(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff output as JSON: %w", err)
+	}
+
+	result := &DiffResult{}
+	var counted DiffResult
+	foundStatistics := false
+	for _, raw := range messages {
+		var msg ResticMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse diff output as JSON: %w", err)
+		}
+
+		switch msg.MessageType {
+		case "change":
+			switch msg.Modifier {
+			case "+":
+				counted.FilesAdded++
+			case "-":
+				counted.FilesRemoved++
+			case "M", "T", "U":
+				counted.FilesChanged++
+			}
+		case "statistics":
+			foundStatistics = true
+			result.SourceSnapshot = msg.SourceSnapshot
+			result.TargetSnapshot = msg.TargetSnapshot
+			result.FilesChanged = msg.ChangedFiles
+			if msg.Added != nil {
+				result.FilesAdded = msg.Added.Files
+				result.SizeDelta += msg.Added.Bytes
+			}
+			if msg.Removed != nil {
+				result.FilesRemoved = msg.Removed.Files
+				result.SizeDelta -= msg.Removed.Bytes
+			}
+		}
+	}
+
+	if !foundStatistics {
+		return &counted, nil
+	}
+	return result, nil
+}
+
+// ParseCopyOutput parses the output of restic copy. Every summary message
+// counts as a copied snapshot and adds its data to the bytes copied. The
+// human-readable "snapshot X saved" and "skipping snapshot" lines are counted
+// as well, so output without --json is understood too.
+func ParseCopyOutput(content string) (*CopyResult, error) {
+	result := &CopyResult{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "{"):
+			var msg ResticMessage
+			if err := json.Unmarshal([]byte(line), &msg); err != nil {
+				return nil, fmt.Errorf("failed to parse copy output as JSON: %w", err)
+			}
+			if msg.MessageType == "summary" {
+				result.SnapshotsCopied++
+				result.BytesCopied += msg.DataAdded
+			}
+		case strings.HasPrefix(line, "skipping snapshot "):
+			result.SnapshotsSkipped++
+		case strings.HasPrefix(line, "snapshot ") && strings.HasSuffix(line, " saved"):
+			result.SnapshotsCopied++
+		}
+	}
+	return result, nil
+}
+
 // ForgetGroup represents a group in forget output
 type ForgetGroup struct {
-	Tags    []string   `json:"tags"`
-	Host    string     `json:"host"`
-	Paths   []string   `json:"paths"`
-	Keep    []Snapshot `json:"keep"`
-	Remove  []Snapshot `json:"remove"`
-	Reasons []struct {
-		Snapshot Snapshot `json:"snapshot"`
-		Matches  []string `json:"matches"`
-	} `json:"reasons"`
-}
-
-// ParseForgetOutput parses forget JSON output and returns kept snapshots and removed count
-func ParseForgetOutput(content string) ([]Snapshot, int, error) {
-	// Find the JSON part (first line should be JSON)
-	lines := strings.Split(content, "\n")
-	var jsonContent string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "[") {
-			jsonContent = line
+	Tags    []string       `json:"tags"`
+	Host    string         `json:"host"`
+	Paths   []string       `json:"paths"`
+	Keep    []Snapshot     `json:"keep"`
+	Remove  []Snapshot     `json:"remove"`
+	Reasons []ForgetReason `json:"reasons"`
+}
+
+// ParseForgetOutput parses forget JSON output and returns the kept snapshots,
+// the reasons they were kept and the removed count
+func ParseForgetOutput(content string) (*ForgetResult, error) {
+	// Find the start of the JSON array. Pretty-printed output spreads it
+	// across many lines, so everything from there on is decoded.
+	start := -1
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			start = offset + strings.Index(line, "[")
 			break
 		}
+		offset += len(line)
 	}
 
-	if jsonContent == "" {
-		return nil, 0, fmt.Errorf("no JSON content found in forget output")
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON content found in forget output")
 	}
 
+	// Decode only the first JSON value so trailing output is ignored
 	var forgetGroups []ForgetGroup
-	if err := json.Unmarshal([]byte(jsonContent), &forgetGroups); err != nil {
-		return nil, 0, fmt.Errorf("failed to parse forget output as JSON: %w", err)
+	if err := json.NewDecoder(strings.NewReader(content[start:])).Decode(&forgetGroups); err != nil {
+		return nil, fmt.Errorf("failed to parse forget output as JSON: %w", err)
 	}
 
-	var keptSnapshots []Snapshot
-	removedCount := 0
-
+	// Count removed snapshots by ID so a snapshot listed in several groups
+	// is only counted once
+	result := &ForgetResult{}
+	removedIDs := make(map[string]bool)
 	for _, group := range forgetGroups {
-		keptSnapshots = append(keptSnapshots, group.Keep...)
-		removedCount += len(group.Remove)
+		result.Kept = append(result.Kept, group.Keep...)
+		result.Reasons = append(result.Reasons, group.Reasons...)
+		for _, snap := range group.Remove {
+			if snap.ID != "" {
+				if removedIDs[snap.ID] {
+					continue
+				}
+				removedIDs[snap.ID] = true
+			}
+			result.Removed = append(result.Removed, snap)
+			result.RemovedCount++
+		}
 	}
 
-	return keptSnapshots, removedCount, nil
-}
-
-// readExitCode reads exit code from file
-func readExitCode(exitcodeFile string) (int, error) {
-	content, err := os.ReadFile(exitcodeFile)
-	if err != nil {
-		return -1, err
-	}
-	code, err := strconv.Atoi(strings.TrimSpace(string(content)))
-	if err != nil {
-		return -1, fmt.Errorf("invalid exit code in %s: %w", exitcodeFile, err)
-	}
-	return code, nil
+	return result, nil
 }
 
-// determineActionType determines action type from exitcode filename
-func determineActionType(exitcodeFile string) (string, string) {
+// DetermineActionType determines the action type and name from an exitcode
+// filename, e.g. backup.home.exitcode is the backup action "home"
+func DetermineActionType(exitcodeFile string) (string, string) {
 	base := filepath.Base(exitcodeFile)
 	base = strings.TrimSuffix(base, ".exitcode")
 
 	if strings.HasPrefix(base, "backup.") {
 		actionName := strings.TrimPrefix(base, "backup.")
 		return "backup", actionName
-	} else if base == "check" {
-		return "check", base
-	} else if base == "snapshots" {
-		return "snapshots", base
-	} else if base == "forget" {
-		return "forget", base
+	}
+	switch base {
+	case "check", "snapshots", "forget", "prune", "stats", "diff", "copy":
+		return base, base
 	}
 	return "unknown", base
 }
 
-// determineOverallSuccess determines overall success from action results
-func determineOverallSuccessFromActions(actions []ActionResult) bool {
+// DetermineOverallSuccess reports whether all actions succeeded
+func DetermineOverallSuccess(actions []ActionResult) bool {
 	for _, action := range actions {
 		if !action.IsSuccess() {
 			return false
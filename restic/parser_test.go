@@ -0,0 +1,561 @@
+package restic
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseBackupOutputStartEnd(t *testing.T) {
+	content := `{"message_type":"status","percent_done":1}
+{"message_type":"summary","files_new":1,"backup_start":"2025-01-01T02:00:00.5+01:00","backup_end":"2025-01-01T02:01:30.75+01:00"}`
+
+	result, err := ParseBackupOutput(content, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.TotalDuration != 90.25 {
+		t.Errorf("Expected duration 90.25, got %v", result.TotalDuration)
+	}
+
+	action := &BackupActionResult{Name: "home", Success: true, Result: result}
+	if got := action.GetSummaryInfo()["duration"]; got != "90.25" {
+		t.Errorf("Expected duration in summary info, got %q", got)
+	}
+
+	// An explicit total_duration takes precedence
+	content = `{"message_type":"summary","total_duration":12.5,"backup_start":"2025-01-01T02:00:00Z","backup_end":"2025-01-01T02:01:30Z"}`
+	result, err = ParseBackupOutput(content, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.TotalDuration != 12.5 {
+		t.Errorf("Expected duration 12.5, got %v", result.TotalDuration)
+	}
+}
+
+func TestParseSnapshotsOutputTags(t *testing.T) {
+	content := `[{"group_key":{"hostname":"","paths":null,"tags":["daily"]},"snapshots":[{"id":"a","paths":["/home"]},{"id":"b","paths":["/etc"],"tags":["daily","etc"]}]}]`
+
+	snapshots, err := ParseSnapshotsOutput(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(snapshots))
+	}
+	if len(snapshots[0].Tags) != 1 || snapshots[0].Tags[0] != "daily" {
+		t.Errorf("Expected tags from group key, got %v", snapshots[0].Tags)
+	}
+	if len(snapshots[1].Tags) != 2 {
+		t.Errorf("Expected snapshot tags to be kept, got %v", snapshots[1].Tags)
+	}
+}
+
+func TestParseForgetOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantKept    int
+		wantRemoved int
+	}{
+		{
+			name:        "single line",
+			content:     `[{"tags":null,"host":"","paths":["/test"],"keep":[{"id":"abc123"}],"remove":[{"id":"def456"}]}]`,
+			wantKept:    1,
+			wantRemoved: 1,
+		},
+		{
+			name: "multi-line with surrounding output",
+			content: `Applying Policy: keep 7 daily snapshots
+[
+  {
+    "tags": null,
+    "host": "server",
+    "paths": [
+      "/etc"
+    ],
+    "keep": [
+      {
+        "time": "2025-10-30T23:34:19.35394226+01:00",
+        "id": "abc123"
+      },
+      {
+        "time": "2025-10-29T23:34:19.35394226+01:00",
+        "id": "abc124"
+      }
+    ],
+    "remove": [
+      {
+        "time": "2025-10-01T23:34:19.35394226+01:00",
+        "id": "def456"
+      }
+    ]
+  }
+]
+remove 1 snapshots`,
+			wantKept:    2,
+			wantRemoved: 1,
+		},
+		{
+			name:        "multiple groups",
+			content:     `[{"host":"a","paths":["/etc"],"keep":[{"id":"k1"}],"remove":[{"id":"r1"},{"id":"r2"}]},{"host":"b","paths":["/home"],"keep":[{"id":"k2"}],"remove":[{"id":"r3"}]}]`,
+			wantKept:    2,
+			wantRemoved: 3,
+		},
+		{
+			name:        "snapshot listed in several groups",
+			content:     `[{"tags":["daily"],"keep":[],"remove":[{"id":"r1"},{"id":"r2"}]},{"tags":["weekly"],"keep":[],"remove":[{"id":"r2"}]}]`,
+			wantKept:    0,
+			wantRemoved: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseForgetOutput(tt.content)
+			if err != nil {
+				t.Fatalf("ParseForgetOutput() error = %v", err)
+			}
+			if len(result.Kept) != tt.wantKept {
+				t.Errorf("ParseForgetOutput() kept = %d, want %d", len(result.Kept), tt.wantKept)
+			}
+			if result.RemovedCount != tt.wantRemoved {
+				t.Errorf("ParseForgetOutput() removed = %d, want %d", result.RemovedCount, tt.wantRemoved)
+			}
+			if len(result.Removed) != tt.wantRemoved {
+				t.Errorf("ParseForgetOutput() removed snapshots = %d, want %d", len(result.Removed), tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestParseForgetOutputReasons(t *testing.T) {
+	content := `[{"tags":null,"host":"","paths":["/etc"],"keep":[{"id":"abc123"}],"remove":[],"reasons":[{"snapshot":{"id":"abc123"},"matches":["last snapshot","daily snapshot"]}]}]`
+
+	result, err := ParseForgetOutput(content)
+	if err != nil {
+		t.Fatalf("ParseForgetOutput() error = %v", err)
+	}
+	if len(result.Reasons) != 1 || result.Reasons[0].Snapshot.ID != "abc123" || len(result.Reasons[0].Matches) != 2 {
+		t.Errorf("ParseForgetOutput() reasons = %+v", result.Reasons)
+	}
+}
+
+func TestParseForgetOutputNoJSON(t *testing.T) {
+	if _, err := ParseForgetOutput("no snapshots to forget\n"); err == nil {
+		t.Error("ParseForgetOutput() expected error, got nil")
+	}
+}
+
+func TestParseCheckOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantErrors int
+	}{
+		{
+			name:       "single summary",
+			content:    `{"message_type":"summary","num_errors":0}`,
+			wantErrors: 0,
+		},
+		{
+			name: "multi-line with progress messages",
+			content: `{"message_type":"status","percent_done":0.25,"total":4}
+{"message_type":"status","percent_done":0.5,"total":4}
+{"message_type":"error","message":"pack 1234abcd: not referenced in any index"}
+{"message_type":"status","percent_done":1,"total":4}
+{"message_type":"summary","num_errors":2,"broken_packs":["1234abcd"],"suggest_repair_index":true,"suggest_prune":false}
+`,
+			wantErrors: 2,
+		},
+		{
+			name: "num_errors without summary type",
+			content: `{"message_type":"status","percent_done":1}
+{"num_errors":3}`,
+			wantErrors: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseCheckOutput(tt.content, tt.wantErrors == 0)
+			if err != nil {
+				t.Fatalf("ParseCheckOutput() error = %v", err)
+			}
+			if result.NumErrors != tt.wantErrors {
+				t.Errorf("ParseCheckOutput() NumErrors = %d, want %d", result.NumErrors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseCheckOutputPrettyPrinted(t *testing.T) {
+	content := `{
+    "message_type": "summary",
+    "num_errors": 2,
+    "broken_packs": null,
+    "suggest_repair_index": false
+}
+`
+	result, err := ParseCheckOutput(content, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.NumErrors != 2 {
+		t.Errorf("Expected 2 errors, got %d", result.NumErrors)
+	}
+}
+
+func TestParseCheckOutputErrors(t *testing.T) {
+	content := `{"message_type":"status","percent_done":0.5}
+{"message_type":"error","message":"pack 1234abcd: not referenced in any index"}
+{"message_type":"error","message":"tree 5678ef90: file \"a.txt\" blob 0 not found in index"}
+{"message_type":"summary","num_errors":2}`
+
+	result, err := ParseCheckOutput(content, false)
+	if err != nil {
+		t.Fatalf("ParseCheckOutput() error = %v", err)
+	}
+	if result.NumErrors != 2 || len(result.Errors) != 2 {
+		t.Fatalf("ParseCheckOutput() = %+v", result)
+	}
+	if result.Errors[0] != "pack 1234abcd: not referenced in any index" {
+		t.Errorf("Unexpected first error: %q", result.Errors[0])
+	}
+
+	// Without a summary, the error messages are counted
+	result, err = ParseCheckOutput(`{"message_type":"error","message":"Fatal: repository contains errors"}`, false)
+	if err != nil {
+		t.Fatalf("ParseCheckOutput() error = %v", err)
+	}
+	if result.NumErrors != 1 {
+		t.Errorf("ParseCheckOutput() NumErrors = %d, want 1", result.NumErrors)
+	}
+}
+
+func TestParseDiffOutput(t *testing.T) {
+	content := `{"message_type":"change","path":"/home/user/new.txt","modifier":"+"}
+{"message_type":"change","path":"/home/user/old.txt","modifier":"-"}
+{"message_type":"change","path":"/home/user/doc.txt","modifier":"M"}
+{"message_type":"statistics","source_snapshot":"1111111111111111","target_snapshot":"2222222222222222","changed_files":1,"added":{"files":1,"dirs":0,"others":0,"data_blobs":1,"tree_blobs":1,"bytes":3072},"removed":{"files":1,"dirs":0,"others":0,"data_blobs":1,"tree_blobs":1,"bytes":1024}}
+`
+	result, err := ParseDiffOutput(content)
+	if err != nil {
+		t.Fatalf("ParseDiffOutput() error = %v", err)
+	}
+	if result.FilesAdded != 1 || result.FilesRemoved != 1 || result.FilesChanged != 1 {
+		t.Errorf("Unexpected file counts: %+v", result)
+	}
+	if result.SizeDelta != 2048 {
+		t.Errorf("Expected size delta 2048, got %d", result.SizeDelta)
+	}
+	if result.SourceSnapshot != "1111111111111111" || result.TargetSnapshot != "2222222222222222" {
+		t.Errorf("Unexpected snapshots: %+v", result)
+	}
+
+	// Without statistics, the change messages are counted
+	lines := strings.Split(content, "\n")
+	result, err = ParseDiffOutput(strings.Join(lines[:3], "\n"))
+	if err != nil {
+		t.Fatalf("ParseDiffOutput() error = %v", err)
+	}
+	if result.FilesAdded != 1 || result.FilesRemoved != 1 || result.FilesChanged != 1 || result.SizeDelta != 0 {
+		t.Errorf("Unexpected counted result: %+v", result)
+	}
+}
+
+func TestBackupSummaryThroughput(t *testing.T) {
+	action := &BackupActionResult{Name: "home", Success: true, Result: &BackupResult{
+		TotalBytesProcessed: 50 * 1024 * 1024,
+		TotalDuration:       20,
+	}}
+	if got := action.GetSummaryInfo()["throughput"]; got != "2.5 MB/s" {
+		t.Errorf("Expected throughput 2.5 MB/s, got %q", got)
+	}
+
+	// Without a duration there is no throughput
+	action.Result.TotalDuration = 0
+	if _, ok := action.GetSummaryInfo()["throughput"]; ok {
+		t.Error("Expected no throughput without a duration")
+	}
+}
+
+func TestParseBackupReader(t *testing.T) {
+	// Many progress messages followed by the summary, read as a stream
+	progress := strings.NewReader(strings.Repeat(`{"message_type":"status","percent_done":0.5,"files_done":10}`+"\n", 10000))
+	summary := strings.NewReader(`{"message_type":"summary","files_new":7,"total_bytes_processed":2048}` + "\n\n")
+
+	result, err := ParseBackupReader(io.MultiReader(progress, summary), true)
+	if err != nil {
+		t.Fatalf("ParseBackupReader() error = %v", err)
+	}
+	if result.FilesNew != 7 || result.TotalBytesProcessed != 2048 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// The human-readable summary is still found without --json
+	text := `open repository
+Files:           5 new,     2 changed,   100 unmodified
+Dirs:            1 new,     0 changed,    50 unmodified
+Added to the repository: 1.000 MiB (512.000 KiB stored)
+
+processed 107 files, 2.000 MiB in 0:10
+snapshot 1234abcd saved
+`
+	result, err = ParseBackupReader(strings.NewReader(text), true)
+	if err != nil {
+		t.Fatalf("ParseBackupReader() error = %v", err)
+	}
+	if result.FilesNew != 5 || result.DirsNew != 1 || result.DataAdded != 1024*1024 || result.TotalFilesProcessed != 107 {
+		t.Errorf("Unexpected text result: %+v", result)
+	}
+}
+
+func TestParseBackupReaderDegraded(t *testing.T) {
+	output := `{"message_type":"status","percent_done":0.5}
+{"message_type":"error","error":{"message":"open /data/locked.db: permission denied"},"during":"archival","item":"/data/locked.db"}
+{"message_type":"error","error":{"message":"read failed"},"during":"archival","item":"/data/broken"}
+{"message_type":"summary","files_new":3,"total_files_processed":10}
+{"message_type":"error","error":{"message":"at least one source file could not be read"}}
+`
+	result, err := ParseBackupReader(strings.NewReader(output), false)
+	if err != nil {
+		t.Fatalf("ParseBackupReader() error = %v", err)
+	}
+	if result.FilesNew != 3 || result.TotalFilesProcessed != 10 {
+		t.Errorf("Expected summary stats of the failed backup, got %+v", result)
+	}
+	if !result.Degraded {
+		t.Error("Expected failed backup with summary to be degraded")
+	}
+	expected := []string{
+		"open /data/locked.db: permission denied",
+		"/data/broken: read failed",
+		"at least one source file could not be read",
+	}
+	if len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), result.Errors)
+	}
+	for i, want := range expected {
+		if result.Errors[i] != want {
+			t.Errorf("Error %d: expected %q, got %q", i, want, result.Errors[i])
+		}
+	}
+
+	// A successful backup is never degraded
+	result, _ = ParseBackupReader(strings.NewReader(output), true)
+	if result.Degraded {
+		t.Error("Expected successful backup not to be degraded")
+	}
+
+	// A failed backup without summary is not degraded
+	result, _ = ParseBackupReader(strings.NewReader(`{"message_type":"error","error":{"message":"repository not found"}}`), false)
+	if result.Degraded || len(result.Errors) != 1 {
+		t.Errorf("Expected plain failure with one error, got %+v", result)
+	}
+}
+
+func TestParseBackupStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		wantExitCode int
+		wantFilesNew int
+		wantDegraded bool
+		wantErrors   int
+	}{
+		{
+			name: "success",
+			output: `{"message_type":"status","percent_done":0.5}
+{"message_type":"verbose_status","action":"new","item":"/data/a"}
+{"message_type":"summary","files_new":3,"total_files_processed":10}`,
+			wantExitCode: 0,
+			wantFilesNew: 3,
+		},
+		{
+			name: "unreadable files",
+			output: `{"message_type":"error","error":{"message":"permission denied"},"during":"archival","item":"/data/locked.db"}
+{"message_type":"summary","files_new":2}`,
+			wantExitCode: 3,
+			wantFilesNew: 2,
+			wantDegraded: true,
+			wantErrors:   1,
+		},
+		{
+			name:         "exit error",
+			output:       `{"message_type":"exit_error","code":10,"message":"Fatal: repository does not exist"}`,
+			wantExitCode: 10,
+			wantErrors:   1,
+		},
+		{
+			name:         "no output",
+			output:       "",
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, exitCode, err := ParseBackupStream(strings.NewReader(tt.output))
+			if err != nil {
+				t.Fatalf("ParseBackupStream() error = %v", err)
+			}
+			if exitCode != tt.wantExitCode {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExitCode)
+			}
+			if result.FilesNew != tt.wantFilesNew || result.Degraded != tt.wantDegraded || len(result.Errors) != tt.wantErrors {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestParseCopyOutput(t *testing.T) {
+	text := `repository 1a2b3c4d opened (version 2, compression level auto)
+repository 5e6f7a8b opened (version 2, compression level auto)
+
+snapshot 3f2e1d0c of [/home] at 2024-01-01 10:00:00 +0000 UTC)
+  copy started, this may take a while...
+snapshot a1b2c3d4 saved
+
+snapshot 9988aabb of [/etc] at 2024-01-01 10:00:00 +0000 UTC)
+skipping snapshot 9988aabb, was already copied to snapshot 11223344
+`
+	result, err := ParseCopyOutput(text)
+	if err != nil {
+		t.Fatalf("ParseCopyOutput() error = %v", err)
+	}
+	if result.SnapshotsCopied != 1 || result.SnapshotsSkipped != 1 {
+		t.Errorf("Unexpected text result: %+v", result)
+	}
+
+	jsonOutput := `{"message_type":"summary","data_added":1024}
+{"message_type":"summary","data_added":2048}
+`
+	result, err = ParseCopyOutput(jsonOutput)
+	if err != nil {
+		t.Fatalf("ParseCopyOutput() error = %v", err)
+	}
+	if result.SnapshotsCopied != 2 || result.BytesCopied != 3072 {
+		t.Errorf("Unexpected JSON result: %+v", result)
+	}
+
+	if _, err := ParseCopyOutput("{invalid"); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestParseBackupReaderMalformedSummary(t *testing.T) {
+	summary := `{"message_type":"summary","files_new":-3,"files_changed":2,"data_added":-4096,"total_bytes_processed":-1,"total_duration":-5}`
+	result, err := ParseBackupReader(strings.NewReader(summary), true)
+	if err != nil {
+		t.Fatalf("ParseBackupReader() error = %v", err)
+	}
+	if result.FilesNew != 0 || result.DataAdded != 0 || result.TotalBytesProcessed != 0 || result.TotalDuration != 0 {
+		t.Errorf("Expected negative values to be clamped to zero, got %+v", result)
+	}
+	if result.FilesChanged != 2 {
+		t.Errorf("Expected valid values to be kept, got %+v", result)
+	}
+}
+
+func TestBackupSummaryValidate(t *testing.T) {
+	if err := (BackupSummary{FilesNew: 1, TotalBytesProcessed: 1024}).Validate(); err != nil {
+		t.Errorf("Expected valid summary, got %v", err)
+	}
+	err := (BackupSummary{DataAdded: -1}).Validate()
+	if err == nil || err.Error() != "data_added is negative: -1" {
+		t.Errorf("Expected negative data_added error, got %v", err)
+	}
+}
+
+func TestParseOutputTail(t *testing.T) {
+	tail, err := ParseOutputTail(strings.NewReader("one\n\ntwo\nthree\n  \nfour\n"), 3)
+	if err != nil {
+		t.Fatalf("ParseOutputTail() error = %v", err)
+	}
+	if strings.Join(tail, ",") != "two,three,four" {
+		t.Errorf("Unexpected tail %v", tail)
+	}
+
+	if tail, _ := ParseOutputTail(strings.NewReader(""), 3); len(tail) != 0 {
+		t.Errorf("Expected empty tail, got %v", tail)
+	}
+}
+
+func TestDetermineActionType(t *testing.T) {
+	tests := []struct {
+		file     string
+		wantType string
+		wantName string
+	}{
+		{"/logs/backup.home.exitcode", "backup", "home"},
+		{"/logs/backup.etc.nginx.exitcode", "backup", "etc.nginx"},
+		{"/logs/check.exitcode", "check", "check"},
+		{"/logs/snapshots.exitcode", "snapshots", "snapshots"},
+		{"/logs/forget.exitcode", "forget", "forget"},
+		{"/logs/prune.exitcode", "prune", "prune"},
+		{"/logs/stats.exitcode", "stats", "stats"},
+		{"/logs/diff.exitcode", "diff", "diff"},
+		{"/logs/copy.exitcode", "copy", "copy"},
+		{"/logs/backup.exitcode", "unknown", "backup"},
+		{"/logs/custom.exitcode", "unknown", "custom"},
+	}
+
+	for _, tt := range tests {
+		gotType, gotName := DetermineActionType(tt.file)
+		if gotType != tt.wantType || gotName != tt.wantName {
+			t.Errorf("DetermineActionType(%q) = %q, %q, want %q, %q", tt.file, gotType, gotName, tt.wantType, tt.wantName)
+		}
+	}
+}
+
+func TestDetermineOverallSuccess(t *testing.T) {
+	if !DetermineOverallSuccess(nil) {
+		t.Error("Expected success without actions")
+	}
+	actions := []ActionResult{
+		&BackupActionResult{Name: "home", Success: true},
+		&CheckActionResult{Name: "check", Success: true},
+	}
+	if !DetermineOverallSuccess(actions) {
+		t.Error("Expected success when all actions succeeded")
+	}
+	actions = append(actions, &PruneActionResult{Name: "prune", Success: false})
+	if DetermineOverallSuccess(actions) {
+		t.Error("Expected failure when an action failed")
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	stderr := `repository 1234abcd opened (version 2)
+error: lstat /root/.cache/secret: permission denied
+{"message_type":"error","error":{"message":"open /data/locked.db: permission denied"},"during":"archival","item":"/data/locked.db"}
+{"message_type":"error","error":{"message":"read failed"},"during":"archival","item":"/data/broken"}
+Warning: at least one source file could not be read
+`
+	warnings, err := ParseWarnings(strings.NewReader(stderr))
+	if err != nil {
+		t.Fatalf("ParseWarnings failed: %v", err)
+	}
+
+	expected := []string{
+		"error: lstat /root/.cache/secret: permission denied",
+		"open /data/locked.db: permission denied",
+		"/data/broken: read failed",
+		"Warning: at least one source file could not be read",
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+	for i, want := range expected {
+		if warnings[i] != want {
+			t.Errorf("Warning %d: expected %q, got %q", i, want, warnings[i])
+		}
+	}
+
+	if warnings, _ := ParseWarnings(strings.NewReader("")); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for empty output, got %v", warnings)
+	}
+}
@@ -0,0 +1,120 @@
+package restic
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Regular expressions matching restic's human-readable backup summary, e.g.
+//
+//	Files:           5 new,     2 changed,   100 unmodified
+//	Dirs:            1 new,     0 changed,    50 unmodified
+//	Added to the repository: 1.234 MiB (512.000 KiB stored)
+//	processed 107 files, 1.000 MiB in 0:10
+var (
+	textFilesRe     = regexp.MustCompile(`Files:\s+(\d+) new,\s+(\d+) changed,\s+(\d+) unmodified`)
+	textDirsRe      = regexp.MustCompile(`Dirs:\s+(\d+) new,\s+(\d+) changed,\s+(\d+) unmodified`)
+	textAddedRe     = regexp.MustCompile(`Added to the repo(?:sitory)?: ([\d.]+ [KMGTPE]?i?B)(?: \(([\d.]+ [KMGTPE]?i?B) stored\))?`)
+	textProcessedRe = regexp.MustCompile(`processed (\d+) files, ([\d.]+ [KMGTPE]?i?B) in ((?:\d+:)?\d+:\d+)`)
+	textCheckErrRe  = regexp.MustCompile(`(?im)^\s*error`)
+)
+
+// isTextSummaryLine reports whether a line may belong to restic's
+// human-readable backup summary
+func isTextSummaryLine(line string) bool {
+	return strings.Contains(line, "Files:") || strings.Contains(line, "Dirs:") ||
+		strings.Contains(line, "Added to the repo") || strings.Contains(line, "processed ")
+}
+
+// parseBackupText extracts a best-effort BackupResult from restic's
+// human-readable backup output, for logs written without --json
+func parseBackupText(content string) *BackupResult {
+	result := &BackupResult{}
+
+	if m := textFilesRe.FindStringSubmatch(content); m != nil {
+		result.FilesNew = atoi(m[1])
+		result.FilesChanged = atoi(m[2])
+		result.FilesUnmodified = atoi(m[3])
+	}
+	if m := textDirsRe.FindStringSubmatch(content); m != nil {
+		result.DirsNew = atoi(m[1])
+		result.DirsChanged = atoi(m[2])
+		result.DirsUnmodified = atoi(m[3])
+	}
+	if m := textAddedRe.FindStringSubmatch(content); m != nil {
+		result.DataAdded = parseTextSize(m[1])
+		if m[2] != "" {
+			result.DataAddedPacked = parseTextSize(m[2])
+		}
+	}
+	if m := textProcessedRe.FindStringSubmatch(content); m != nil {
+		result.TotalFilesProcessed = atoi(m[1])
+		result.TotalBytesProcessed = parseTextSize(m[2])
+		result.TotalDuration = parseTextDuration(m[3])
+	}
+
+	return result
+}
+
+// parseCheckText extracts a best-effort CheckResult from restic's
+// human-readable check output
+func parseCheckText(content string) *CheckResult {
+	if strings.Contains(content, "no errors were found") {
+		return &CheckResult{}
+	}
+
+	result := &CheckResult{}
+	var fatal []string
+	for _, line := range strings.Split(content, "\n") {
+		if textCheckErrRe.MatchString(line) {
+			result.Errors = append(result.Errors, strings.TrimSpace(line))
+		} else if strings.HasPrefix(line, "Fatal:") {
+			fatal = append(fatal, strings.TrimSpace(line))
+		}
+	}
+	if len(result.Errors) == 0 {
+		result.Errors = fatal
+	}
+	result.NumErrors = len(result.Errors)
+	return result
+}
+
+// parseTextSize converts a restic size string like "1.234 MiB" into bytes
+func parseTextSize(s string) int64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+
+	multiplier := float64(1)
+	if unit := strings.TrimSuffix(strings.TrimSuffix(fields[1], "B"), "i"); unit != "" {
+		exp := strings.Index("KMGTPE", unit)
+		if exp < 0 {
+			return 0
+		}
+		for i := 0; i <= exp; i++ {
+			multiplier *= 1024
+		}
+	}
+	return int64(value * multiplier)
+}
+
+// parseTextDuration converts a restic duration like "1:02" or "1:02:03"
+// into seconds
+func parseTextDuration(s string) float64 {
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		seconds = seconds*60 + float64(atoi(part))
+	}
+	return seconds
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,12 @@
+package shared
+
+import "time"
+
+// MaxRetryDelay caps the backoff between notification attempts
+const MaxRetryDelay = 1 * time.Minute
+
+// NextBackoff returns the delay before the next retry: the previous delay
+// doubled, capped at maxDelay
+func NextBackoff(delay, maxDelay time.Duration) time.Duration {
+	return min(delay*2, maxDelay)
+}
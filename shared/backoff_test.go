@@ -0,0 +1,23 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	tests := []struct {
+		delay    time.Duration
+		maxDelay time.Duration
+		expected time.Duration
+	}{
+		{delay: time.Second, maxDelay: time.Minute, expected: 2 * time.Second},
+		{delay: 20 * time.Second, maxDelay: 30 * time.Second, expected: 30 * time.Second},
+		{delay: time.Minute, maxDelay: time.Minute, expected: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := NextBackoff(tt.delay, tt.maxDelay); got != tt.expected {
+			t.Errorf("NextBackoff(%v, %v) = %v, want %v", tt.delay, tt.maxDelay, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig holds settings loaded from a YAML config file. The smtp block is
+// shared by all email-capable commands, all other top-level blocks are named
+// after a command. Keys within a block match the command's flag names.
+type FileConfig struct {
+	SMTP     map[string]interface{}            `yaml:"smtp"`
+	Commands map[string]map[string]interface{} `yaml:",inline"`
+}
+
+// LoadConfigFile reads and parses a YAML config file
+func LoadConfigFile(path string) (*FileConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var cfg FileConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// ApplyConfigFile sets every flag of the given command that was not set
+// explicitly on the command line from the config file. Values from the
+// command's own block take precedence over the shared smtp block.
+func ApplyConfigFile(flags *pflag.FlagSet, cfg *FileConfig, command string) error {
+	unsetEnvVars = map[string][]string{}
+	for key, value := range cfg.Commands[command] {
+		if flags.Lookup(key) == nil {
+			return fmt.Errorf("unknown option %q in %s section of config file", key, command)
+		}
+		if err := setFlagFromConfig(flags, key, value); err != nil {
+			return err
+		}
+	}
+
+	// Only email-capable commands use the smtp block
+	if flags.Lookup("smtp-host") == nil {
+		return nil
+	}
+	for key, value := range cfg.SMTP {
+		name := key
+		if flags.Lookup("smtp-"+key) != nil {
+			name = "smtp-" + key
+		} else if flags.Lookup(key) == nil {
+			return fmt.Errorf("unknown option %q in smtp section of config file", key)
+		}
+		if err := setFlagFromConfig(flags, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFlagFromConfig sets a flag from a config value unless it was already set
+func setFlagFromConfig(flags *pflag.FlagSet, name string, value interface{}) error {
+	if flags.Changed(name) {
+		return nil
+	}
+
+	values := []interface{}{value}
+	if list, ok := value.([]interface{}); ok {
+		values = list
+	}
+
+	for _, v := range values {
+		str := fmt.Sprint(v)
+		if _, ok := v.(string); ok {
+			expanded, missing := expandEnv(str)
+			unsetEnvVars[name] = append(unsetEnvVars[name], missing...)
+			str = expanded
+		}
+		if err := flags.Set(name, str); err != nil {
+			return fmt.Errorf("invalid value for %s in config file: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// unsetEnvVars maps flag names to the unset environment variables their
+// config file values referenced
+var unsetEnvVars = map[string][]string{}
+
+// expandEnv replaces ${VAR} references with the values of the environment
+// variables and $$ with a literal $. Any other $ is kept as is. Unset
+// variables expand to an empty string and are returned, so validation can
+// name them.
+func expandEnv(value string) (string, []string) {
+	var expanded strings.Builder
+	var missing []string
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			expanded.WriteByte(value[i])
+			continue
+		}
+		if value[i+1] == '$' {
+			expanded.WriteByte('$')
+			i++
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if value[i+1] != '{' || end < 3 {
+			expanded.WriteByte('$')
+			continue
+		}
+		key := value[i+2 : i+end]
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			missing = append(missing, key)
+		}
+		expanded.WriteString(v)
+		i += end
+	}
+	return expanded.String(), missing
+}
+
+// ExplainUnsetEnv adds the unset environment variables referenced by the
+// config file value of the named option to its validation error, since an
+// empty value is then most likely a missing export
+func ExplainUnsetEnv(name string, err error) error {
+	if vars := unsetEnvVars[name]; len(vars) > 0 {
+		return fmt.Errorf("%w (environment variable %s is not set)", err, strings.Join(vars, ", "))
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("RESTIC_KIT_TEST_SECRET", "s3cret")
+
+	tests := []struct {
+		value       string
+		expected    string
+		wantMissing []string
+	}{
+		{value: "${RESTIC_KIT_TEST_SECRET}", expected: "s3cret"},
+		{value: "user-${RESTIC_KIT_TEST_SECRET}-x", expected: "user-s3cret-x"},
+		{value: "pa$word", expected: "pa$word"},
+		{value: "price: 5$", expected: "price: 5$"},
+		{value: "pa$$word", expected: "pa$word"},
+		{value: "$${RESTIC_KIT_TEST_SECRET}", expected: "${RESTIC_KIT_TEST_SECRET}"},
+		{value: "${}", expected: "${}"},
+		{value: "${RESTIC_KIT_TEST_UNSET}", expected: "", wantMissing: []string{"RESTIC_KIT_TEST_UNSET"}},
+	}
+	for _, tt := range tests {
+		got, missing := expandEnv(tt.value)
+		if got != tt.expected {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.value, got, tt.expected)
+		}
+		if strings.Join(missing, ",") != strings.Join(tt.wantMissing, ",") {
+			t.Errorf("expandEnv(%q) missing = %v, want %v", tt.value, missing, tt.wantMissing)
+		}
+	}
+}
+
+func TestApplyConfigFileUnsetEnv(t *testing.T) {
+	t.Cleanup(func() { unsetEnvVars = map[string][]string{} })
+
+	flags := pflag.NewFlagSet("notify-email", pflag.ContinueOnError)
+	flags.String("smtp-host", "", "")
+	flags.String("smtp-password", "", "")
+
+	cfg := &FileConfig{SMTP: map[string]interface{}{
+		"host":     "smtp.example.com",
+		"password": "${RESTIC_KIT_TEST_UNSET}",
+	}}
+	if err := ApplyConfigFile(flags, cfg, "notify-email"); err != nil {
+		t.Fatalf("Expected unset variable to expand to empty, got %v", err)
+	}
+	if password, _ := flags.GetString("smtp-password"); password != "" {
+		t.Errorf("Expected empty password, got %q", password)
+	}
+
+	err := ValidateNotifyEmailConfig(&NotifyEmailConfig{
+		SMTPHost:     "smtp.example.com",
+		SMTPUsername: "user",
+		From:         "from@example.com",
+		To:           "to@example.com",
+	})
+	if err == nil || err.Error() != "smtp-password is required (environment variable RESTIC_KIT_TEST_UNSET is not set)" {
+		t.Errorf("Expected validation error naming the variable, got %v", err)
+	}
+}
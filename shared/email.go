@@ -1,46 +1,256 @@
 package shared
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	gomail "gopkg.in/gomail.v2"
 )
 
 // NotifyEmailConfig holds configuration for email notifications
 type NotifyEmailConfig struct {
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUsername string
-	SMTPPassword string
-	From         string
-	To           string
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPAuthMethod         string
+	SMTPToken              string
+	SMTPEncryption         string
+	SMTPInsecureSkipVerify bool
+	SMTPRetries            int
+	SMTPRetryDelay         time.Duration
+	SMTPTimeout            time.Duration
+	From                   string
+	FromName               string
+	ReplyTo                string
+	To                     string
+	Cc                     string
+	Bcc                    string
+	Format                 string
+	InlineErrors           bool
+	InlineErrorLines       int
+	AttachCompress         bool
+	AttachCompressMinSize  int64
+	AttachAll              bool
+	AttachReport           string
+	MaxAttachmentSize      int64
+	MaxSnapshotRows        int
+	Columns                []string
+	SubjectPrefix          string
+	HostnameInSubject      bool
+	RepoName               string
+	ExitOnFailure          bool
+	OnFailureOnly          bool
+	ResultFile             string
+	Stdin                  bool
+	Since                  string
+	Until                  string
+	TimeRange              TimeRange
+	BodyTemplate           string
+	SubjectTemplate        string
+	ParsedSubjectTemplate  *template.Template
+	Timezone               string
+	Location               *time.Location
 }
 
 // ValidateNotifyEmailConfig validates the email notification config
 func ValidateNotifyEmailConfig(cfg *NotifyEmailConfig) error {
 	if cfg.SMTPHost == "" {
-		return fmt.Errorf("smtp-host is required")
+		return ExplainUnsetEnv("smtp-host", fmt.Errorf("smtp-host is required"))
 	}
 	if cfg.SMTPPort == 0 {
 		cfg.SMTPPort = 587
 	}
+	if cfg.SMTPEncryption == "" {
+		// Keep gomail's default: implicit TLS on 465, STARTTLS otherwise
+		if cfg.SMTPPort == 465 {
+			cfg.SMTPEncryption = "tls"
+		} else {
+			cfg.SMTPEncryption = "starttls"
+		}
+	}
+	if cfg.SMTPEncryption != "none" && cfg.SMTPEncryption != "starttls" && cfg.SMTPEncryption != "tls" {
+		return fmt.Errorf("smtp-encryption must be one of none, starttls or tls")
+	}
+	if cfg.SMTPRetries < 0 {
+		return fmt.Errorf("smtp-retries must be non-negative")
+	}
+	if cfg.SMTPRetryDelay == 0 {
+		cfg.SMTPRetryDelay = 2 * time.Second
+	}
+	if cfg.SMTPTimeout < 0 {
+		return fmt.Errorf("smtp-timeout must be non-negative")
+	}
+	if cfg.SMTPTimeout == 0 {
+		cfg.SMTPTimeout = 30 * time.Second
+	}
 	if cfg.From == "" {
-		return fmt.Errorf("from is required")
+		return ExplainUnsetEnv("from", fmt.Errorf("from is required"))
 	}
 	if cfg.To == "" {
-		return fmt.Errorf("to is required")
+		return ExplainUnsetEnv("to", fmt.Errorf("to is required"))
+	}
+	if len(SplitAddresses(cfg.To)) == 0 {
+		return ExplainUnsetEnv("to", fmt.Errorf("at least one recipient is required"))
+	}
+	for _, list := range []string{cfg.To, cfg.Cc, cfg.Bcc} {
+		for _, addr := range SplitAddresses(list) {
+			if _, err := NormalizeAddress(addr); err != nil {
+				return fmt.Errorf("invalid email address %q: %w", addr, err)
+			}
+		}
+	}
+	if cfg.ReplyTo != "" {
+		if _, err := NormalizeAddress(cfg.ReplyTo); err != nil {
+			return fmt.Errorf("invalid reply-to address %q: %w", cfg.ReplyTo, err)
+		}
 	}
 	if cfg.SMTPUsername == "" {
-		return fmt.Errorf("smtp-username is required")
+		return ExplainUnsetEnv("smtp-username", fmt.Errorf("smtp-username is required"))
+	}
+	switch cfg.SMTPAuthMethod {
+	case "", "plain", "login":
+		if cfg.SMTPPassword == "" {
+			return ExplainUnsetEnv("smtp-password", fmt.Errorf("smtp-password is required"))
+		}
+	case "xoauth2":
+		if cfg.SMTPToken == "" {
+			return ExplainUnsetEnv("smtp-token", fmt.Errorf("smtp-token is required with smtp-auth xoauth2"))
+		}
+	default:
+		return fmt.Errorf("smtp-auth must be one of plain, login or xoauth2")
+	}
+	if cfg.Format == "" {
+		cfg.Format = "text"
 	}
-	if cfg.SMTPPassword == "" {
-		return fmt.Errorf("smtp-password is required")
+	if cfg.Format != "text" && cfg.Format != "html" {
+		return fmt.Errorf("format must be either text or html")
+	}
+	if cfg.InlineErrorLines < 0 {
+		return fmt.Errorf("inline-error-lines must be non-negative")
+	}
+	if cfg.InlineErrorLines == 0 {
+		cfg.InlineErrorLines = 20
+	}
+	if cfg.AttachCompressMinSize < 0 {
+		return fmt.Errorf("attach-compress-min-size must be non-negative")
+	}
+	if cfg.MaxAttachmentSize < 0 {
+		return fmt.Errorf("max-attachment-size must be non-negative")
+	}
+	if cfg.MaxSnapshotRows < 0 {
+		return fmt.Errorf("max-snapshot-rows must be non-negative")
+	}
+	timeRange, err := ParseTimeRange(cfg.Since, cfg.Until, time.Now())
+	if err != nil {
+		return err
+	}
+	cfg.TimeRange = timeRange
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+		}
+		cfg.Location = loc
+	}
+	if cfg.SubjectTemplate != "" {
+		tmpl, err := template.New("subject").Parse(cfg.SubjectTemplate)
+		if err != nil {
+			return fmt.Errorf("failed to parse subject template: %w", err)
+		}
+		cfg.ParsedSubjectTemplate = tmpl
 	}
 	return nil
 }
 
-// SendEmail sends an email with the given configuration
-func SendEmail(cfg *NotifyEmailConfig, subject, body string, attachments []string, dryRun bool) error {
+// SMTPPasswordEnvVar is the environment variable consulted for the SMTP
+// password when neither --smtp-password nor --smtp-password-file is given
+const SMTPPasswordEnvVar = "RESTIC_KIT_SMTP_PASSWORD"
+
+// ResolveSMTPPassword determines the SMTP password with the precedence
+// explicit value > password file > environment variable
+func ResolveSMTPPassword(password, passwordFile string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	if passwordFile != "" {
+		content, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read smtp password file: %w", err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return os.Getenv(SMTPPasswordEnvVar), nil
+}
+
+// ResolveSMTPToken determines the OAuth2 access token for XOAUTH2 with the
+// precedence explicit value > token file
+func ResolveSMTPToken(token, tokenFile string) (string, error) {
+	if token != "" || tokenFile == "" {
+		return token, nil
+	}
+	content, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read smtp token file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// FormatSubject applies the configured subject prefix and hostname to a subject
+func FormatSubject(cfg *NotifyEmailConfig, subject string) string {
+	if cfg.SubjectPrefix != "" {
+		subject = cfg.SubjectPrefix + " " + subject
+	}
+	if cfg.HostnameInSubject {
+		hostname, err := os.Hostname()
+		if err != nil {
+			Verbosef("Could not determine hostname for the subject: %v\n", err)
+		} else {
+			subject = fmt.Sprintf("%s (%s)", subject, hostname)
+		}
+	}
+	return subject
+}
+
+// SubjectData is the data passed to a --subject-template template
+type SubjectData struct {
+	Success  bool
+	Status   string
+	Total    int
+	Failed   int
+	Hostname string
+	RepoName string
+}
+
+// BuildSubject renders the subject template, or uses title if none is
+// configured, and applies the subject prefix and hostname
+func BuildSubject(cfg *NotifyEmailConfig, title string, data SubjectData) (string, error) {
+	if cfg.ParsedSubjectTemplate == nil {
+		return FormatSubject(cfg, title), nil
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		data.Hostname = hostname
+	}
+	var subject strings.Builder
+	if err := cfg.ParsedSubjectTemplate.Execute(&subject, data); err != nil {
+		return "", fmt.Errorf("failed to render subject template: %w", err)
+	}
+	return FormatSubject(cfg, strings.TrimSpace(subject.String())), nil
+}
+
+// SendEmail sends an email with the given configuration. If htmlBody is not
+// empty, it is added as an HTML alternative to the plain-text body.
+func SendEmail(cfg *NotifyEmailConfig, subject, body, htmlBody string, attachments []string, dryRun bool) error {
 	if dryRun {
 		fmt.Println("DRY RUN: Would send email with subject:", subject)
 		fmt.Println("DRY RUN: Email body preview:")
@@ -48,23 +258,212 @@ func SendEmail(cfg *NotifyEmailConfig, subject, body string, attachments []strin
 		return nil
 	}
 
+	m := newMessage(cfg, subject, body, htmlBody, attachments)
+	d := NewDialer(cfg)
+
+	delay := cfg.SMTPRetryDelay
+	for attempt := 0; ; attempt++ {
+		Verbosef("Sending email via %s:%d (attempt %d)\n", cfg.SMTPHost, cfg.SMTPPort, attempt+1)
+		err := dialAndSend(d, cfg.SMTPEncryption, m, cfg.SMTPTimeout)
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.SMTPRetries || !isTransientSMTPError(err) {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+
+		Infof("Failed to send email (%v), retrying in %v...\n", err, delay)
+		time.Sleep(delay)
+		delay = NextBackoff(delay, MaxRetryDelay)
+	}
+
+	Infof("Email sent successfully\n")
+	return nil
+}
+
+// newMessage builds the email message. Without a from name, the From header
+// is the bare address. Internationalized domains are sent as punycode.
+func newMessage(cfg *NotifyEmailConfig, subject, body, htmlBody string, attachments []string) *gomail.Message {
 	m := gomail.NewMessage()
-	m.SetHeader("From", cfg.From)
-	m.SetHeader("To", cfg.To)
+	from := cfg.From
+	if normalized, err := NormalizeAddress(from); err == nil {
+		from = normalized
+	}
+	if cfg.FromName != "" {
+		m.SetAddressHeader("From", from, cfg.FromName)
+	} else {
+		m.SetHeader("From", from)
+	}
+	if cfg.ReplyTo != "" {
+		m.SetHeader("Reply-To", normalizeAddresses(cfg.ReplyTo)...)
+	}
+	m.SetHeader("To", normalizeAddresses(cfg.To)...)
+	if cc := normalizeAddresses(cfg.Cc); len(cc) > 0 {
+		m.SetHeader("Cc", cc...)
+	}
+	if bcc := normalizeAddresses(cfg.Bcc); len(bcc) > 0 {
+		m.SetHeader("Bcc", bcc...)
+	}
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body)
+	if htmlBody != "" {
+		m.AddAlternative("text/html", htmlBody)
+	}
 
 	// Attach log files
 	for _, attachment := range attachments {
 		m.Attach(attachment)
 	}
+	return m
+}
 
-	d := gomail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+// dialAndSend runs a complete SMTP session for the message. gomail only
+// limits the TCP connect and sets no deadline on the SMTP conversation, so
+// the session is driven here on a connection whose deadline covers the whole
+// attempt. When the timeout hits, the socket is closed before SendEmail
+// retries, so a slow server cannot receive the message twice. The session
+// also enforces the encryption mode: gomail upgrades to STARTTLS whenever
+// the server offers it and silently continues in plain text otherwise.
+func dialAndSend(d *gomail.Dialer, encryption string, m *gomail.Message, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(d.Host, strconv.Itoa(d.Port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+	}
 
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	tlsConfig := d.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: d.Host}
+	}
+	if encryption == "tls" {
+		conn = tls.Client(conn, tlsConfig)
 	}
 
-	fmt.Println("Email sent successfully")
-	return nil
+	c, err := smtp.NewClient(conn, d.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if encryption == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS, use --smtp-encryption none to send unencrypted")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if auth := negotiateAuth(c, d); auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	send := gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		if err := c.Mail(from); err != nil {
+			return err
+		}
+		for _, addr := range to {
+			if err := c.Rcpt(addr); err != nil {
+				return err
+			}
+		}
+		w, err := c.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := msg.WriteTo(w); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	})
+	if err := gomail.Send(send, m); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// negotiateAuth returns the configured auth method or, like gomail, picks
+// one the server supports
+func negotiateAuth(c *smtp.Client, d *gomail.Dialer) smtp.Auth {
+	if d.Auth != nil || d.Username == "" {
+		return d.Auth
+	}
+	ok, auths := c.Extension("AUTH")
+	if !ok {
+		return nil
+	}
+	switch {
+	case strings.Contains(auths, "CRAM-MD5"):
+		return smtp.CRAMMD5Auth(d.Username, d.Password)
+	case strings.Contains(auths, "LOGIN") && !strings.Contains(auths, "PLAIN"):
+		return &loginAuth{username: d.Username, password: d.Password, host: d.Host}
+	default:
+		return smtp.PlainAuth("", d.Username, d.Password, d.Host)
+	}
+}
+
+// isTransientSMTPError reports whether a send error is worth retrying.
+// Network timeouts and 4xx SMTP replies are transient, everything else
+// (e.g. 535 authentication failures) is permanent.
+func isTransientSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
+
+// NewDialer creates an SMTP dialer honoring the configured encryption mode.
+// With "tls" the connection uses implicit TLS, otherwise the connection is
+// plain and, with "starttls", upgraded via STARTTLS by dialAndSend. Without
+// an explicit auth method, one the server supports is picked.
+func NewDialer(cfg *NotifyEmailConfig) *gomail.Dialer {
+	d := gomail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	switch cfg.SMTPEncryption {
+	case "tls":
+		d.SSL = true
+	case "starttls", "none":
+		d.SSL = false
+	}
+	switch cfg.SMTPAuthMethod {
+	case "plain":
+		d.Auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	case "login":
+		d.Auth = &loginAuth{username: cfg.SMTPUsername, password: cfg.SMTPPassword, host: cfg.SMTPHost}
+	case "xoauth2":
+		d.Auth = &xoauth2Auth{username: cfg.SMTPUsername, token: cfg.SMTPToken, host: cfg.SMTPHost}
+	}
+	if cfg.SMTPInsecureSkipVerify {
+		d.TLSConfig = &tls.Config{
+			ServerName:         cfg.SMTPHost,
+			InsecureSkipVerify: true,
+		}
+	}
+	return d
+}
+
+// SplitAddresses splits a comma-separated list of email addresses, trimming
+// whitespace and dropping empty entries
+func SplitAddresses(list string) []string {
+	var addresses []string
+	for _, addr := range strings.Split(list, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr != "" {
+			addresses = append(addresses, addr)
+		}
+	}
+	return addresses
 }
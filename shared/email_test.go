@@ -0,0 +1,443 @@
+package shared
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSubject(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("Hostname not available: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   *NotifyEmailConfig
+		expected string
+	}{
+		{name: "default", config: &NotifyEmailConfig{}, expected: "Backup Report: SUCCESS"},
+		{name: "prefix", config: &NotifyEmailConfig{SubjectPrefix: "[backup]"}, expected: "[backup] Backup Report: SUCCESS"},
+		{name: "hostname", config: &NotifyEmailConfig{HostnameInSubject: true}, expected: "Backup Report: SUCCESS (" + hostname + ")"},
+		{name: "both", config: &NotifyEmailConfig{SubjectPrefix: "[backup]", HostnameInSubject: true}, expected: "[backup] Backup Report: SUCCESS (" + hostname + ")"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSubject(tt.config, "Backup Report: SUCCESS"); got != tt.expected {
+				t.Errorf("FormatSubject() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildSubject(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("Hostname not available: %v", err)
+	}
+
+	cfg := &NotifyEmailConfig{
+		SMTPHost:        "smtp.example.com",
+		SMTPUsername:    "user",
+		SMTPPassword:    "pass",
+		From:            "from@example.com",
+		To:              "to@example.com",
+		SubjectTemplate: "{{if .Success}}[BACKUP OK]{{else}}[BACKUP FAIL]{{end}} {{.Hostname}} — {{.Failed}} of {{.Total}} jobs failed",
+	}
+	if err := ValidateNotifyEmailConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data := SubjectData{Success: false, Status: "FAILURE", Total: 5, Failed: 2}
+	subject, err := BuildSubject(cfg, "Backup Report: FAILURE", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[BACKUP FAIL] " + hostname + " — 2 of 5 jobs failed"; subject != expected {
+		t.Errorf("BuildSubject() = %q, want %q", subject, expected)
+	}
+
+	// Without a template the title is used
+	subject, err = BuildSubject(&NotifyEmailConfig{SubjectPrefix: "[backup]"}, "Backup Report: FAILURE", data)
+	if err != nil || subject != "[backup] Backup Report: FAILURE" {
+		t.Errorf("BuildSubject() = %q, %v, want default subject", subject, err)
+	}
+
+	cfg.SubjectTemplate = "{{.Status"
+	if err := ValidateNotifyEmailConfig(cfg); err == nil {
+		t.Error("Expected invalid subject template to fail validation")
+	}
+}
+
+func TestIsTransientSMTPError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network timeout", err: &net.OpError{Op: "dial", Err: timeoutError{}}, want: true},
+		{name: "wrapped timeout", err: fmt.Errorf("send: %w", &net.OpError{Op: "read", Err: timeoutError{}}), want: true},
+		{name: "temporary smtp reply", err: &textproto.Error{Code: 451, Msg: "try again later"}, want: true},
+		{name: "authentication failure", err: &textproto.Error{Code: 535, Msg: "authentication failed"}, want: false},
+		{name: "generic error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientSMTPError(tt.err); got != tt.want {
+				t.Errorf("isTransientSMTPError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestSendEmailTimeout(t *testing.T) {
+	// A server that accepts connections but never sends the SMTP greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	cfg := &NotifyEmailConfig{
+		SMTPHost:       "127.0.0.1",
+		SMTPPort:       addr.Port,
+		SMTPUsername:   "user",
+		SMTPPassword:   "pass",
+		SMTPEncryption: "none",
+		SMTPTimeout:    200 * time.Millisecond,
+		From:           "from@example.com",
+		To:             "to@example.com",
+	}
+	if err := ValidateNotifyEmailConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = SendEmail(cfg, "subject", "body", "", nil, false)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected SendEmail to return within the timeout, took %v", elapsed)
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	cfg := server.config()
+	if err := ValidateNotifyEmailConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SendEmail(cfg, "Backup Report", "all good", "", nil, false); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	select {
+	case msg := <-server.messages:
+		if !strings.Contains(msg.data, "Subject: Backup Report") || !strings.Contains(msg.data, "all good") {
+			t.Errorf("Unexpected message:\n%s", msg.data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the server to receive the message")
+	}
+}
+
+func TestSendEmailEncryption(t *testing.T) {
+	tests := []struct {
+		name        string
+		encryption  string
+		implicitTLS bool
+		offerTLS    bool
+		wantTLS     bool
+		wantErr     bool
+	}{
+		{name: "none ignores offered starttls", encryption: "none", offerTLS: true, wantTLS: false},
+		{name: "starttls", encryption: "starttls", offerTLS: true, wantTLS: true},
+		{name: "starttls not offered", encryption: "starttls", offerTLS: false, wantErr: true},
+		{name: "implicit tls", encryption: "tls", implicitTLS: true, wantTLS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+			if tt.offerTLS || tt.implicitTLS {
+				server.tlsConfig = selfSignedTLSConfig(t)
+			}
+			server.implicitTLS = tt.implicitTLS
+
+			cfg := server.config()
+			cfg.SMTPEncryption = tt.encryption
+			cfg.SMTPInsecureSkipVerify = true
+			cfg.SMTPTimeout = 2 * time.Second
+			if err := ValidateNotifyEmailConfig(cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			err := SendEmail(cfg, "subject", "body", "", nil, false)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+					t.Errorf("Expected missing STARTTLS error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SendEmail() error = %v", err)
+			}
+			if msg := <-server.messages; msg.tls != tt.wantTLS {
+				t.Errorf("Expected TLS=%v, got %v", tt.wantTLS, msg.tls)
+			}
+		})
+	}
+}
+
+func TestSendEmailTimeoutClosesConnection(t *testing.T) {
+	// A server that receives the message but never confirms it. The timed
+	// out attempt must not keep the session open in the background.
+	server := newFakeSMTPServer(t)
+	server.stallAfterData = true
+
+	cfg := server.config()
+	cfg.SMTPTimeout = 200 * time.Millisecond
+	if err := ValidateNotifyEmailConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SendEmail(cfg, "subject", "body", "", nil, false); err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+	select {
+	case <-server.closed:
+	case <-time.After(time.Second):
+		t.Error("Expected the connection to be closed when SendEmail returns")
+	}
+}
+
+// fakeSMTPServer is a minimal SMTP server accepting a single session. With
+// a TLS config it offers STARTTLS, or speaks implicit TLS with implicitTLS.
+type fakeSMTPServer struct {
+	listener       net.Listener
+	tlsConfig      *tls.Config
+	implicitTLS    bool
+	stallAfterData bool
+	messages       chan fakeSMTPMessage
+	closed         chan struct{}
+}
+
+// fakeSMTPMessage is a message received by fakeSMTPServer
+type fakeSMTPMessage struct {
+	data string
+	tls  bool
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSMTPServer{
+		listener: listener,
+		messages: make(chan fakeSMTPMessage, 1),
+		closed:   make(chan struct{}),
+	}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer close(s.closed)
+		defer conn.Close()
+		s.serve(conn, false)
+	}()
+	return s
+}
+
+// config returns an email config pointing at the server
+func (s *fakeSMTPServer) config() *NotifyEmailConfig {
+	return &NotifyEmailConfig{
+		SMTPHost:       "127.0.0.1",
+		SMTPPort:       s.listener.Addr().(*net.TCPAddr).Port,
+		SMTPUsername:   "user",
+		SMTPPassword:   "pass",
+		SMTPEncryption: "none",
+		From:           "from@example.com",
+		To:             "to@example.com",
+	}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn, secure bool) {
+	if s.implicitTLS && !secure {
+		s.serve(tls.Server(conn, s.tlsConfig), true)
+		return
+	}
+
+	tp := textproto.NewConn(conn)
+	if !secure || s.implicitTLS {
+		tp.PrintfLine("220 localhost ESMTP")
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb, _, _ := strings.Cut(line, " "); strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.tlsConfig != nil && !secure {
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 STARTTLS")
+			} else {
+				tp.PrintfLine("250 localhost")
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			s.serve(tls.Server(conn, s.tlsConfig), true)
+			return
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if s.stallAfterData {
+				// Wait for the client to give up
+				io.Copy(io.Discard, conn)
+				return
+			}
+			s.messages <- fakeSMTPMessage{data: string(data), tls: secure}
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+// selfSignedTLSConfig returns a server TLS config with a throwaway
+// certificate for 127.0.0.1
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestNewMessageFromName(t *testing.T) {
+	cfg := &NotifyEmailConfig{From: "backup@example.com", To: "admin@example.com"}
+
+	var buf bytes.Buffer
+	newMessage(cfg, "Subject", "body", "", nil).WriteTo(&buf)
+	if !strings.Contains(buf.String(), "From: backup@example.com\r\n") {
+		t.Errorf("Expected bare From address, got:\n%s", buf.String())
+	}
+
+	cfg.FromName = "Backup Bot"
+	buf.Reset()
+	newMessage(cfg, "Subject", "body", "", nil).WriteTo(&buf)
+	if !strings.Contains(buf.String(), `From: "Backup Bot" <backup@example.com>`) {
+		t.Errorf("Expected From header with display name, got:\n%s", buf.String())
+	}
+}
+
+func TestNewMessageInternationalizedAddresses(t *testing.T) {
+	cfg := &NotifyEmailConfig{
+		SMTPHost:     "smtp.example.com",
+		SMTPUsername: "user",
+		SMTPPassword: "pass",
+		From:         "backup@bücher.example",
+		To:           "backups+web01@example.com, admin@münchen.de",
+	}
+	if err := ValidateNotifyEmailConfig(cfg); err != nil {
+		t.Fatalf("Expected plus-addressing and unicode domains to be valid: %v", err)
+	}
+
+	var buf bytes.Buffer
+	newMessage(cfg, "Subject", "body", "", nil).WriteTo(&buf)
+	if !strings.Contains(buf.String(), "From: backup@xn--bcher-kva.example\r\n") {
+		t.Errorf("Expected punycode From address, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "To: backups+web01@example.com, admin@xn--mnchen-3ya.de\r\n") {
+		t.Errorf("Expected punycode To addresses, got:\n%s", buf.String())
+	}
+}
+
+func TestNewMessageReplyTo(t *testing.T) {
+	cfg := &NotifyEmailConfig{From: "backup@example.com", To: "admin@example.com"}
+
+	var buf bytes.Buffer
+	newMessage(cfg, "Subject", "body", "", nil).WriteTo(&buf)
+	if strings.Contains(buf.String(), "Reply-To:") {
+		t.Errorf("Expected no Reply-To header without --reply-to, got:\n%s", buf.String())
+	}
+
+	cfg.ReplyTo = "ops@example.com"
+	buf.Reset()
+	newMessage(cfg, "Subject", "body", "", nil).WriteTo(&buf)
+	if !strings.Contains(buf.String(), "Reply-To: ops@example.com\r\n") {
+		t.Errorf("Expected Reply-To header, got:\n%s", buf.String())
+	}
+
+	invalid := &NotifyEmailConfig{
+		SMTPHost:     "smtp.example.com",
+		SMTPUsername: "user",
+		SMTPPassword: "pass",
+		From:         "from@example.com",
+		To:           "to@example.com",
+		ReplyTo:      "not an address",
+	}
+	if err := ValidateNotifyEmailConfig(invalid); err == nil {
+		t.Error("Expected invalid reply-to address to be rejected")
+	}
+}
@@ -4,13 +4,50 @@ import (
 	"fmt"
 )
 
-// FormatBytes formats bytes into human readable format
+// ByteUnits selects how byte counts are formatted in reports
+type ByteUnits int
+
+const (
+	// ByteUnitsIEC uses base-1024 units, as restic itself does
+	ByteUnitsIEC ByteUnits = iota
+	// ByteUnitsSI uses base-1000 units, as most cloud provider dashboards do
+	ByteUnitsSI
+)
+
+var byteUnits = ByteUnitsIEC
+
+// SetByteUnits sets the units used by FormatBytes from a --byte-units value
+func SetByteUnits(units string) error {
+	switch units {
+	case "", "iec":
+		byteUnits = ByteUnitsIEC
+	case "si":
+		byteUnits = ByteUnitsSI
+	default:
+		return fmt.Errorf("byte-units must be either iec or si")
+	}
+	return nil
+}
+
+// FormatBytes formats bytes into human readable format using the units
+// selected with SetByteUnits
 func FormatBytes(bytes int64) string {
-	const unit = 1024
+	if byteUnits == ByteUnitsSI {
+		return FormatBytesSI(bytes)
+	}
+	return formatBytesBase(bytes, 1024)
+}
+
+// FormatBytesSI formats bytes into human readable format using base-1000 units
+func FormatBytesSI(bytes int64) string {
+	return formatBytesBase(bytes, 1000)
+}
+
+func formatBytesBase(bytes, unit int64) string {
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
 	}
-	div, exp := int64(unit), 0
+	div, exp := unit, 0
 	for n := bytes / unit; n >= unit; n /= unit {
 		div *= unit
 		exp++
@@ -0,0 +1,40 @@
+package shared
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		iec   string
+		si    string
+	}{
+		{0, "0 B", "0 B"},
+		{999, "999 B", "999 B"},
+		{1000, "1000 B", "1.0 KB"},
+		{1023, "1023 B", "1.0 KB"},
+		{1024, "1.0 KB", "1.0 KB"},
+		{1536, "1.5 KB", "1.5 KB"},
+		{1024 * 1024, "1.0 MB", "1.0 MB"},
+		{5 * 1000 * 1000 * 1000, "4.7 GB", "5.0 GB"},
+		{1 << 60, "1.0 EB", "1.2 EB"},
+	}
+
+	defer SetByteUnits("iec")
+	for _, tt := range tests {
+		SetByteUnits("iec")
+		if got := FormatBytes(tt.bytes); got != tt.iec {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.iec)
+		}
+		SetByteUnits("si")
+		if got := FormatBytes(tt.bytes); got != tt.si {
+			t.Errorf("FormatBytes(%d) with si = %q, want %q", tt.bytes, got, tt.si)
+		}
+		if got := FormatBytesSI(tt.bytes); got != tt.si {
+			t.Errorf("FormatBytesSI(%d) = %q, want %q", tt.bytes, got, tt.si)
+		}
+	}
+
+	if err := SetByteUnits("binary"); err == nil {
+		t.Error("Expected error for unknown byte units")
+	}
+}
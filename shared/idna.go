@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ToASCIIDomain converts an internationalized domain to its punycode form,
+// e.g. bücher.example to xn--bcher-kva.example, applying the UTS #46
+// mapping (lowercasing and NFC normalization). Domains with code points
+// IDNA disallows are rejected.
+func ToASCIIDomain(domain string) (string, error) {
+	if strings.Contains(domain, "..") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("empty label in domain %q", domain)
+	}
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode domain %q: %w", domain, err)
+	}
+	return ascii, nil
+}
+
+// NormalizeAddress parses an email address and converts its domain to
+// punycode. The local part, including any +tag, is kept as is.
+func NormalizeAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	at := strings.LastIndex(parsed.Address, "@")
+	if at < 0 {
+		return "", fmt.Errorf("missing domain in %q", address)
+	}
+	domain, err := ToASCIIDomain(parsed.Address[at+1:])
+	if err != nil {
+		return "", err
+	}
+	parsed.Address = parsed.Address[:at+1] + domain
+	if parsed.Name == "" {
+		return parsed.Address, nil
+	}
+	return parsed.String(), nil
+}
+
+// normalizeAddresses normalizes a comma-separated address list. Addresses
+// that cannot be normalized are kept unchanged, validation rejects them
+// before sending.
+func normalizeAddresses(list string) []string {
+	addresses := SplitAddresses(list)
+	for i, addr := range addresses {
+		if normalized, err := NormalizeAddress(addr); err == nil {
+			addresses[i] = normalized
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,64 @@
+package shared
+
+import "testing"
+
+func TestToASCIIDomain(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM", "example.com"},
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"münchen.de", "xn--mnchen-3ya.de"},
+		{"例え.jp", "xn--r8jz45g.jp"},
+		// Decomposed ü (u + combining diaeresis) is normalized first
+		{"bu\u0308cher.example", "xn--bcher-kva.example"},
+	}
+	for _, tt := range tests {
+		got, err := ToASCIIDomain(tt.domain)
+		if err != nil {
+			t.Errorf("ToASCIIDomain(%q) error = %v", tt.domain, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ToASCIIDomain(%q) = %q, want %q", tt.domain, got, tt.expected)
+		}
+	}
+
+	if _, err := ToASCIIDomain("example..com"); err == nil {
+		t.Error("Expected error for empty label")
+	}
+	for _, domain := range []string{"exa mple.com", "back_up.example"} {
+		if _, err := ToASCIIDomain(domain); err == nil {
+			t.Errorf("Expected error for disallowed code point in %q", domain)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{"backups+web01@example.com", "backups+web01@example.com"},
+		{"admin@bücher.example", "admin@xn--bcher-kva.example"},
+		{"backups+nas@münchen.de", "backups+nas@xn--mnchen-3ya.de"},
+		{"Backup Admin <admin@bücher.example>", `"Backup Admin" <admin@xn--bcher-kva.example>`},
+		{"admin@bu\u0308cher.example", "admin@xn--bcher-kva.example"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeAddress(tt.address)
+		if err != nil {
+			t.Errorf("NormalizeAddress(%q) error = %v", tt.address, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("NormalizeAddress(%q) = %q, want %q", tt.address, got, tt.expected)
+		}
+	}
+
+	if _, err := NormalizeAddress("not an address"); err == nil {
+		t.Error("Expected error for invalid address")
+	}
+}
@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// LogLevel controls how much routine output is printed
+type LogLevel int
+
+const (
+	// LogQuiet suppresses routine success messages
+	LogQuiet LogLevel = iota
+	// LogNormal is the default output
+	LogNormal
+	// LogVerbose additionally prints details such as parsed files and HTTP attempts
+	LogVerbose
+)
+
+var logLevel = LogNormal
+
+// logOutput is where messages are printed, nil means stdout
+var logOutput io.Writer
+
+// SetLogLevel sets the level used by Infof and Verbosef
+func SetLogLevel(level LogLevel) {
+	logLevel = level
+}
+
+// SetLogOutput redirects messages and dry-run previews, e.g. to stderr when
+// stdout carries machine-readable output. It returns the previous writer,
+// nil restores stdout.
+func SetLogOutput(w io.Writer) io.Writer {
+	previous := logOutput
+	logOutput = w
+	return previous
+}
+
+// LogOutput returns the writer messages and dry-run previews are printed to
+func LogOutput() io.Writer {
+	if logOutput == nil {
+		return os.Stdout
+	}
+	return logOutput
+}
+
+// Infof prints a routine message unless running with --quiet
+func Infof(format string, args ...interface{}) {
+	if logLevel >= LogNormal {
+		fmt.Fprintf(LogOutput(), format, args...)
+	}
+}
+
+// Verbosef prints a detail message only when running with --verbose
+func Verbosef(format string, args ...interface{}) {
+	if logLevel >= LogVerbose {
+		fmt.Fprintf(LogOutput(), format, args...)
+	}
+}
@@ -0,0 +1,58 @@
+package shared
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	defer SetLogLevel(LogNormal)
+
+	tests := []struct {
+		name  string
+		level LogLevel
+		want  string
+	}{
+		{name: "quiet", level: LogQuiet, want: ""},
+		{name: "normal", level: LogNormal, want: "info\n"},
+		{name: "verbose", level: LogVerbose, want: "info\nverbose\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLogLevel(tt.level)
+
+			// Capture stdout for validation
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			Infof("info\n")
+			Verbosef("verbose\n")
+
+			w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			buf.ReadFrom(r)
+			if buf.String() != tt.want {
+				t.Errorf("output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetLogOutput(t *testing.T) {
+	var buf bytes.Buffer
+	previous := SetLogOutput(&buf)
+	defer SetLogOutput(previous)
+
+	Infof("info\n")
+	if buf.String() != "info\n" {
+		t.Errorf("output = %q, want %q", buf.String(), "info\n")
+	}
+	if SetLogOutput(nil); LogOutput() != os.Stdout {
+		t.Error("Expected nil to restore stdout")
+	}
+}
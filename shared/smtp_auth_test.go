@@ -0,0 +1,68 @@
+package shared
+
+import (
+	"net/smtp"
+	"testing"
+)
+
+func TestXOAuth2Auth(t *testing.T) {
+	auth := &xoauth2Auth{username: "user@example.com", token: "ya29.token", host: "smtp.gmail.com"}
+
+	mech, resp, err := auth.Start(&smtp.ServerInfo{Name: "smtp.gmail.com", TLS: true})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if mech != "XOAUTH2" {
+		t.Errorf("Expected XOAUTH2 mechanism, got %q", mech)
+	}
+	if want := "user=user@example.com\x01auth=Bearer ya29.token\x01\x01"; string(resp) != want {
+		t.Errorf("Start() response = %q, want %q", resp, want)
+	}
+
+	// The JSON error challenge is answered with an empty response
+	next, err := auth.Next([]byte(`{"status":"400"}`), true)
+	if err != nil || next == nil || len(next) != 0 {
+		t.Errorf("Next() = %q, %v, want empty response", next, err)
+	}
+
+	// Tokens are never sent over unencrypted connections
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.gmail.com"}); err == nil {
+		t.Error("Expected error for unencrypted connection")
+	}
+}
+
+func TestLoginAuth(t *testing.T) {
+	auth := &loginAuth{username: "user", password: "secret", host: "localhost"}
+
+	mech, _, err := auth.Start(&smtp.ServerInfo{Name: "localhost"})
+	if err != nil || mech != "LOGIN" {
+		t.Fatalf("Start() = %q, %v", mech, err)
+	}
+	if resp, err := auth.Next([]byte("Username:"), true); err != nil || string(resp) != "user" {
+		t.Errorf("Next(Username:) = %q, %v", resp, err)
+	}
+	if resp, err := auth.Next([]byte("Password:"), true); err != nil || string(resp) != "secret" {
+		t.Errorf("Next(Password:) = %q, %v", resp, err)
+	}
+	if _, err := auth.Next([]byte("Other:"), true); err == nil {
+		t.Error("Expected error for unexpected challenge")
+	}
+}
+
+func TestNewDialerAuthMethod(t *testing.T) {
+	cfg := &NotifyEmailConfig{SMTPHost: "smtp.example.com", SMTPUsername: "user", SMTPPassword: "pass"}
+	if d := NewDialer(cfg); d.Auth != nil {
+		t.Errorf("Expected negotiated auth without an auth method, got %T", d.Auth)
+	}
+
+	cfg.SMTPAuthMethod = "xoauth2"
+	cfg.SMTPToken = "token"
+	if _, ok := NewDialer(cfg).Auth.(*xoauth2Auth); !ok {
+		t.Error("Expected XOAUTH2 auth")
+	}
+
+	cfg.SMTPAuthMethod = "login"
+	if _, ok := NewDialer(cfg).Auth.(*loginAuth); !ok {
+		t.Error("Expected LOGIN auth")
+	}
+}
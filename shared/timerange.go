@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeRange limits reports to a period of time. A zero Since or Until leaves
+// that side open.
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseTimeRange parses --since and --until values, each either an RFC3339
+// timestamp or a duration like 24h relative to now
+func ParseTimeRange(since, until string, now time.Time) (TimeRange, error) {
+	var r TimeRange
+	var err error
+	if since != "" {
+		if r.Since, err = parseTimeExpression(since, now); err != nil {
+			return r, fmt.Errorf("invalid since %q: %w", since, err)
+		}
+	}
+	if until != "" {
+		if r.Until, err = parseTimeExpression(until, now); err != nil {
+			return r, fmt.Errorf("invalid until %q: %w", until, err)
+		}
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && r.Until.Before(r.Since) {
+		return r, fmt.Errorf("until must not be before since")
+	}
+	return r, nil
+}
+
+func parseTimeExpression(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration must be non-negative")
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 time or a duration like 24h")
+	}
+	return t, nil
+}
+
+// Contains reports whether t lies within the range
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && t.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && t.After(r.Until) {
+		return false
+	}
+	return true
+}
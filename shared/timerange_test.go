@@ -0,0 +1,33 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+
+	r, err := ParseTimeRange("24h", "2025-10-30T11:00:00Z", now)
+	if err != nil {
+		t.Fatalf("ParseTimeRange() error = %v", err)
+	}
+	if !r.Since.Equal(now.Add(-24*time.Hour)) || !r.Until.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected range: %+v", r)
+	}
+	if !r.Contains(now.Add(-2*time.Hour)) || r.Contains(now.Add(-25*time.Hour)) || r.Contains(now) {
+		t.Error("Contains() does not match the range")
+	}
+
+	// An empty range contains everything
+	r, err = ParseTimeRange("", "", now)
+	if err != nil || !r.Contains(time.Time{}) || !r.Contains(now) {
+		t.Errorf("Expected an open range, got %+v (%v)", r, err)
+	}
+
+	for _, tt := range [][2]string{{"yesterday", ""}, {"", "-1h"}, {"1h", "2h"}} {
+		if _, err := ParseTimeRange(tt[0], tt[1], now); err == nil {
+			t.Errorf("Expected error for since %q until %q", tt[0], tt[1])
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package shared
+
+// Build information, injected at build time with
+// -ldflags "-X restic-kit/shared.Version=... -X restic-kit/shared.Commit=... -X restic-kit/shared.BuildDate=..."
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// VersionString returns the version, falling back to "dev" for builds
+// without injected build information
+func VersionString() string {
+	if Version == "" {
+		return "dev"
+	}
+	return Version
+}
+
+// UserAgent returns the User-Agent header sent with HTTP requests
+func UserAgent() string {
+	return "restic-kit/" + VersionString()
+}
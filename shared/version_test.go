@@ -0,0 +1,18 @@
+package shared
+
+import "testing"
+
+func TestUserAgent(t *testing.T) {
+	oldVersion := Version
+	defer func() { Version = oldVersion }()
+
+	Version = ""
+	if got := UserAgent(); got != "restic-kit/dev" {
+		t.Errorf("Expected default user agent restic-kit/dev, got %q", got)
+	}
+
+	Version = "1.2.3"
+	if got := UserAgent(); got != "restic-kit/1.2.3" {
+		t.Errorf("Expected user agent restic-kit/1.2.3, got %q", got)
+	}
+}
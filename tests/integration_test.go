@@ -251,10 +251,10 @@ func TestCLINotifyEmail(t *testing.T) {
 	if !contains(outputStr, "Repository Snapshots: 1") {
 		t.Errorf("Expected snapshots count not found in output: %s", outputStr)
 	}
-	if !contains(outputStr, "Date & Time          |      New | Modified |  Total Files |   Added Size |   Total Size") {
+	if !contains(outputStr, "Date & Time          | Snapshot |      New | Modified |  Total Files |   Added Size |   Total Size") {
 		t.Errorf("Expected snapshot table header not found in output: %s", outputStr)
 	}
-	if !contains(outputStr, "-------------------- | -------- | -------- | ------------ | ------------ | ------------") {
+	if !contains(outputStr, "-------------------- | -------- | -------- | -------- | ------------ | ------------ | ------------") {
 		t.Errorf("Expected snapshot table separator not found in output: %s", outputStr)
 	}
 	if !contains(outputStr, "✅ forget") {
@@ -265,6 +265,137 @@ func TestCLINotifyEmail(t *testing.T) {
 	}
 }
 
+func TestCLIConfigFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cli-config-test*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	os.WriteFile(filepath.Join(tmpDir, "check.exitcode"), []byte("0"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "check.out"), []byte(`{"message_type":"summary","num_errors":0}`), 0644)
+
+	configFile := filepath.Join(tmpDir, "restic-kit.yaml")
+	os.WriteFile(configFile, []byte(`smtp:
+  host: localhost
+  port: 2525
+  username: test
+  password: secret
+  from: test@example.com
+  to:
+    - one@example.com
+    - two@example.com
+notify-email:
+  format: text
+`), 0644)
+
+	// Build the binary
+	binaryPath := filepath.Join(os.TempDir(), "restic-kit-test")
+	cmd := exec.Command("go", "build", "-o", binaryPath, "./cmd")
+	cmd.Dir = ".." // Go back to project root
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove(binaryPath)
+
+	// All required SMTP flags come from the config file
+	cmd = exec.Command(binaryPath, "notify-email", "--dry-run", "--config", configFile, tmpDir)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("CLI command failed: %v, output: %s", err, string(output))
+	}
+	if !contains(string(output), "Backup Report: SUCCESS") {
+		t.Errorf("Expected report in output, got: %s", string(output))
+	}
+
+	// Explicit flags override config file values and validation still runs
+	cmd = exec.Command(binaryPath, "notify-email", "--dry-run", "--config", configFile, "--format", "pdf", tmpDir)
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Errorf("Expected invalid format flag to fail, output: %s", string(output))
+	}
+
+	// Environment variables are expanded, unset ones are reported by name
+	envConfigFile := filepath.Join(tmpDir, "env.yaml")
+	os.WriteFile(envConfigFile, []byte(`smtp:
+  host: localhost
+  username: test
+  password: ${RESTIC_KIT_TEST_PASSWORD}
+  from: test@example.com
+  to: ${RESTIC_KIT_TEST_TO}
+`), 0644)
+	cmd = exec.Command(binaryPath, "notify-email", "--dry-run", "--config", envConfigFile, tmpDir)
+	cmd.Env = append(os.Environ(), "RESTIC_KIT_TEST_PASSWORD=secret", "RESTIC_KIT_TEST_TO=one@example.com")
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Errorf("Expected expanded config to work, got: %v, output: %s", err, string(output))
+	}
+	cmd = exec.Command(binaryPath, "notify-email", "--dry-run", "--config", envConfigFile, tmpDir)
+	cmd.Env = append(os.Environ(), "RESTIC_KIT_TEST_TO=one@example.com")
+	output, err = cmd.CombinedOutput()
+	if err == nil || !contains(string(output), "RESTIC_KIT_TEST_PASSWORD is not set") {
+		t.Errorf("Expected unset variable error, got: %v, output: %s", err, string(output))
+	}
+
+	// Unknown keys are rejected
+	badConfigFile := filepath.Join(tmpDir, "bad.yaml")
+	os.WriteFile(badConfigFile, []byte("notify-http:\n  uri: https://example.com\n"), 0644)
+	cmd = exec.Command(binaryPath, "notify-http", "--config", badConfigFile, tmpDir)
+	output, err = cmd.CombinedOutput()
+	if err == nil || !contains(string(output), `unknown option "uri"`) {
+		t.Errorf("Expected unknown option error, got: %v, output: %s", err, string(output))
+	}
+}
+
+func TestCLIRun(t *testing.T) {
+	logDir := t.TempDir()
+
+	// Build the binary
+	binaryPath := filepath.Join(os.TempDir(), "restic-kit-test-run")
+	cmd := exec.Command("go", "build", "-o", binaryPath, "./cmd")
+	cmd.Dir = ".." // Go back to project root
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove(binaryPath)
+
+	cmd = exec.Command(binaryPath, "run", "--log-dir", logDir, "backup.etc", "--", "sh", "-c", "echo '{\"message_type\":\"summary\"}'; exit 3")
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 3 {
+		t.Fatalf("Expected exit code 3, got %v, output: %s", err, output)
+	}
+
+	content, err := os.ReadFile(filepath.Join(logDir, "backup.etc.exitcode"))
+	if err != nil || strings.TrimSpace(string(content)) != "3" {
+		t.Errorf("Expected exit code file with 3, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, "backup.etc.out")); err != nil {
+		t.Errorf("Expected out file: %v", err)
+	}
+}
+
+func TestCLIVersion(t *testing.T) {
+	// Build the binary with injected version information
+	binaryPath := filepath.Join(os.TempDir(), "restic-kit-test-version")
+	ldflags := "-X restic-kit/shared.Version=1.2.3 -X restic-kit/shared.Commit=abc1234 -X restic-kit/shared.BuildDate=2025-01-02T03:04:05Z"
+	cmd := exec.Command("go", "build", "-ldflags", ldflags, "-o", binaryPath, "./cmd")
+	cmd.Dir = ".." // Go back to project root
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer os.Remove(binaryPath)
+
+	output, err := exec.Command(binaryPath, "version").CombinedOutput()
+	if err != nil {
+		t.Fatalf("CLI command failed: %v, output: %s", err, output)
+	}
+	expected := "restic-kit 1.2.3 (commit abc1234, built 2025-01-02T03:04:05Z)\n"
+	if string(output) != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
 func contains(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
 		if s[i:i+len(substr)] == substr {